@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/borderzero/border0-cli/internal/profile"
 )
 
 func Read() (*Data, error) {
@@ -39,6 +41,10 @@ func Write(data *Data) error {
 	if err != nil {
 		return fmt.Errorf("failed to get preference file path: %w", err)
 	}
+	// profiles other than the default keep their preferences in a directory of their own
+	if err := os.MkdirAll(filepath.Dir(pathToFile), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(pathToFile), err)
+	}
 	jsonFile, err := os.Create(pathToFile)
 	if err != nil {
 		fmt.Println(err)
@@ -70,7 +76,7 @@ func filePath() (string, error) {
 
 	border0ConfigDir := filepath.Join(userConfigDir, "border0")
 	if fileOrPathExists(border0ConfigDir) {
-		return filepath.Join(border0ConfigDir, "preference.json"), nil
+		return profile.Path(border0ConfigDir, "preference.json"), nil
 	}
 
 	home, err := osUserHomeDir()
@@ -85,7 +91,7 @@ func filePath() (string, error) {
 		}
 	}
 
-	return filepath.Join(dotBorder0Dir, "preference.json"), nil
+	return profile.Path(dotBorder0Dir, "preference.json"), nil
 }
 
 func fileOrPathExists(fileOrPath string) bool {
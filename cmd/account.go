@@ -19,7 +19,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/http"
@@ -99,26 +98,7 @@ var switchOrg = &cobra.Command{
 
 		fmt.Printf("Switching to organization: %s\n", val.OrgName)
 
-		// create dir if not exists
-		configPath := filepath.Dir(http.TokenFilePath())
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			if err := os.Mkdir(configPath, 0700); err != nil {
-				log.Fatalf("failed to create directory %s : %s", configPath, err)
-			}
-		}
-
-		f, err := os.Create(http.TokenFilePath())
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		if err := os.Chmod(http.TokenFilePath(), 0600); err != nil {
-			log.Fatal(err)
-		}
-
-		defer f.Close()
-		_, err = f.WriteString(fmt.Sprintf("%s\n", val.Token))
-		if err != nil {
+		if err := http.SaveTokenInDisk(val.Token); err != nil {
 			log.Fatal(err)
 		}
 	},
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestFiles []string
+	applyPrune    bool
+	applyRotate   bool
+	applyDryRun   bool
+	applyYes      bool
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Make the organization match yaml manifests of sockets, policies, notifications, domains and identity providers",
+	Long: `Make the organization match yaml manifests of sockets, policies, notifications, domains and identity providers.
+
+The manifests are compared with the organization and the plan of changes is shown before it's applied.
+Fields that aren't set in a manifest are left unchanged. Secrets can be read from the environment with env:NAME.
+Secrets the api doesn't return are sent when other fields of their object change, use --rotate_secrets to
+update them on their own.
+With --prune only the kinds of objects the manifests list are pruned, list a kind as empty, like
+notifications: [], to delete all of them.
+
+Example manifest:
+
+  sockets:
+    - name: prod-db
+      socket_type: database
+      upstream_type: postgres
+      upstream_username: border0
+      upstream_password: env:PROD_DB_PASSWORD
+      policies: [engineering]
+  policies:
+    - name: engineering
+      policy_data:
+        action: [database, ssh]
+        condition:
+          who:
+            domain: [example.com]
+  notifications:
+    - name: slack
+      type: webhook
+      webhook_url: https://hooks.example.com/border0
+      events: [login]
+  domains:
+    - domain: example.com
+      default: true
+  identity_providers:
+    - name: google
+      enabled: true`,
+	Run: func(cmd *cobra.Command, args []string) {
+		desired, err := manifest.Load(manifestFiles...)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		client, err := http.NewClient()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		current, err := manifest.Fetch(client)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		plan, err := manifest.NewPlan(desired, current, applyPrune, applyRotate)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		fmt.Print(plan.String())
+		if plan.Empty() || applyDryRun {
+			return
+		}
+
+		if !applyYes {
+			confirmed := false
+			if err := survey.AskOne(&survey.Confirm{Message: "Apply these changes?"}, &confirmed); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if !confirmed {
+				fmt.Println("Apply cancelled")
+				return
+			}
+		}
+
+		err = plan.Apply(client, func(c manifest.Change) {
+			switch c.Action {
+			case manifest.ActionAttach:
+				fmt.Printf("policy %s attached to socket %s\n", c.Name, c.Socket)
+			case manifest.ActionDetach:
+				fmt.Printf("policy %s detached from socket %s\n", c.Name, c.Socket)
+			default:
+				fmt.Printf("%s %s %sd\n", c.Kind, c.Name, c.Action)
+			}
+		})
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		fmt.Println("Apply complete")
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringArrayVarP(&manifestFiles, "filename", "f", nil, "Manifest file or directory of manifests, can be repeated")
+	applyCmd.Flags().BoolVarP(&applyPrune, "prune", "", false, "Delete objects that aren't in the manifests, of the kinds the manifests list, sockets created by connectors are kept")
+	applyCmd.Flags().BoolVarP(&applyRotate, "rotate_secrets", "", false, "Update the secrets set in the manifests that the api doesn't return, even when nothing else changed")
+	applyCmd.Flags().BoolVarP(&applyDryRun, "dry_run", "", false, "Only show the plan")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Apply without asking for confirmation")
+	applyCmd.MarkFlagRequired("filename")
+
+	rootCmd.AddCommand(applyCmd)
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/borderzero/border0-cli/client/preference"
 	"github.com/borderzero/border0-cli/cmd/client/db"
+	"github.com/borderzero/border0-cli/cmd/client/desktop"
+	clientProxy "github.com/borderzero/border0-cli/cmd/client/proxy"
+	clientTcp "github.com/borderzero/border0-cli/cmd/client/tcp"
 	clientTls "github.com/borderzero/border0-cli/cmd/client/tls"
 
 	"github.com/borderzero/border0-cli/cmd/client/hosts"
@@ -143,4 +146,7 @@ func init() {
 	hosts.AddCommandsTo(clientCmd)
 	ssh.AddCommandsTo(clientCmd)
 	clientTls.AddCommandsTo(clientCmd)
+	clientTcp.AddCommandsTo(clientCmd)
+	desktop.AddCommandsTo(clientCmd)
+	clientProxy.AddCommandsTo(clientCmd)
 }
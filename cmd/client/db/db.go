@@ -22,6 +22,9 @@ func AddCommandsTo(client *cobra.Command) {
 	addOneCommandTo(dbeaverCmd, client)
 	addOneCommandTo(psqlCmd, client)
 	addOneCommandTo(pgcliCmd, client)
+	addOneCommandTo(redisCliCmd, client)
+	addOneCommandTo(mongoshCmd, client)
+	addOneCommandTo(sqlcmdCmd, client)
 }
 
 func addOneCommandTo(cmdToAdd, cmdAddedTo *cobra.Command) {
@@ -74,12 +77,21 @@ var dbCmd = &cobra.Command{
 			dbClients           []string
 			dbClientsMySQL      = []string{"mysql", "mysqlworkbench", "mycli", "dbeaver"}
 			dbClientsPostgreSQL = []string{"psql", "pgcli"}
+			dbClientsRedis      = []string{"redis-cli"}
+			dbClientsMongoDB    = []string{"mongosh"}
+			dbClientsMSSQL      = []string{"sqlcmd"}
 		)
 		switch pickedHost.DatabaseType {
 		case "mysql":
 			dbClients = dbClientsMySQL
 		case "postgres":
 			dbClients = dbClientsPostgreSQL
+		case "redis":
+			dbClients = dbClientsRedis
+		case "mongodb":
+			dbClients = dbClientsMongoDB
+		case "mssql":
+			dbClients = dbClientsMSSQL
 		default:
 			dbClients = dbClientsMySQL
 		}
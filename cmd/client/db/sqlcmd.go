@@ -0,0 +1,82 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/borderzero/border0-cli/client/preference"
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/enum"
+	"github.com/spf13/cobra"
+)
+
+var sqlcmdCmd = &cobra.Command{
+	Use:   "db:sqlcmd",
+	Short: "Connect to a database socket with sqlcmd client",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		pickedHost, err := client.PickHost(hostname, enum.DatabaseSocket)
+		if err != nil {
+			return err
+		}
+		hostname = pickedHost.Hostname()
+
+		// Let's read preferences from the config file
+		pref, err := preference.Read()
+		if err != nil {
+			fmt.Println("WARNING: could not read preference file:", err)
+		}
+		socketPref := preference.NewDatabaseSocket(hostname)
+
+		var suggestedDBName string
+
+		dbName := dbNameFrom(args)
+		if dbName == "" {
+			suggestedSocket := pref.GetOrSuggestSocket(hostname, enum.DatabaseSocket)
+			if preference.Found(suggestedSocket) {
+				suggestedDBName = suggestedSocket.DatabaseName
+				socketPref = suggestedSocket
+			}
+		}
+
+		dbName, err = client.EnterDBName(dbName, suggestedDBName)
+		if err != nil {
+			return err
+		}
+
+		socketPref.DatabaseName = dbName
+		socketPref.DatabaseClient = "sqlcmd"
+		pref.SetSocket(socketPref)
+
+		info, err := client.GetResourceInfo(hostname)
+		if err != nil {
+			return err
+		}
+
+		persistPreference := func() {
+			// persist preference to json file
+			if err == nil {
+				if err := preference.Write(pref); err != nil {
+					fmt.Println("WARNING: could not update preference file:", err)
+				}
+			}
+		}
+		// make sure we will persist preference on successful connection to socket
+		defer persistPreference()
+		client.OnInterruptDo(persistPreference)
+
+		// sqlcmd connects to a local listener, which presents the client
+		// certificate to the socket on its behalf
+		info.Port, err = client.StartLocalTLSListener(fmt.Sprintf("%s:%d", hostname, info.Port), info.SetupTLSCertificate(), info.ConnectorAuthenticationEnabled, 0)
+		if err != nil {
+			fmt.Println("ERROR: could not setup listener:", err)
+			return err
+		}
+
+		sqlcmdArgs := []string{"-S", fmt.Sprintf("tcp:localhost,%d", info.Port)}
+		if dbName != "" {
+			sqlcmdArgs = append(sqlcmdArgs, "-d", dbName)
+		}
+
+		return client.ExecCommand("sqlcmd", sqlcmdArgs...)
+	},
+}
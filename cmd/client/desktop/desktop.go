@@ -0,0 +1,101 @@
+package desktop
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/enum"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostname string
+	username string
+	listener int
+)
+
+func AddCommandsTo(client *cobra.Command) {
+	client.AddCommand(rdpCmd)
+	rdpCmd.Flags().StringVarP(&hostname, "host", "", "", "The border0 target host")
+	rdpCmd.Flags().StringVarP(&username, "username", "u", "", "Username to log in with")
+	rdpCmd.Flags().IntVarP(&listener, "listener", "l", 0, "Listener port number, a random port is used when not set")
+
+	client.AddCommand(vncCmd)
+	vncCmd.Flags().StringVarP(&hostname, "host", "", "", "The border0 target host")
+	vncCmd.Flags().IntVarP(&listener, "listener", "l", 0, "Listener port number, a random port is used when not set")
+}
+
+// startListener picks the host when none is given and starts a local listener
+// for it, desktop clients can't present the client certificate themselves
+func startListener(args []string) (int, error) {
+	if len(args) > 0 {
+		hostname = args[0]
+	}
+
+	if hostname == "" {
+		pickedHost, err := client.PickHost(hostname, enum.TCPSocket, enum.TLSSocket)
+		if err != nil {
+			return 0, err
+		}
+		hostname = pickedHost.Hostname()
+	}
+
+	info, err := client.GetResourceInfo(hostname)
+	if err != nil {
+		return 0, err
+	}
+
+	port, err := client.StartLocalTLSListener(fmt.Sprintf("%s:%d", hostname, info.Port), info.SetupTLSCertificate(), info.ConnectorAuthenticationEnabled, listener)
+	if err != nil {
+		return 0, fmt.Errorf("could not start listener: %w", err)
+	}
+
+	return port, nil
+}
+
+// writeConnectionFile writes a connection file to ~/.border0/<dir>
+func writeConnectionFile(dir, name, contents string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir : %w", err)
+	}
+
+	configPath := filepath.Join(home, ".border0", dir)
+	if err := os.MkdirAll(configPath, 0700); err != nil {
+		return "", fmt.Errorf("failed to create directory %s : %w", configPath, err)
+	}
+
+	path := filepath.Join(configPath, name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		return "", fmt.Errorf("failed writing connection file %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// launch starts the first client that is installed, it reports false when
+// none of them is
+func launch(clients ...[]string) (bool, error) {
+	for _, c := range clients {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		return true, exec.Command(c[0], c[1:]...).Start()
+	}
+
+	return false, nil
+}
+
+// waitForInterrupt keeps the listener running until the user stops it
+func waitForInterrupt() {
+	fmt.Println("Press ctrl+c to close the connection")
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	<-ch
+}
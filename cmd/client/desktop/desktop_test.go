@@ -0,0 +1,37 @@
+package desktop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRdpFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     int
+		username string
+		want     string
+	}{
+		{
+			name: "without username",
+			port: 50123,
+			want: "full address:s:localhost:50123\r\nprompt for credentials:i:1\r\nauthentication level:i:2\r\n",
+		},
+		{
+			name:     "with username",
+			port:     3389,
+			username: "Administrator",
+			want:     "full address:s:localhost:3389\r\nprompt for credentials:i:1\r\nauthentication level:i:2\r\nusername:s:Administrator\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rdpFile(tt.port, tt.username))
+		})
+	}
+}
+
+func TestVncFile(t *testing.T) {
+	assert.Equal(t, "[Connection]\r\nHost=localhost:5901\r\n", vncFile(5901))
+}
@@ -0,0 +1,67 @@
+package desktop
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var rdpCmd = &cobra.Command{
+	Use:               "rdp",
+	Short:             "Connect to a border0 socket with a remote desktop client",
+	ValidArgsFunction: client.AutocompleteHost,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		port, err := startListener(args)
+		if err != nil {
+			return err
+		}
+
+		path, err := writeConnectionFile("rdp", hostname+".rdp", rdpFile(port, username))
+		if err != nil {
+			return err
+		}
+
+		var launched bool
+		switch runtime.GOOS {
+		case "darwin":
+			launched, err = launch([]string{"open", path})
+		case "windows":
+			launched, err = launch([]string{"mstsc", path})
+		default:
+			xfreerdp := []string{"xfreerdp", fmt.Sprintf("/v:localhost:%d", port)}
+			if username != "" {
+				xfreerdp = append(xfreerdp, "/u:"+username)
+			}
+			launched, err = launch(xfreerdp, []string{"remmina", "-c", path})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to start remote desktop client: %w", err)
+		}
+
+		if !launched {
+			fmt.Println("No remote desktop client found, open this file with your client:", path)
+		} else {
+			fmt.Println("Starting up remote desktop client...")
+		}
+
+		waitForInterrupt()
+		return nil
+	},
+}
+
+// rdpFile returns the contents of a .rdp file connecting to the local listener
+func rdpFile(port int, username string) string {
+	lines := []string{
+		fmt.Sprintf("full address:s:localhost:%d", port),
+		"prompt for credentials:i:1",
+		"authentication level:i:2",
+	}
+	if username != "" {
+		lines = append(lines, "username:s:"+username)
+	}
+
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
@@ -0,0 +1,57 @@
+package desktop
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/spf13/cobra"
+)
+
+var vncCmd = &cobra.Command{
+	Use:               "vnc",
+	Short:             "Connect to a border0 socket with a VNC client",
+	ValidArgsFunction: client.AutocompleteHost,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		port, err := startListener(args)
+		if err != nil {
+			return err
+		}
+
+		path, err := writeConnectionFile("vnc", hostname+".vnc", vncFile(port))
+		if err != nil {
+			return err
+		}
+
+		address := fmt.Sprintf("localhost::%d", port)
+
+		var launched bool
+		switch runtime.GOOS {
+		case "darwin":
+			// the built in screen sharing app handles vnc urls
+			launched, err = launch([]string{"open", fmt.Sprintf("vnc://localhost:%d", port)})
+		case "windows":
+			launched, err = launch([]string{"vncviewer", path}, []string{"tvnviewer", address})
+		default:
+			launched, err = launch([]string{"vncviewer", address}, []string{"remmina", "-c", fmt.Sprintf("vnc://localhost:%d", port)})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to start VNC client: %w", err)
+		}
+
+		if !launched {
+			fmt.Println("No VNC client found, open this file with your client:", path)
+		} else {
+			fmt.Println("Starting up VNC client...")
+		}
+
+		waitForInterrupt()
+		return nil
+	},
+}
+
+// vncFile returns the contents of a .vnc connection file connecting to the
+// local listener
+func vncFile(port int) string {
+	return fmt.Sprintf("[Connection]\r\nHost=localhost:%d\r\n", port)
+}
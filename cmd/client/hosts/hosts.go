@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/output"
 	"github.com/fatih/color"
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
@@ -39,23 +41,28 @@ var hostsCmd = &cobra.Command{
 
 		blue := color.New(color.FgBlue)
 
-		tbl := table.NewWriter()
-		tbl.AppendHeader(table.Row{"DNS Name", "Type", "Description"})
-		for _, res := range resources.Resources {
-			instruction := res.Instruction()
-			if instruction != "" {
-				instruction = "\n" + blue.Sprint(instruction)
-			}
-			tbl.AppendRow(table.Row{
-				res.DomainsToString(),
-				strings.ToUpper(res.SocketType),
-				strings.Split(res.Description, ";")[0] + instruction,
-			})
+		columns := []output.Column[models.ClientResource]{
+			{Field: "domains", Header: "DNS Name", Value: func(res models.ClientResource) interface{} { return res.DomainsToString() }},
+			{Field: "socket_type", Header: "Type", Value: func(res models.ClientResource) interface{} { return strings.ToUpper(res.SocketType) }},
+			{Field: "socket_ports", Header: "Port(s)", Wide: true, Value: func(res models.ClientResource) interface{} { return fmt.Sprint(res.SocketPorts) }},
+			{Field: "ip_address", Header: "IP Address", Wide: true, Value: func(res models.ClientResource) interface{} { return res.IPAddress }},
+			{Field: "description", Header: "Description", Value: func(res models.ClientResource) interface{} {
+				instruction := res.Instruction()
+				if instruction != "" {
+					instruction = "\n" + blue.Sprint(instruction)
+				}
+				return strings.Split(res.Description, ";")[0] + instruction
+			}},
+		}
+		style := func(tbl table.Writer) {
+			tbl.SetStyle(table.StyleDefault)
+			tbl.SetAutoIndex(true)
+			tbl.Style().Options.SeparateRows = true
+		}
+		if err := output.Print(resources.Resources, columns, style); err != nil {
+			return err
 		}
-		tbl.SetAutoIndex(true)
-		tbl.Style().Options.SeparateRows = true
 
-		fmt.Print(tbl.Render())
 		return nil
 	},
 }
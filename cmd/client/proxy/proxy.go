@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/client/proxy"
+	"github.com/borderzero/border0-cli/internal/profile"
+	"github.com/borderzero/border0-cli/internal/util"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configFile string
+	foreground bool
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Serve border0 resources on local ports from a background process",
+}
+
+var proxyStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the local proxy for the resources in a config file",
+	Long: `Start the local proxy for the resources in a config file, for example:
+
+resources:
+  - host: mydb-myorg.border0.io
+    port: 5432
+  - host: myssh-myorg.border0.io
+    socket: /tmp/myssh.sock`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, err := filepath.Abs(configFile)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := proxy.ParseConfig(configPath)
+		if err != nil {
+			return err
+		}
+
+		status, err := proxy.ReadStatus()
+		if err != nil {
+			return err
+		}
+		if status != nil {
+			return fmt.Errorf("proxy is already running with pid %d, stop it first", status.PID)
+		}
+
+		if foreground {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return proxy.New(cfg, configPath).Run(ctx)
+		}
+
+		// log in and get the certificate now, the background process can't prompt
+		for _, r := range cfg.Resources {
+			if _, err := client.GetResourceInfo(r.Host); err != nil {
+				return fmt.Errorf("failed to get resource info for %s: %w", r.Host, err)
+			}
+		}
+
+		return startBackground(configPath)
+	},
+}
+
+var proxyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the local proxy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := proxy.ReadStatus()
+		if err != nil {
+			return err
+		}
+		if status == nil {
+			fmt.Println("proxy is not running")
+			return nil
+		}
+
+		fmt.Printf("proxy is running with pid %d since %s\n", status.PID, status.StartedAt.Format(time.RFC3339))
+		fmt.Printf("config: %s\n", status.Config)
+		fmt.Printf("client certificate expires at %s\n", status.CertificateExpiry.Format(time.RFC3339))
+
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"Host", "Listen", "Active", "Total", "Last Error"})
+		for _, l := range status.Listeners {
+			t.AppendRow(table.Row{l.Host, l.Listen, l.ActiveConnections, l.TotalConnections, l.LastError})
+		}
+		t.SetStyle(table.StyleLight)
+		fmt.Printf("%s\n", t.Render())
+
+		return nil
+	},
+}
+
+var proxyStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the local proxy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := proxy.ReadStatus()
+		if err != nil {
+			return err
+		}
+		if status == nil {
+			return errors.New("proxy is not running")
+		}
+
+		if err := proxy.Stop(status); err != nil {
+			return fmt.Errorf("failed to stop proxy: %w", err)
+		}
+
+		fmt.Printf("proxy with pid %d stopped\n", status.PID)
+		return nil
+	},
+}
+
+// startBackground starts the proxy in a process of its own, it logs to the
+// proxy log file
+func startBackground(configPath string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(proxy.Dir(), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", proxy.Dir(), err)
+	}
+
+	logFile, err := os.OpenFile(proxy.LogFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open proxy log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, "client", "proxy", "start", "--config", configPath, "--foreground")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// keeps using the files of this profile when another profile is picked
+	cmd.Env = append(os.Environ(), "BORDER0_PROFILE="+profile.CurrentName())
+	util.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start proxy: %w", err)
+	}
+
+	fmt.Printf("proxy started with pid %d, logging to %s\n", cmd.Process.Pid, proxy.LogFile())
+	return cmd.Process.Release()
+}
+
+func AddCommandsTo(client *cobra.Command) {
+	client.AddCommand(proxyCmd)
+
+	proxyCmd.AddCommand(proxyStartCmd)
+	proxyStartCmd.Flags().StringVarP(&configFile, "config", "f", "", "Config file with the resources to serve")
+	proxyStartCmd.Flags().BoolVarP(&foreground, "foreground", "", false, "Run the proxy in the foreground")
+	proxyStartCmd.MarkFlagRequired("config")
+
+	proxyCmd.AddCommand(proxyStatusCmd)
+	proxyCmd.AddCommand(proxyStopCmd)
+}
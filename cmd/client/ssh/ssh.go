@@ -46,6 +46,10 @@ func AddCommandsTo(client *cobra.Command) {
 	client.AddCommand(keySignCmd)
 	keySignCmd.Flags().StringVarP(&hostname, "host", "", "", "The border0 target host")
 	keySignCmd.MarkFlagRequired("host")
+
+	client.AddCommand(sshConfigCmd)
+	sshConfigCmd.Flags().BoolVarP(&sshConfigWatch, "watch", "w", false, "Keep the config in sync with the ssh resources")
+	sshConfigCmd.Flags().DurationVarP(&sshConfigInterval, "interval", "", 5*time.Minute, "How often the resources are checked with --watch")
 }
 
 // sshCmd represents the client ssh keysign command
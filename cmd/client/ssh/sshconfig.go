@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/enum"
+	"github.com/spf13/cobra"
+)
+
+const sshConfigFileName = "border0.conf"
+
+var (
+	sshConfigWatch    bool
+	sshConfigInterval time.Duration
+)
+
+// sshConfigCmd represents the client ssh-config command
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Generate an OpenSSH client config for all ssh resources",
+	Long: `Generate an OpenSSH client config for all ssh resources, the config is written
+to ~/.ssh/border0.conf and included from ~/.ssh/config. Plain ssh, scp, rsync
+and anything else using OpenSSH can then connect to the resources directly.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home dir : %w", err)
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+
+		if err := writeSSHConfig(home, executable); err != nil {
+			return err
+		}
+
+		if !sshConfigWatch {
+			return nil
+		}
+
+		for range time.Tick(sshConfigInterval) {
+			if err := writeSSHConfig(home, executable); err != nil {
+				log.Printf("failed to update ssh config: %v", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// writeSSHConfig writes the config for the current ssh resources and includes
+// it from the user's ssh config, files are only written when they change
+func writeSSHConfig(home, executable string) error {
+	token, err := client.ReadTokenOrAskToLogIn()
+	if err != nil {
+		return err
+	}
+
+	_, claims, err := client.ValidateClientToken(token)
+	if err != nil {
+		return err
+	}
+	orgID := fmt.Sprint(claims["org_id"])
+
+	resources, err := client.FetchResources(token, enum.SSHSocket)
+	if err != nil {
+		return err
+	}
+
+	var hosts []string
+	for _, resource := range resources.Resources {
+		if hostname := resource.Hostname(); hostname != "" {
+			hosts = append(hosts, hostname)
+		}
+	}
+
+	// make sure there is a signed ssh certificate to begin with
+	if len(hosts) > 0 {
+		if _, err := client.GenSSHKey(token, orgID, hosts[0]); err != nil {
+			return err
+		}
+	}
+
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0700); err != nil {
+		return fmt.Errorf("failed to create ssh directory: %w", err)
+	}
+
+	configPath := filepath.Join(sshDir, sshConfigFileName)
+	changed, err := writeFileIfChanged(configPath, []byte(sshConfig(executable, orgID, hosts)))
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Printf("wrote ssh config for %d resources to %s\n", len(hosts), configPath)
+	}
+
+	userConfigPath := filepath.Join(sshDir, "config")
+	userConfig, err := os.ReadFile(userConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read ssh config: %w", err)
+	}
+
+	if userConfig, ok := includeSSHConfig(userConfig); ok {
+		if _, err := writeFileIfChanged(userConfigPath, userConfig); err != nil {
+			return err
+		}
+		fmt.Printf("added include of %s to %s\n", sshConfigFileName, userConfigPath)
+	}
+
+	return nil
+}
+
+// sshConfig returns a Host block per resource, connections go through the
+// border0 client and the signed certificate is renewed before every connection
+func sshConfig(executable, orgID string, hosts []string) string {
+	sort.Strings(hosts)
+
+	border0 := quoteSSHConfigArg(executable)
+	identityFile := filepath.ToSlash(filepath.Join("~", ".ssh", orgID))
+
+	var b strings.Builder
+	b.WriteString("# Managed by border0 client ssh-config, changes will be overwritten\n")
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "\nHost %s\n", host)
+		fmt.Fprintf(&b, "    ProxyCommand %s client tls --host %%h\n", border0)
+		b.WriteString("    IdentitiesOnly yes\n")
+		fmt.Fprintf(&b, "Match host %s exec \"%s client ssh-keysign --host %%h\"\n", host, strings.ReplaceAll(border0, `"`, `\"`))
+		fmt.Fprintf(&b, "    IdentityFile %s\n", identityFile)
+		fmt.Fprintf(&b, "    CertificateFile %s-cert.pub\n", identityFile)
+	}
+
+	return b.String()
+}
+
+// includeSSHConfig adds the include of the border0 config at the top of the
+// user's ssh config, includes further down would only apply to the last Host
+func includeSSHConfig(userConfig []byte) ([]byte, bool) {
+	for _, line := range strings.Split(string(userConfig), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.EqualFold(fields[0], "include") {
+			for _, file := range fields[1:] {
+				if filepath.Base(file) == sshConfigFileName {
+					return userConfig, false
+				}
+			}
+		}
+	}
+
+	include := fmt.Sprintf("Include %s\n\n", sshConfigFileName)
+	return append([]byte(include), userConfig...), true
+}
+
+func quoteSSHConfigArg(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
+
+func writeFileIfChanged(path string, data []byte) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(current, data) {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return true, nil
+}
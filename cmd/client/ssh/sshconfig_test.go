@@ -0,0 +1,62 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSHConfig(t *testing.T) {
+	got := sshConfig("/usr/local/bin/border0", "org1", []string{"web-org1.border0.io", "db-org1.border0.io"})
+
+	want := `# Managed by border0 client ssh-config, changes will be overwritten
+
+Host db-org1.border0.io
+    ProxyCommand /usr/local/bin/border0 client tls --host %h
+    IdentitiesOnly yes
+Match host db-org1.border0.io exec "/usr/local/bin/border0 client ssh-keysign --host %h"
+    IdentityFile ~/.ssh/org1
+    CertificateFile ~/.ssh/org1-cert.pub
+
+Host web-org1.border0.io
+    ProxyCommand /usr/local/bin/border0 client tls --host %h
+    IdentitiesOnly yes
+Match host web-org1.border0.io exec "/usr/local/bin/border0 client ssh-keysign --host %h"
+    IdentityFile ~/.ssh/org1
+    CertificateFile ~/.ssh/org1-cert.pub
+`
+	assert.Equal(t, want, got)
+}
+
+func TestIncludeSSHConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		userConfig  string
+		want        string
+		wantChanged bool
+	}{
+		{
+			name:        "no config",
+			want:        "Include border0.conf\n\n",
+			wantChanged: true,
+		},
+		{
+			name:        "existing config",
+			userConfig:  "Host example.com\n    User admin\n",
+			want:        "Include border0.conf\n\nHost example.com\n    User admin\n",
+			wantChanged: true,
+		},
+		{
+			name:       "already included",
+			userConfig: "include ~/.ssh/border0.conf\nHost example.com\n",
+			want:       "include ~/.ssh/border0.conf\nHost example.com\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := includeSSHConfig([]byte(tt.userConfig))
+			assert.Equal(t, tt.want, string(got))
+			assert.Equal(t, tt.wantChanged, changed)
+		})
+	}
+}
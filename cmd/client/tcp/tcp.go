@@ -0,0 +1,65 @@
+package tcp
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/enum"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostname string
+	listener int
+)
+
+// clientTcpCmd represents the client tcp command
+var clientTcpCmd = &cobra.Command{
+	Use:               "tcp",
+	Short:             "Connect to a border0 TCP socket through a local listener",
+	ValidArgsFunction: client.AutocompleteHost,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			hostname = args[0]
+		}
+
+		if hostname == "" {
+			pickedHost, err := client.PickHost(hostname, enum.TCPSocket)
+			if err != nil {
+				return err
+			}
+			hostname = pickedHost.Hostname()
+		}
+
+		info, err := client.GetResourceInfo(hostname)
+		if err != nil {
+			log.Fatalf("failed to get certificate: %v", err.Error())
+		}
+
+		// plain tcp clients connect to the local listener, which presents the
+		// client certificate to the socket on their behalf
+		port, err := client.StartLocalTLSListener(fmt.Sprintf("%s:%d", hostname, info.Port), info.SetupTLSCertificate(), info.ConnectorAuthenticationEnabled, listener)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Listening on localhost:%d for %s, press ctrl+c to stop\n", port, hostname)
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		<-sigChan
+
+		return nil
+	},
+}
+
+func AddCommandsTo(client *cobra.Command) {
+	client.AddCommand(clientTcpCmd)
+
+	clientTcpCmd.Flags().StringVarP(&hostname, "host", "", "", "The border0 target host")
+	clientTcpCmd.Flags().IntVarP(&listener, "listener", "l", 0, "Listener port number, a random port is used when not set")
+}
@@ -85,11 +85,14 @@ var connectCmd = &cobra.Command{
 		}
 
 		socketType := strings.ToLower(socketType)
-		if socketType != "http" && socketType != "https" && socketType != "tls" && socketType != "ssh" && socketType != "database" {
-			log.Fatalf("error: --type should be either http, https, database, ssh or tls")
+		if socketType != "http" && socketType != "https" && socketType != "tls" && socketType != "tcp" && socketType != "ssh" && socketType != "database" {
+			log.Fatalf("error: --type should be either http, https, database, ssh, tls or tcp")
 		}
 
-		if socketType == "database" {
+		upstreamType := strings.ToLower(upstream_type)
+
+		// redis and mongodb are often run without authentication
+		if socketType == "database" && upstreamType != "redis" && upstreamType != "mongodb" {
 			if upstream_username == "" {
 				log.Fatalln("Upstream Username required for database sockets")
 			}
@@ -98,7 +101,6 @@ var connectCmd = &cobra.Command{
 			}
 		}
 
-		upstreamType := strings.ToLower(upstream_type)
 		if socketType == "http" || socketType == "https" {
 			if upstreamType != "http" && upstreamType != "https" && upstreamType != "" {
 				log.Fatalf("error: --upstream_type should be either http, https")
@@ -106,8 +108,8 @@ var connectCmd = &cobra.Command{
 		}
 
 		if socketType == "database" {
-			if upstreamType != "mysql" && upstreamType != "postgres" && upstreamType != "" {
-				log.Fatalf("error: --upstream_type should be  mysql or postgres, defaults to mysql")
+			if !isDatabaseUpstreamType(upstreamType) {
+				log.Fatalf("error: --upstream_type should be mysql, postgres, redis, mongodb or mssql, defaults to mysql")
 			}
 		}
 
@@ -197,7 +199,7 @@ var connectCmd = &cobra.Command{
 			httpserver = false
 		}
 
-		ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool)
+		ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool, sshServerConfig())
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -220,17 +222,18 @@ func init() {
 	connectCmd.Flags().BoolVarP(&protected, "protected", "", false, "Protected, default no")
 	connectCmd.Flags().StringVarP(&username, "username", "u", "", "Username, required when protected set to true")
 	connectCmd.Flags().StringVarP(&password, "password", "", "", "Password, required when protected set to true")
-	connectCmd.Flags().StringVarP(&socketType, "type", "t", "http", "Socket type: http, https, ssh, tls, database")
+	connectCmd.Flags().StringVarP(&socketType, "type", "t", "http", "Socket type: http, https, ssh, tls, tcp, database")
 	connectCmd.Flags().StringVarP(&identityFile, "identity_file", "i", "", "Identity File")
 	connectCmd.Flags().StringVarP(&cloudauth_addresses, "allowed_email_addresses", "e", "", "Comma seperated list of allowed Email addresses when using cloudauth")
 	connectCmd.Flags().StringVarP(&cloudauth_domains, "allowed_email_domains", "d", "", "comma seperated list of allowed Email domain (i.e. 'example.com', when using cloudauth")
 	connectCmd.Flags().StringVarP(&upstream_username, "upstream_username", "j", "", "Upstream username used to connect to upstream database")
 	connectCmd.Flags().StringVarP(&upstream_password, "upstream_password", "k", "", "Upstream password used to connect to upstream database")
 	connectCmd.Flags().StringVarP(&upstream_http_hostname, "upstream_http_hostname", "", "", "Upstream http hostname")
-	connectCmd.Flags().StringVarP(&upstream_type, "upstream_type", "", "", "Upstream type: Upstream type: http, https for http sockets or mysql, postgres for database sockets")
+	connectCmd.Flags().StringVarP(&upstream_type, "upstream_type", "", "", "Upstream type: Upstream type: http, https for http sockets or mysql, postgres, redis, mongodb, mssql for database sockets")
 	connectCmd.Flags().StringVarP(&proxyHost, "proxy", "", "", "Proxy host used for connection to border0")
 	connectCmd.Flags().BoolVarP(&localssh, "localssh", "", false, "Start a local SSH server to accept SSH sessions on this host")
 	connectCmd.Flags().BoolVarP(&localssh, "sshserver", "l", false, "Start a local SSH server to accept SSH sessions on this host")
+	addSshServerFlags(connectCmd)
 	connectCmd.Flags().BoolVarP(&httpserver, "httpserver", "", false, "Start a local http server to accept http connections on this host")
 	connectCmd.Flags().StringVarP(&httpserver_dir, "httpserver_dir", "", "", "Directory to serve http connections on this host")
 	connectCmd.Flags().MarkDeprecated("localssh", "use --sshserver instead")
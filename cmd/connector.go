@@ -53,6 +53,10 @@ var connectorStartCmd = &cobra.Command{
 			}
 		}
 
+		if err := parser.LoadVaultInConfig(cfg); err != nil {
+			log.Error("failed to load vault config", zap.String("error", err.Error()))
+		}
+
 		if err := connector.NewConnectorService(*cfg, log, version).Start(); err != nil {
 			log.Error("failed to start connector", zap.String("error", err.Error()))
 		}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFile             string
+	exportDir              string
+	exportSecrets          string
+	exportConnectorSockets bool
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the sockets, policies, notifications, domains and identity providers of the organization as yaml manifests",
+	Long: `Export the sockets, policies, notifications, domains and identity providers of the organization as yaml manifests.
+
+The manifests can be changed and applied with border0 apply. Secrets are replaced by env: references,
+or left out with --secrets redact, apply doesn't change fields that aren't set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportFile != "" && exportDir != "" {
+			log.Fatalf("error: --file and --dir can't be used together")
+		}
+
+		client, err := http.NewClient()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		state, err := manifest.Fetch(client)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		m, envVars, err := state.Export(manifest.ExportOptions{
+			Secrets:          exportSecrets,
+			ConnectorSockets: exportConnectorSockets,
+		})
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		switch {
+		case exportDir != "":
+			err = m.WriteDir(exportDir)
+		case exportFile != "":
+			var data []byte
+			if data, err = m.Marshal(); err == nil {
+				err = os.WriteFile(exportFile, data, 0600)
+			}
+		default:
+			var data []byte
+			if data, err = m.Marshal(); err == nil {
+				fmt.Print(string(data))
+			}
+		}
+		if err != nil {
+			log.Fatalf("error: failed to write manifests: %v", err)
+		}
+
+		if len(envVars) > 0 {
+			fmt.Fprintf(os.Stderr, "Set these environment variables to the secrets before applying the manifests:\n  %s\n", strings.Join(envVars, "\n  "))
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "Write the manifests to a file instead of stdout")
+	exportCmd.Flags().StringVarP(&exportDir, "dir", "d", "", "Write the manifests to a directory, with one file per kind of object")
+	exportCmd.Flags().StringVarP(&exportSecrets, "secrets", "", manifest.SecretsEnv, "How to export secrets: env for env: references or redact to leave them out")
+	exportCmd.Flags().BoolVarP(&exportConnectorSockets, "connector_sockets", "", false, "Also export the sockets created by connectors")
+
+	rootCmd.AddCommand(exportCmd)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	border0 "github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/output"
 	"github.com/borderzero/border0-cli/internal/util"
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
@@ -52,6 +53,24 @@ func getIDPListCmdHandler() func(cmd *cobra.Command, args []string) {
 			return
 		}
 
+		list, err := output.Apply(resp.List)
+		if err != nil {
+			util.FailPretty("%s", err)
+		}
+
+		if !output.IsTable() {
+			columns := []output.Column[identityProviderSummary]{
+				{Field: "name", Header: "Name", Value: func(p identityProviderSummary) interface{} { return deref(p.Name) }},
+				{Field: "display_name", Header: "DisplayName", Value: func(p identityProviderSummary) interface{} { return deref(p.DisplayName) }},
+				{Field: "type", Header: "Type", Value: func(p identityProviderSummary) interface{} { return deref(p.Type) }},
+				{Field: "enabled", Header: "Enabled", Value: func(p identityProviderSummary) interface{} { return p.Enabled != nil && *p.Enabled }},
+			}
+			if err := output.Print(list, columns); err != nil {
+				util.FailPretty("%s", err)
+			}
+			return
+		}
+
 		// init global providers table
 		global := table.NewWriter()
 		global.AppendHeader(table.Row{"Name", "Enabled"})
@@ -60,7 +79,7 @@ func getIDPListCmdHandler() func(cmd *cobra.Command, args []string) {
 		custom := table.NewWriter()
 		custom.AppendHeader(table.Row{"Name", "DisplayName", "Type", "Enabled"})
 
-		for _, provider := range resp.List {
+		for _, provider := range list {
 			if provider.Enabled != nil && provider.LogoURL != nil && provider.Name != nil && provider.Type != nil {
 				if *provider.Type == "global" {
 					global.AppendRow(table.Row{*provider.Name, *provider.Enabled})
@@ -84,3 +103,10 @@ func getIDPListCmdHandler() func(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/client"
+	"github.com/borderzero/border0-cli/internal/credstore"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/spf13/cobra"
+)
+
+// logoutCmd represents the logout command
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logout from border0 and wipe the token and keys of the current profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		// the user id is in the token, so look it up before wiping the token
+		userID, _, err := http.GetUserID()
+		if err == nil {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := credstore.New().Delete(filepath.Join(home, ".border0", "user_"+*userID)); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		}
+
+		if err := http.DeleteToken(); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		credstore.Lock()
+
+		fmt.Println("Logout successful")
+	},
+}
+
+// clientLogoutCmd represents the client logout command
+var clientLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Logout and wipe the client token, certificates and keys of the current profile",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := client.Logout(); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		credstore.Lock()
+
+		fmt.Println("Logout successful")
+	},
+}
+
+// credentialAgentCmd keeps the passphrase of the encrypted credential store,
+// it's started in the background once the store is unlocked
+var credentialAgentCmd = &cobra.Command{
+	Use:    credstore.AgentCommand,
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			log.Fatalf("error: failed to read passphrase: %v", err)
+		}
+
+		if err := credstore.RunAgent(strings.TrimRight(passphrase, "\r\n")); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(credentialAgentCmd)
+	clientCmd.AddCommand(clientLogoutCmd)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/borderzero/border0-cli/cmd/idp"
 	"github.com/borderzero/border0-cli/internal/api/models"
 	border0_http "github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/output"
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
 )
@@ -77,19 +78,13 @@ var domainListCmd = &cobra.Command{
 			log.Fatalf("error: %v", err)
 		}
 
-		t := table.NewWriter()
-		t.AppendHeader(table.Row{"Name", "Default"})
-
-		for _, domain := range domains {
-			if domain.Default {
-				t.AppendRow(table.Row{domain.Domain, "✔"})
-			} else {
-				t.AppendRow(table.Row{domain.Domain, ""})
-			}
+		columns := []output.Column[models.Domain]{
+			{Field: "domain", Header: "Name", Value: func(d models.Domain) interface{} { return d.Domain }},
+			{Field: "default", Header: "Default", Value: func(d models.Domain) interface{} { return checkmark(d.Default) }},
+		}
+		if err := output.Print(domains, columns); err != nil {
+			log.Fatalf("error: %v", err)
 		}
-
-		t.SetStyle(table.StyleLight)
-		fmt.Printf("%s\n", t.Render())
 
 	},
 }
@@ -255,21 +250,15 @@ var notificationListCmd = &cobra.Command{
 			log.Fatalf("error: %v", err)
 		}
 
-		t := table.NewWriter()
-		t.AppendHeader(table.Row{"Name", "Type", "Enabled"})
-
-		for _, notification := range notifications {
-			var enabled string
-			if notification.Enabled {
-				enabled = "✔"
-			} else {
-				enabled = ""
-			}
-			t.AppendRow(table.Row{notification.Name, notification.Type, enabled})
+		columns := []output.Column[models.Notification]{
+			{Field: "name", Header: "Name", Value: func(n models.Notification) interface{} { return n.Name }},
+			{Field: "type", Header: "Type", Value: func(n models.Notification) interface{} { return n.Type }},
+			{Field: "enabled", Header: "Enabled", Value: func(n models.Notification) interface{} { return checkmark(n.Enabled) }},
+			{Field: "events", Header: "Events", Wide: true, Value: func(n models.Notification) interface{} { return strings.Join(n.Events, ", ") }},
+		}
+		if err := output.Print(notifications, columns); err != nil {
+			log.Fatalf("error: %v", err)
 		}
-
-		t.SetStyle(table.StyleLight)
-		fmt.Printf("%s\n", t.Render())
 
 	},
 }
@@ -495,3 +484,10 @@ func init() {
 	notificationUpdateCmd.Flags().StringSliceVarP(&notificationEmailRecipients, "email-recipient", "r", []string{}, "email recipients, can be specified multiple times")
 	notificationUpdateCmd.Flags().StringSliceVarP(&notificationEvents, "event", "v", []string{}, "notification event, can be specified multiple times (login-success, login-failure, audit-events)")
 }
+
+func checkmark(ok bool) string {
+	if ok {
+		return "✔"
+	}
+	return ""
+}
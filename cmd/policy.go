@@ -2,27 +2,28 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"os/exec"
-	"path"
-	"runtime"
 	"sort"
-	"strings"
 	"time"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/TylerBrock/colorjson"
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/output"
+	border0policy "github.com/borderzero/border0-cli/internal/policy"
+	"github.com/borderzero/border0-cli/internal/util"
 	jwt "github.com/golang-jwt/jwt"
 	"github.com/jedib0t/go-pretty/table"
 
 	"github.com/spf13/cobra"
-	"k8s.io/kubectl/pkg/util/term"
 )
 
+var policyYes bool
+
 // policyCmd represents the policy command
 var policyCmd = &cobra.Command{
 	Use:   "policy",
@@ -62,40 +63,36 @@ func policysList(cmd *cobra.Command, args []string) {
 	}
 
 	policies := []models.Policy{}
-	fmt.Println(policiesPath)
 	err = client.Request("GET", policiesPath, &policies, nil)
 	if err != nil {
 		log.Fatalf(fmt.Sprintf("Error: %v", err))
 	}
 
-	if err != nil {
-		log.Fatalf("Error: %v", err)
-	}
-
-	t := table.NewWriter()
-	t.AppendHeader(table.Row{"Name", "Description", "# Sockets", "Organization Wide"})
-
-	sort.Slice(policies, func(i, j int) bool {
+	sort.SliceStable(policies, func(i, j int) bool {
 		return policies[i].OrgWide && !policies[j].OrgWide
 	})
 
-	for _, s := range policies {
-		var socketIDs string
-
-		for _, p := range s.SocketIDs {
-			if socketIDs == "" {
-				socketIDs = socketIDs + ", " + p
+	columns := []output.Column[models.Policy]{
+		{Field: "id", Header: "ID", Wide: true, Value: func(p models.Policy) interface{} { return p.ID }},
+		{Field: "name", Header: "Name", Value: func(p models.Policy) interface{} { return p.Name }},
+		{Field: "description", Header: "Description", Value: func(p models.Policy) interface{} { return p.Description }},
+		{Field: "socket_ids", Header: "# Sockets", Value: func(p models.Policy) interface{} {
+			if p.OrgWide {
+				return "All"
 			}
-
-		}
-		if s.OrgWide {
-			t.AppendRow(table.Row{s.Name, s.Description, "All", "Yes"})
-		} else {
-			t.AppendRow(table.Row{s.Name, s.Description, len(s.SocketIDs), "No"})
-		}
+			return len(p.SocketIDs)
+		}},
+		{Field: "org_wide", Header: "Organization Wide", Value: func(p models.Policy) interface{} {
+			if p.OrgWide {
+				return "Yes"
+			}
+			return "No"
+		}},
+		{Field: "created_at", Header: "Created At", Wide: true, Value: func(p models.Policy) interface{} { return p.CreatedAt.Format(time.RFC3339) }},
+	}
+	if err := output.Print(policies, columns); err != nil {
+		log.Fatalf("error: %v", err)
 	}
-	t.SetStyle(table.StyleLight)
-	fmt.Printf("%s\n", t.Render())
 }
 
 // policyDeleteCmd represents the policy delete command
@@ -142,8 +139,11 @@ func policyAttach(cmd *cobra.Command, args []string) {
 		log.Fatalf("error: invalid policy name")
 	}
 
-	if socketID == "" {
-		log.Fatalf("error: invalid socket id")
+	if (socketID == "") == (len(socketSelectors) == 0) {
+		log.Fatalf("error: either --socket_id or --selector is required")
+	}
+	if err := checkBulkFlags(cmd); err != nil {
+		log.Fatalf("%v", err)
 	}
 
 	policy, err := findPolicyByName(policyName)
@@ -151,6 +151,11 @@ func policyAttach(cmd *cobra.Command, args []string) {
 		log.Fatalf(fmt.Sprintf("Error: %v", err))
 	}
 
+	if len(socketSelectors) > 0 {
+		policySocketsSelected(policy, "add")
+		return
+	}
+
 	client, err := http.NewClient()
 	if err != nil {
 		log.Fatalf("error: %v", err)
@@ -184,8 +189,11 @@ func policyDettach(cmd *cobra.Command, args []string) {
 		log.Fatalf("error: invalid policy name")
 	}
 
-	if socketID == "" {
-		log.Fatalf("error: invalid socket id")
+	if (socketID == "") == (len(socketSelectors) == 0) {
+		log.Fatalf("error: either --socket_id or --selector is required")
+	}
+	if err := checkBulkFlags(cmd); err != nil {
+		log.Fatalf("%v", err)
 	}
 
 	policy, err := findPolicyByName(policyName)
@@ -193,6 +201,11 @@ func policyDettach(cmd *cobra.Command, args []string) {
 		log.Fatalf(fmt.Sprintf("Error: %v", err))
 	}
 
+	if len(socketSelectors) > 0 {
+		policySocketsSelected(policy, "remove")
+		return
+	}
+
 	client, err := http.NewClient()
 	if err != nil {
 		log.Fatalf("error: %v", err)
@@ -264,91 +277,41 @@ func policyShow(cmd *cobra.Command, args []string) {
 var policyEditCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "Edit a policy",
+	Long:  "Edit a policy in your editor, from VISUAL or EDITOR, or from a yaml or json file with --policy-file",
 	Run: func(cmd *cobra.Command, args []string) {
 		if policyName == "" {
 			log.Fatalf("error: invalid policy name")
 		}
 
-		var data []byte
-		var err error
-
 		policy, err := findPolicyByName(policyName)
 		if err != nil {
 			log.Fatalf(fmt.Sprintf("Error: %v", err))
 		}
 
+		var policyData models.PolicyData
 		if policyFile != "" {
-			data, err = os.ReadFile(policyFile)
-			if err != nil {
-				fmt.Printf("could not open policy file %s\n", err)
-				return
-			}
-
+			policyData, err = readPolicyFile(policyFile)
 		} else {
-			if strings.Contains(runtime.GOOS, "windows") {
-				fmt.Printf("not available on windows. Please use the --policy-file or -f option")
-				return
-			}
-
-			// temporary policy parth and filename
-			fpath := path.Join(os.TempDir(), policyName+".json")
-			f, err := os.Create(fpath)
-			if err != nil {
-				fmt.Printf("could not create a policy file %s\n", err)
-				return
-			}
-			f.Close()
-
-			file, err := os.OpenFile(fpath, os.O_APPEND|os.O_WRONLY, 0600)
-			if err != nil {
-				fmt.Printf("could not create a policy file %s\n", err)
-				return
-			}
-
-			bytes, err := json.MarshalIndent(policy.PolicyData, "", "  ")
-			if err != nil {
-				fmt.Println("Can't serislize", err)
-			}
-
-			file.WriteString(string(bytes))
-			file.Close()
-
-			c := exec.Command(defaultEnvEditor(), fpath)
-			c.Stdin = os.Stdin
-			c.Stdout = os.Stdout
-			c.Stderr = os.Stderr
-
-			// we defer the removal of temporary prolicy file
-			defer os.Remove(fpath)
-
-			if err := (term.TTY{In: os.Stdin, TryDev: true}).Safe(c.Run); err != nil {
-				if err, ok := err.(*exec.Error); ok {
-					if err.Err == exec.ErrNotFound {
-						fmt.Printf("unable to launch the editor")
-						return
-					}
-				}
-				fmt.Printf("there was a problem with the editor")
-				return
-			}
-			jsonFile, err := os.Open(fpath)
-			if err != nil {
-				fmt.Printf("could not open policy file %s\n", err)
-				return
-			}
-			defer jsonFile.Close()
-			data, err = io.ReadAll(jsonFile)
-			if err != nil {
-				fmt.Printf("could not open policy file %s\n", err)
-				return
+			var current []byte
+			if current, err = border0policy.Marshal(policy.PolicyData); err == nil {
+				policyData, err = editPolicyData(policyName, current)
 			}
 		}
+		if err != nil {
+			log.Fatalf("⛔ %v", err)
+		}
 
-		var policyData models.PolicyData
-
-		err = json.Unmarshal(data, &policyData)
+		diff, err := border0policy.Diff(&policy.PolicyData, policyData)
 		if err != nil {
-			log.Fatalf("⛔ Json format error: %v", err)
+			log.Fatalf("⛔ error: %v", err)
+		}
+		if diff == "" {
+			fmt.Println("No changes to the policy")
+			return
+		}
+		if !confirmPolicyData(diff) {
+			fmt.Println("Policy not updated")
+			return
 		}
 
 		req := models.UpdatePolicyRequest{
@@ -372,85 +335,30 @@ var policyEditCmd = &cobra.Command{
 var policyAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Create a policy",
+	Long:  "Create a policy in your editor, from VISUAL or EDITOR, or from a yaml or json file with --policy-file",
 	Run: func(cmd *cobra.Command, args []string) {
-
-		var data []byte
-		var err error
-
 		if policyName == "" {
 			log.Fatalf("⛔ error: invalid policy name")
 		}
 
+		var policyData models.PolicyData
+		var err error
 		if policyFile != "" {
-			data, err = os.ReadFile(policyFile)
-			if err != nil {
-				fmt.Printf("⛔ could not open policy file %s\n", err)
-				return
-			}
-
+			policyData, err = readPolicyFile(policyFile)
 		} else {
-			if strings.Contains(runtime.GOOS, "windows") {
-				fmt.Printf("⛔ not available on windows. Please use the --policy-file or -f option")
-				return
-			}
-
-			fpath := os.TempDir() + "/" + policyName + ".json"
-			f, err := os.Create(fpath)
-			if err != nil {
-				fmt.Printf("could not create a policy file %s\n", err)
-				return
-			}
-			f.Close()
-
-			file, err := os.OpenFile(fpath, os.O_APPEND|os.O_WRONLY, 0600)
-			if err != nil {
-				fmt.Printf("could not create a policy file %s\n", err)
-				return
-			}
-
-			file.WriteString(policyTemplate())
-			file.Close()
-
-			c := exec.Command(defaultEnvEditor(), fpath)
-			c.Stdin = os.Stdin
-			c.Stdout = os.Stdout
-			c.Stderr = os.Stderr
-
-			if err := (term.TTY{In: os.Stdin, TryDev: true}).Safe(c.Run); err != nil {
-				if err, ok := err.(*exec.Error); ok {
-					if err.Err == exec.ErrNotFound {
-						fmt.Printf("⚠️ unable to launch the editor")
-						return
-					}
-				}
-				fmt.Printf("⛔ there was a problem with the editor")
-				return
-			}
-
-			jsonFile, err := os.Open(fpath)
-			if err != nil {
-				fmt.Printf("⛔ could not open policy file %s\n", err)
-				return
-			}
-			defer jsonFile.Close()
-
-			data, err = io.ReadAll(jsonFile)
-			if err != nil {
-				fmt.Printf("⚠️ could not open policy file %s\n", err)
-				return
-			}
+			policyData, err = editPolicyData(policyName, []byte(policyTemplate()))
 		}
-
 		if err != nil {
-			fmt.Printf("⚠️ could not open policy file %s\n", err)
-			return
+			log.Fatalf("⛔ %v", err)
 		}
 
-		var policyData models.PolicyData
-
-		err = json.Unmarshal(data, &policyData)
+		diff, err := border0policy.Diff(nil, policyData)
 		if err != nil {
-			log.Fatalf("⛔ Json format error: %v", err)
+			log.Fatalf("⛔ error: %v", err)
+		}
+		if !confirmPolicyData(diff) {
+			fmt.Println("Policy not created")
+			return
 		}
 
 		req := models.CreatePolicyRequest{
@@ -473,19 +381,63 @@ var policyAddCmd = &cobra.Command{
 	},
 }
 
-func defaultEnvEditor() string {
-	editor := os.Getenv("EDITOR")
+func readPolicyFile(file string) (models.PolicyData, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return models.PolicyData{}, fmt.Errorf("could not open policy file %w", err)
+	}
+	return border0policy.Parse(data)
+}
+
+// editPolicyData opens the policy data in an editor until it's valid or the user gives up
+func editPolicyData(name string, initial []byte) (models.PolicyData, error) {
+	f, err := os.CreateTemp("", "border0-policy-"+name+"-*.yaml")
+	if err != nil {
+		return models.PolicyData{}, fmt.Errorf("could not create a policy file %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(initial)
+	f.Close()
+	if err != nil {
+		return models.PolicyData{}, fmt.Errorf("could not write the policy file %w", err)
+	}
+
+	for {
+		if err := util.Edit(f.Name()); err != nil {
+			return models.PolicyData{}, err
+		}
+
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			return models.PolicyData{}, fmt.Errorf("could not open policy file %w", err)
+		}
+
+		policyData, err := border0policy.Parse(data)
+		if err == nil {
+			return policyData, nil
+		}
 
-	if len(editor) == 0 {
-		editor = "vi"
+		fmt.Printf("⛔ %v\n", err)
+		again := true
+		if err := survey.AskOne(&survey.Confirm{Message: "Edit the policy again?", Default: true}, &again); err != nil || !again {
+			return models.PolicyData{}, errors.New("policy not saved")
+		}
 	}
-	if !strings.Contains(editor, " ") {
-		return []string{editor}[0]
+}
+
+// confirmPolicyData shows the changes to the policy data and asks to save them
+func confirmPolicyData(diff string) bool {
+	fmt.Printf("\nPolicy Data:\n\n%s\n", diff)
+	if policyYes {
+		return true
 	}
-	if !strings.ContainsAny(editor, "\"'\\") {
-		return strings.Split(editor, " ")[0]
+
+	confirmed := false
+	if err := survey.AskOne(&survey.Confirm{Message: "Save the policy?"}, &confirmed); err != nil {
+		return false
 	}
-	return editor
+	return confirmed
 }
 
 func findPolicyByName(name string) (models.Policy, error) {
@@ -529,55 +481,54 @@ func init() {
 	policyAttachCmd.Flags().StringVarP(&policyName, "name", "n", "", "Policy Name")
 	policyAttachCmd.MarkFlagRequired("name")
 	policyAttachCmd.Flags().StringVarP(&socketID, "socket_id", "s", "", "Socket ID")
-	policyAttachCmd.MarkFlagRequired("socket_id")
 
 	policyDettachCmd.Flags().StringVarP(&policyName, "name", "n", "", "Policy Name")
 	policyDettachCmd.MarkFlagRequired("name")
 	policyDettachCmd.Flags().StringVarP(&socketID, "socket_id", "s", "", "Socket ID")
-	policyDettachCmd.MarkFlagRequired("socket_id")
 
 	policyAddCmd.Flags().StringVarP(&policyName, "name", "n", "", "Policy Name")
 	policyAddCmd.MarkFlagRequired("name")
 	policyAddCmd.Flags().StringVarP(&policyDescription, "description", "d", "", "Policy Description")
-	policyAddCmd.Flags().StringVarP(&policyFile, "policy-file", "f", "", "Policy Definition File")
+	policyAddCmd.Flags().StringVarP(&policyFile, "policy-file", "f", "", "Policy Definition File, in yaml or json")
+	policyAddCmd.Flags().BoolVarP(&policyYes, "yes", "y", false, "Create the policy without asking for confirmation")
 	policyAddCmd.Flags().BoolVarP(&orgwide, "orgwide", "o", false, "Organization wide polciy")
 
 	policyEditCmd.Flags().StringVarP(&policyName, "name", "n", "", "Policy Name")
 	policyEditCmd.MarkFlagRequired("name")
-	policyEditCmd.Flags().StringVarP(&policyFile, "policy-file", "f", "", "Policy Definition File")
+	policyEditCmd.Flags().StringVarP(&policyFile, "policy-file", "f", "", "Policy Definition File, in yaml or json")
+	policyEditCmd.Flags().BoolVarP(&policyYes, "yes", "y", false, "Update the policy without asking for confirmation")
 
 }
 
-const defaultPolicyDataTemplate = `{
-	"version": "v1",
-	"action": [
-		"database",
-		"ssh",
-		"http",
-		"tls"
-	],
-	"condition": {
-		"who": {
-		"email": [
-			"%s"
-		],
-		"domain": [
-			"example.com"
-		]
-		},
-		"where": {
-			"allowed_ip": ["0.0.0.0/0", "::/0"],
-			"country": [],
-			"country_not": []
-		},
-		"when": {
-			"after": "%s",
-			"before": null,
-			"time_of_day_after": "00:00:00 UTC",
-			"time_of_day_before": "23:59:59 UTC"
-		}
-	}
-}`
+const defaultPolicyDataTemplate = `# Border0 policy, in yaml or json
+version: v1
+# the types of sockets the policy allows: database, ssh, http and tls
+action:
+  - database
+  - ssh
+  - http
+  - tls
+condition:
+  # who is allowed, by email address or email domain
+  who:
+    email:
+      - %s
+    domain:
+      - example.com
+  # where from, by ip address or cidr and by two letter country code, like NL or US
+  where:
+    allowed_ip:
+      - 0.0.0.0/0
+      - ::/0
+    country: []
+    country_not: []
+  # when, after and before are dates like 2006-01-02, times of day are like 09:00:00 UTC
+  when:
+    after: "%s"
+    before: null
+    time_of_day_after: "00:00:00 UTC"
+    time_of_day_before: "23:59:59 UTC"
+`
 
 func policyTemplate() string {
 	// Lets create a template for the policy
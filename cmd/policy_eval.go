@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/policy"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	policyTestSocket  string
+	policyTestEmail   string
+	policyTestIP      string
+	policyTestCountry string
+	policyTestTime    string
+	policyTestAction  string
+)
+
+// policyTestCmd represents the policy test command
+var policyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Evaluate policies locally and explain which conditions match",
+	Long: `Evaluate a policy, or all policies of a socket, locally and explain which conditions match.
+
+Access is allowed when all conditions of one of the policies match. The country of an ip address
+isn't looked up, use --country to check country conditions.`,
+	Example: `  border0 policy test --name engineering --email alice@example.com --ip 1.2.3.4 --time 2026-10-16T09:00Z
+  border0 policy test --socket prod-db --email alice@example.com --country NL`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (policyName == "") == (policyTestSocket == "") {
+			log.Fatalf("error: either --name or --socket is required")
+		}
+
+		req := policy.Request{
+			Email:   policyTestEmail,
+			Country: policyTestCountry,
+			Action:  policyTestAction,
+			Time:    time.Now(),
+		}
+		if policyTestIP != "" {
+			if req.IP = net.ParseIP(policyTestIP); req.IP == nil {
+				log.Fatalf("error: invalid ip address %s", policyTestIP)
+			}
+		}
+		if policyTestTime != "" {
+			t, err := policy.ParseTime(policyTestTime)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			req.Time = t
+		}
+
+		var policies []models.Policy
+		if policyName != "" {
+			p, err := findPolicyByName(policyName)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			policies = append(policies, p)
+		} else {
+			socket, socketPolicies, err := socketPolicies(policyTestSocket)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if req.Action == "" {
+				req.Action = socketAction(socket.SocketType)
+			}
+			policies = socketPolicies
+			fmt.Printf("Socket %s has %d policies\n\n", socket.Name, len(policies))
+		}
+
+		allowed := policy.NoMatch
+		for _, p := range policies {
+			evaluation := policy.Evaluate(p.Name, p.PolicyData, req)
+			printEvaluation(evaluation)
+
+			if evaluation.Result == policy.Match || (evaluation.Result == policy.Unknown && allowed == policy.NoMatch) {
+				allowed = evaluation.Result
+			}
+		}
+
+		switch allowed {
+		case policy.Match:
+			fmt.Println(color.GreenString("Access allowed"))
+		case policy.Unknown:
+			fmt.Println(color.YellowString("Access can't be determined, some conditions need more information"))
+		default:
+			fmt.Println(color.RedString("Access denied"))
+		}
+	},
+}
+
+func printEvaluation(e policy.Evaluation) {
+	result := map[policy.Result]string{
+		policy.Match:   color.GreenString("matches"),
+		policy.NoMatch: color.RedString("doesn't match"),
+		policy.Unknown: color.YellowString("can't be determined"),
+	}
+	marks := map[policy.Result]string{
+		policy.Match:   color.GreenString("✔"),
+		policy.NoMatch: color.RedString("✘"),
+		policy.Unknown: color.YellowString("?"),
+	}
+
+	fmt.Printf("Policy %s %s\n", e.Policy, result[e.Result])
+	for _, c := range e.Checks {
+		fmt.Printf("  %s %-18s %s\n", marks[c.Result], c.Condition, c.Reason)
+	}
+	fmt.Println()
+}
+
+// socketPolicies returns a socket, by name or id, with its own and the organization wide policies
+func socketPolicies(nameOrID string) (models.Socket, []models.Policy, error) {
+	client, err := http.NewClient()
+	if err != nil {
+		return models.Socket{}, nil, err
+	}
+
+	var sockets []models.Socket
+	if err := client.Request("GET", "socket", &sockets, nil); err != nil {
+		return models.Socket{}, nil, err
+	}
+
+	var socket *models.Socket
+	for i, s := range sockets {
+		if s.Name == nameOrID || s.SocketID == nameOrID || s.Dnsname == nameOrID {
+			socket = &sockets[i]
+			break
+		}
+	}
+	if socket == nil {
+		return models.Socket{}, nil, fmt.Errorf("socket %s not found", nameOrID)
+	}
+
+	var attached, orgWide []models.Policy
+	if err := client.Request("GET", "policies?socket_id="+url.QueryEscape(socket.SocketID), &attached, nil); err != nil {
+		return models.Socket{}, nil, err
+	}
+	if err := client.Request("GET", "policies/?org_wide=true", &orgWide, nil); err != nil {
+		return models.Socket{}, nil, err
+	}
+
+	seen := map[string]bool{}
+	var policies []models.Policy
+	for _, p := range append(attached, orgWide...) {
+		if !seen[p.ID] {
+			seen[p.ID] = true
+			policies = append(policies, p)
+		}
+	}
+
+	return *socket, policies, nil
+}
+
+// socketAction returns the policy action of a socket type
+func socketAction(socketType string) string {
+	if socketType == "https" {
+		return "http"
+	}
+	return socketType
+}
+
+func init() {
+	policyCmd.AddCommand(policyTestCmd)
+
+	policyTestCmd.Flags().StringVarP(&policyName, "name", "n", "", "Policy Name")
+	policyTestCmd.Flags().StringVarP(&policyTestSocket, "socket", "", "", "Socket name or ID, evaluates the policies of the socket and the organization wide policies")
+	policyTestCmd.Flags().StringVarP(&policyTestEmail, "email", "e", "", "Email address of the user")
+	policyTestCmd.Flags().StringVarP(&policyTestIP, "ip", "", "", "IP address the user connects from")
+	policyTestCmd.Flags().StringVarP(&policyTestCountry, "country", "", "", "Country code the user connects from, like NL or US")
+	policyTestCmd.Flags().StringVarP(&policyTestTime, "time", "t", "", "Time of the request, like 2026-10-16T09:00Z, defaults to now")
+	policyTestCmd.Flags().StringVarP(&policyTestAction, "action", "a", "", "Action to check, like ssh, database, http or tls, defaults to the type of the socket")
+}
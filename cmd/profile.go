@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/borderzero/border0-cli/internal/api"
+	"github.com/borderzero/border0-cli/internal/credstore"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/profile"
+	"github.com/borderzero/border0-cli/internal/ssh"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileName         string
+	profileAPIURL       string
+	profileTunnelServer string
+	profileWebURL       string
+	credentialStore     string
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage profiles for multiple organizations and environments",
+}
+
+var profileLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List profiles",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := profile.Read()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		current := profile.CurrentName()
+
+		t := table.NewWriter()
+		t.AppendHeader(table.Row{"Name", "API URL", "Tunnel Server", "Current"})
+		for _, name := range cfg.Names() {
+			p := cfg.Get(name)
+			currentMark := "No"
+			if name == current {
+				currentMark = "Yes"
+			}
+			t.AppendRow(table.Row{name, p.APIURL, p.TunnelServer, currentMark})
+		}
+		t.SetStyle(table.StyleLight)
+		fmt.Printf("%s\n", t.Render())
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch to a profile, the endpoint flags create or update it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := profile.ValidateName(name); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		cfg, err := profile.Read()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		p, exists := cfg.Profiles[name]
+		if cmd.Flags().Changed("api_url") {
+			p.APIURL = profileAPIURL
+		}
+		if cmd.Flags().Changed("tunnel_server") {
+			p.TunnelServer = profileTunnelServer
+		}
+		if cmd.Flags().Changed("web_url") {
+			p.WebURL = profileWebURL
+		}
+		if cmd.Flags().Changed("credential_store") {
+			if err := credstore.ValidateBackend(credentialStore); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			p.CredentialStore = credentialStore
+		}
+
+		changed := cmd.Flags().Changed("api_url") || cmd.Flags().Changed("tunnel_server") || cmd.Flags().Changed("web_url") || cmd.Flags().Changed("credential_store")
+		if !exists && !changed && name != profile.DefaultProfile {
+			log.Fatalf("error: profile %s doesn't exist, create it with --api_url, --tunnel_server, --web_url or --credential_store", name)
+		}
+		if exists || changed {
+			cfg.Profiles[name] = p
+		}
+
+		cfg.Current = name
+		if err := profile.Write(cfg); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		fmt.Printf("Using profile %s\n", name)
+		if os.Getenv("BORDER0_PROFILE") != "" && os.Getenv("BORDER0_PROFILE") != name {
+			fmt.Printf("Note: BORDER0_PROFILE is set to %s and takes precedence\n", os.Getenv("BORDER0_PROFILE"))
+		}
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile, the current profile by default",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := profile.CurrentName()
+		if len(args) > 0 {
+			name = args[0]
+			if err := profile.ValidateName(name); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			os.Setenv("BORDER0_PROFILE", name)
+		}
+
+		fmt.Printf("Profile: %s\n", name)
+		fmt.Printf("API URL: %s\n", api.APIURL())
+		fmt.Printf("Web URL: %s\n", http.WebUrl())
+		fmt.Printf("Tunnel server: %s\n", ssh.TunnelServer())
+		fmt.Printf("Admin token: %s\n", http.TokenFilePath())
+		backend := profile.Current().CredentialStore
+		if backend == "" {
+			backend = credstore.PlaintextBackend
+		}
+		fmt.Printf("Credential store: %s\n", backend)
+	},
+}
+
+func init() {
+	profileUseCmd.Flags().StringVarP(&profileAPIURL, "api_url", "", "", "API URL of the profile")
+	profileUseCmd.Flags().StringVarP(&profileTunnelServer, "tunnel_server", "", "", "Tunnel server of the profile")
+	profileUseCmd.Flags().StringVarP(&profileWebURL, "web_url", "", "", "Web portal URL of the profile")
+	profileUseCmd.Flags().StringVarP(&credentialStore, "credential_store", "", "", "Credential store of the profile: file or encrypted")
+
+	profileCmd.AddCommand(profileLsCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileShowCmd)
+	rootCmd.AddCommand(profileCmd)
+}
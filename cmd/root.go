@@ -17,11 +17,15 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/output"
+	"github.com/borderzero/border0-cli/internal/profile"
 	"github.com/jedib0t/go-pretty/table"
 
 	cc "github.com/ivanpirog/coloredcobra"
@@ -73,6 +77,21 @@ var (
 	upstream_cert_file     string
 	upstream_key_file      string
 	upstream_ca_file       string
+	sftpDisabled           bool
+	sftpChroot             string
+	sftpReadOnly           bool
+	sftpUser               string
+	allowLocalForward      []string
+	allowRemoteForward     []string
+	allowAgentForwarding   bool
+	hostKeyDir             string
+	knownHosts             bool
+	userMapping            []string
+	allowRoot              bool
+	idleTimeout            time.Duration
+	maxSessionDuration     time.Duration
+	maxSessionsPerIdentity int
+	sessionWarning         time.Duration
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -100,6 +119,23 @@ func Execute() {
 
 func init() {
 	rootCmd.SetVersionTemplate(fmt.Sprintf("border0:\nversion %s\ndate: %s\n", version, date))
+
+	rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "", "", "Profile to use, defaults to BORDER0_PROFILE or the profile picked with profile use")
+	output.AddFlags(rootCmd.PersistentFlags())
+	cobra.OnInitialize(func() {
+		if err := output.Validate(); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		if profileName != "" {
+			// the environment is inherited by the processes we start, like ssh proxy commands
+			os.Setenv("BORDER0_PROFILE", profileName)
+		}
+		if name := os.Getenv("BORDER0_PROFILE"); name != "" {
+			if err := profile.ValidateName(name); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		}
+	})
 }
 
 func splitLongLines(b string, maxLength int) string {
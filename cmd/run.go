@@ -228,7 +228,7 @@ func createSocketStartTunnel(cmd *cobra.Command, quitChannelSsh chan bool, clean
 				}
 			}
 
-			ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, false, localsshServer, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool)
+			ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, false, localsshServer, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool, sshServerConfig())
 			if err != nil {
 				//fmt.Println(err)
 				//continue
@@ -344,7 +344,7 @@ func createHTTPSocketStartTunnel(cmd *cobra.Command, quitChannelHttp chan bool,
 				}
 			}
 
-			ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, httpserver, false, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool)
+			ssh.SshConnect(userIDStr, c.SocketID, c.Tunnels[0].TunnelID, port, hostname, identityFile, proxyHost, version, httpserver, false, org.Certificates["ssh_public_key"], "", httpserver_dir, c.ConnectorAuthenticationEnabled, caCertPool, sshServerConfig())
 			if err != nil {
 				//fmt.Println(err)
 				//continue
@@ -553,4 +553,5 @@ func processStats(process exec.Cmd) {
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringVarP(&runcommand, "command", "c", "", "Command to execute")
+	addSshServerFlags(runCmd)
 }
@@ -22,14 +22,15 @@ import (
 	"os"
 	"os/signal"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/output"
 	"github.com/borderzero/border0-cli/internal/ssh"
 	"github.com/borderzero/border0-cli/internal/util"
-	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
 )
 
@@ -55,31 +56,39 @@ var socketsListCmd = &cobra.Command{
 			log.Fatalf(fmt.Sprintf("Error: %v", err))
 		}
 
-		var portsStr string
-
-		if err != nil {
-			log.Fatalf("Error: %v", err)
+		if err := output.Print(sockets, socketColumns); err != nil {
+			log.Fatalf("error: %v", err)
 		}
+	},
+}
 
-		t := table.NewWriter()
-		t.AppendHeader(table.Row{"Socket ID", "Name", "DNS Name", "Port(s)", "Type", "Description"})
+var socketColumns = []output.Column[models.Socket]{
+	{Field: "socket_id", Header: "Socket ID", Value: func(s models.Socket) interface{} { return s.SocketID }},
+	{Field: "name", Header: "Name", Value: func(s models.Socket) interface{} { return s.Name }},
+	{Field: "dnsname", Header: "DNS Name", Value: func(s models.Socket) interface{} { return s.Dnsname }},
+	{Field: "socket_tcp_ports", Header: "Port(s)", Value: func(s models.Socket) interface{} { return joinInts(s.SocketTcpPorts) }},
+	{Field: "socket_type", Header: "Type", Value: func(s models.Socket) interface{} { return s.SocketType }},
+	{Field: "upstream_type", Header: "Upstream Type", Wide: true, Value: func(s models.Socket) interface{} { return s.UpstreamType }},
+	{Field: "policy_names", Header: "Policies", Wide: true, Value: func(s models.Socket) interface{} { return strings.Join(s.PolicyNames, ", ") }},
+	{Field: "tags", Header: "Tags", Wide: true, Value: func(s models.Socket) interface{} { return joinTags(s.Tags) }},
+	{Field: "description", Header: "Description", Value: func(s models.Socket) interface{} { return s.Description }},
+}
 
-		for _, s := range sockets {
-			portsStr = ""
-			for _, p := range s.SocketTcpPorts {
-				i := strconv.Itoa(p)
-				if portsStr == "" {
-					portsStr = i
-				} else {
-					portsStr = portsStr + ", " + i
-				}
-			}
+func joinInts(values []int) string {
+	var strs []string
+	for _, v := range values {
+		strs = append(strs, strconv.Itoa(v))
+	}
+	return strings.Join(strs, ", ")
+}
 
-			t.AppendRow(table.Row{s.SocketID, s.Name, s.Dnsname, portsStr, s.SocketType, s.Description})
-		}
-		t.SetStyle(table.StyleLight)
-		fmt.Printf("%s\n", t.Render())
-	},
+func joinTags(tags map[string]string) string {
+	var strs []string
+	for k, v := range tags {
+		strs = append(strs, k+"="+v)
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, ", ")
 }
 
 // socketCreateCmd represents the socket create command
@@ -123,11 +132,14 @@ var socketCreateCmd = &cobra.Command{
 		}
 
 		socketType := strings.ToLower(socketType)
-		if socketType != "http" && socketType != "https" && socketType != "tls" && socketType != "ssh" && socketType != "database" {
-			log.Fatalf("error: --type should be either http, https, ssh, database or tls")
+		if socketType != "http" && socketType != "https" && socketType != "tls" && socketType != "tcp" && socketType != "ssh" && socketType != "database" {
+			log.Fatalf("error: --type should be either http, https, ssh, database, tls or tcp")
 		}
 
-		if socketType == "database" {
+		upstreamType := strings.ToLower(upstream_type)
+
+		// redis and mongodb are often run without authentication
+		if socketType == "database" && upstreamType != "redis" && upstreamType != "mongodb" {
 			if upstream_username == "" {
 				log.Fatalln("Upstream Username required for database sockets")
 			}
@@ -136,7 +148,6 @@ var socketCreateCmd = &cobra.Command{
 			}
 		}
 
-		upstreamType := strings.ToLower(upstream_type)
 		if socketType == "http" || socketType == "https" {
 			if upstreamType != "http" && upstreamType != "https" && upstreamType != "" {
 				log.Fatalf("error: --upstream_type should be either http, https")
@@ -145,8 +156,8 @@ var socketCreateCmd = &cobra.Command{
 
 		var upstream_cert, upstream_key, upstream_ca *string
 		if socketType == "database" {
-			if upstreamType != "mysql" && upstreamType != "postgres" && upstreamType != "" {
-				log.Fatalf("error: --upstream_type should be mysql or postgres, defaults to mysql")
+			if !isDatabaseUpstreamType(upstreamType) {
+				log.Fatalf("error: --upstream_type should be mysql, postgres, redis, mongodb or mssql, defaults to mysql")
 			}
 
 			if upstream_cert_file != "" {
@@ -227,9 +238,22 @@ var socketCreateCmd = &cobra.Command{
 // socketDeleteCmd represents the socket delete command
 var socketDeleteCmd = &cobra.Command{
 	Use:               "delete [socket]",
-	Short:             "Delete a socket",
+	Short:             "Delete a socket, or all sockets that match a selector",
+	Example:           "  border0 socket delete --selector connector_name=my-connector --selector type=ssh --dry_run",
 	ValidArgsFunction: AutocompleteSocket,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkBulkFlags(cmd); err != nil {
+			return err
+		}
+
+		if len(socketSelectors) > 0 {
+			if socketID != "" || len(args) > 0 {
+				return fmt.Errorf("error: a socket and --selector can't be used together")
+			}
+			socketDeleteSelected()
+			return nil
+		}
+
 		if socketID == "" && (len(args) == 0) {
 			return fmt.Errorf("error: no socket provided")
 		}
@@ -377,7 +401,7 @@ var socketConnectCmd = &cobra.Command{
 			localssh = false
 		}
 
-		err = ssh.SshConnect(userIDStr, socketID, "", port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, socket.ConnectorAuthenticationEnabled, caCertPool)
+		err = ssh.SshConnect(userIDStr, socketID, "", port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, socket.ConnectorAuthenticationEnabled, caCertPool, sshServerConfig())
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -386,6 +410,16 @@ var socketConnectCmd = &cobra.Command{
 	},
 }
 
+// isDatabaseUpstreamType reports whether the upstream type is supported for
+// database sockets, an empty upstream type defaults to mysql
+func isDatabaseUpstreamType(upstreamType string) bool {
+	switch upstreamType {
+	case "", "mysql", "postgres", "redis", "mongodb", "mssql":
+		return true
+	}
+	return false
+}
+
 func getSockets(toComplete string) []string {
 	var socketIDs []string
 
@@ -455,8 +489,8 @@ func init() {
 	socketCreateCmd.Flags().StringVarP(&upstream_username, "upstream_username", "j", "", "Upstream username used to connect to upstream database")
 	socketCreateCmd.Flags().StringVarP(&upstream_password, "upstream_password", "k", "", "Upstream password used to connect to upstream database")
 	socketCreateCmd.Flags().StringVarP(&upstream_http_hostname, "upstream_http_hostname", "", "", "Upstream http hostname")
-	socketCreateCmd.Flags().StringVarP(&upstream_type, "upstream_type", "", "", "Upstream type: http, https for http sockets or mysql, postgres for database sockets")
-	socketCreateCmd.Flags().StringVarP(&socketType, "type", "t", "http", "Socket type: http, https, ssh, tls, database")
+	socketCreateCmd.Flags().StringVarP(&upstream_type, "upstream_type", "", "", "Upstream type: http, https for http sockets or mysql, postgres, redis, mongodb, mssql for database sockets")
+	socketCreateCmd.Flags().StringVarP(&socketType, "type", "t", "http", "Socket type: http, https, ssh, tls, tcp, database")
 	socketCreateCmd.Flags().BoolVarP(&connectorAuthEnabled, "connector_auth", "c", false, "Enables connector authentication")
 	socketCreateCmd.Flags().StringVarP(&orgCustomDomain, "domain", "o", "", "Use custom domain for socket")
 	socketCreateCmd.Flags().StringVarP(&upstream_cert_file, "upstream_certificate_filename", "f", "", "path to file from where to read the upstream client certificate")
@@ -529,6 +563,7 @@ func init() {
 	socketConnectCmd.Flags().StringVarP(&proxyHost, "proxy", "", "", "Proxy host used for connection to border0.com")
 	socketConnectCmd.Flags().BoolVarP(&localssh, "localssh", "", false, "Start a local SSH server to accept SSH sessions on this host")
 	socketConnectCmd.Flags().BoolVarP(&localssh, "sshserver", "l", false, "Start a local SSH server to accept SSH sessions on this host")
+	addSshServerFlags(socketConnectCmd)
 	socketConnectCmd.Flags().MarkDeprecated("localssh", "use --sshserver instead")
 	socketConnectCmd.Flags().BoolVarP(&httpserver, "httpserver", "", false, "Start a local http server to accept http connections on this host")
 	socketConnectCmd.Flags().StringVarP(&httpserver_dir, "httpserver_dir", "", "", "Directory to serve http connections on this host")
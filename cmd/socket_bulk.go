@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/selector"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	socketSelectors []string
+	bulkDryRun      bool
+	bulkYes         bool
+	pruneConnector  string
+	prunePlugin     string
+	pruneOlderThan  string
+)
+
+// socketPruneCmd represents the socket prune command
+var socketPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete the sockets a connector created",
+	Long: `Delete the sockets a connector created, like the sockets of a connector host that's gone.
+
+The sockets are found by the connector_name and plugin_name tags the connector sets. A running
+connector creates its sockets again, stop it before pruning.`,
+	Example: `  border0 socket prune --connector my-connector --dry_run
+  border0 socket prune --connector my-connector --plugin Ec2Discover --older_than 7d`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if pruneConnector == "" {
+			log.Fatalf("error: --connector is required")
+		}
+
+		exprs := []string{"connector_name=" + pruneConnector}
+		if prunePlugin != "" {
+			exprs = append(exprs, "plugin_name="+prunePlugin)
+		}
+		sel, err := selector.Parse(exprs)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		client, err := http.NewClient()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		sockets := selectSockets(client, sel)
+		if pruneOlderThan != "" {
+			age, err := selector.ParseAge(pruneOlderThan)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			old, unknown := selector.OlderThan(sockets, age, time.Now())
+			if len(unknown) > 0 && len(unknown) == len(sockets) {
+				log.Fatalf("error: none of the %d sockets have a creation time, --older_than can't be used", len(sockets))
+			}
+			if len(unknown) > 0 {
+				fmt.Printf("WARNING: %d sockets have no creation time and are left out\n", len(unknown))
+			}
+			sockets = old
+		}
+
+		if !confirmSockets("Delete", sockets) {
+			return
+		}
+		deleteSockets(client, sockets)
+	},
+}
+
+// socketDeleteSelected deletes the sockets of the --selector flags of socket delete
+func socketDeleteSelected() {
+	sel, err := selector.Parse(socketSelectors)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	client, err := http.NewClient()
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	sockets := selectSockets(client, sel)
+	if !confirmSockets("Delete", sockets) {
+		return
+	}
+	deleteSockets(client, sockets)
+}
+
+// policySocketsSelected attaches a policy to, or detaches it from, the sockets of the --selector flags
+func policySocketsSelected(policy models.Policy, action string) {
+	sel, err := selector.Parse(socketSelectors)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	client, err := http.NewClient()
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	sockets := selectSockets(client, sel)
+
+	verb := "Attach policy " + policy.Name + " to"
+	if action == "remove" {
+		verb = "Detach policy " + policy.Name + " from"
+	}
+	if !confirmSockets(verb, sockets) {
+		return
+	}
+
+	body := models.AddSocketToPolicyRequest{}
+	for _, s := range sockets {
+		body.Actions = append(body.Actions, models.PolicyActionUpdateRequest{
+			ID:     s.SocketID,
+			Action: action,
+		})
+	}
+
+	err = client.Request("PUT", "policy/"+policy.ID+"/socket", nil, body)
+	if err != nil {
+		log.Fatalf(fmt.Sprintf("Error: %v", err))
+	}
+
+	if action == "remove" {
+		fmt.Printf("Policy detached from %d sockets\n", len(sockets))
+	} else {
+		fmt.Printf("Policy attached to %d sockets\n", len(sockets))
+	}
+}
+
+func selectSockets(client *http.Client, sel selector.Selector) []models.Socket {
+	sockets := []models.Socket{}
+	err := client.Request("GET", "socket", &sockets, nil)
+	if err != nil {
+		log.Fatalf(fmt.Sprintf("Error: %v", err))
+	}
+
+	return sel.Select(sockets)
+}
+
+// confirmSockets lists the sockets an action applies to and asks to go ahead,
+// unless --yes is given. It returns false for --dry_run
+func confirmSockets(action string, sockets []models.Socket) bool {
+	if len(sockets) == 0 {
+		fmt.Println("No sockets found")
+		return false
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Socket ID", "Name", "Type", "Connector", "Plugin", "Created"})
+	for _, s := range sockets {
+		created := ""
+		if s.CreatedAt != nil {
+			created = s.CreatedAt.Format(time.RFC3339)
+		}
+		t.AppendRow(table.Row{s.SocketID, s.Name, s.SocketType, s.Tags["connector_name"], s.Tags["plugin_name"], created})
+	}
+	t.SetStyle(table.StyleLight)
+	fmt.Printf("%s\n", t.Render())
+
+	if bulkDryRun {
+		fmt.Printf("Dry run, %d sockets match and nothing was changed\n", len(sockets))
+		return false
+	}
+	if bulkYes {
+		return true
+	}
+
+	confirmed := false
+	if err := survey.AskOne(&survey.Confirm{Message: fmt.Sprintf("%s %d sockets?", action, len(sockets))}, &confirmed); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+	}
+	return confirmed
+}
+
+func deleteSockets(client *http.Client, sockets []models.Socket) {
+	failed := 0
+	for _, s := range sockets {
+		if err := client.Request("DELETE", "socket/"+s.SocketID, nil, nil); err != nil {
+			fmt.Printf("Failed to delete socket %s: %v\n", s.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Socket %s deleted\n", s.Name)
+	}
+
+	if failed > 0 {
+		log.Fatalf("error: failed to delete %d of %d sockets", failed, len(sockets))
+	}
+}
+
+// checkBulkFlags rejects --dry_run and --yes without --selector, they only apply to the selected sockets
+func checkBulkFlags(cmd *cobra.Command) error {
+	if len(socketSelectors) == 0 && (cmd.Flags().Changed("dry_run") || cmd.Flags().Changed("yes")) {
+		return fmt.Errorf("error: --dry_run and --yes can only be used with --selector")
+	}
+	return nil
+}
+
+// addBulkFlags adds the --dry_run and --yes flags of commands that change the sockets of --selector
+func addBulkFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&bulkDryRun, "dry_run", "", false, "Only show the sockets that would be changed, with --selector")
+	cmd.Flags().BoolVarP(&bulkYes, "yes", "", false, "Don't ask for confirmation, with --selector")
+}
+
+func init() {
+	socketCmd.AddCommand(socketPruneCmd)
+
+	socketPruneCmd.Flags().StringVarP(&pruneConnector, "connector", "", "", "Name of the connector that created the sockets")
+	socketPruneCmd.Flags().StringVarP(&prunePlugin, "plugin", "", "", "Only prune the sockets of a discovery plugin, like Ec2Discover or StaticSocketFinder")
+	socketPruneCmd.Flags().StringVarP(&pruneOlderThan, "older_than", "", "", "Only prune sockets created longer ago than this, like 7d or 36h")
+	socketPruneCmd.Flags().BoolVarP(&bulkDryRun, "dry_run", "", false, "Only show the sockets that would be deleted")
+	socketPruneCmd.Flags().BoolVarP(&bulkYes, "yes", "", false, "Don't ask for confirmation")
+
+	selectorUsage := "Select sockets by name, type, upstream_type, dnsname or a tag, like type=ssh or connector_name=my-connector, the value can be a glob"
+	socketDeleteCmd.Flags().StringArrayVarP(&socketSelectors, "selector", "", nil, selectorUsage)
+	addBulkFlags(socketDeleteCmd)
+	policyAttachCmd.Flags().StringArrayVarP(&socketSelectors, "selector", "", nil, selectorUsage)
+	addBulkFlags(policyAttachCmd)
+	policyDettachCmd.Flags().StringArrayVarP(&socketSelectors, "selector", "", nil, selectorUsage)
+	addBulkFlags(policyDettachCmd)
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// editableSocket are the fields of a socket that can be changed without recreating it
+type editableSocket struct {
+	Description                    string            `json:"description"`
+	ProtectedSocket                bool              `json:"protected_socket"`
+	ProtectedUsername              string            `json:"protected_username"`
+	ProtectedPassword              string            `json:"protected_password"`
+	UpstreamType                   string            `json:"upstream_type"`
+	UpstreamUsername               string            `json:"upstream_username"`
+	UpstreamPassword               string            `json:"upstream_password"`
+	UpstreamHttpHostname           string            `json:"upstream_http_hostname"`
+	ConnectorAuthenticationEnabled bool              `json:"connector_authentication_enabled"`
+	OrgCustomDomain                string            `json:"org_custom_domain"`
+	Tags                           map[string]string `json:"tags"`
+}
+
+func newEditableSocket(s models.Socket) editableSocket {
+	return editableSocket{
+		Description:                    s.Description,
+		ProtectedSocket:                s.ProtectedSocket,
+		ProtectedUsername:              s.ProtectedUsername,
+		ProtectedPassword:              s.ProtectedPassword,
+		UpstreamType:                   s.UpstreamType,
+		UpstreamUsername:               s.UpstreamUsername,
+		UpstreamPassword:               s.UpstreamPassword,
+		UpstreamHttpHostname:           s.UpstreamHttpHostname,
+		ConnectorAuthenticationEnabled: s.ConnectorAuthenticationEnabled,
+		OrgCustomDomain:                s.OrgCustomDomain,
+		Tags:                           s.Tags,
+	}
+}
+
+func (e editableSocket) apply(s *models.Socket) {
+	s.Description = e.Description
+	s.ProtectedSocket = e.ProtectedSocket
+	s.ProtectedUsername = e.ProtectedUsername
+	s.ProtectedPassword = e.ProtectedPassword
+	s.UpstreamType = e.UpstreamType
+	s.UpstreamUsername = e.UpstreamUsername
+	s.UpstreamPassword = e.UpstreamPassword
+	s.UpstreamHttpHostname = e.UpstreamHttpHostname
+	s.ConnectorAuthenticationEnabled = e.ConnectorAuthenticationEnabled
+	s.OrgCustomDomain = e.OrgCustomDomain
+	s.Tags = e.Tags
+}
+
+const socketEditHeader = `# Edit the socket %s, it's updated when the editor exits.
+# The name and type of a socket can't be changed, create a new socket instead.
+`
+
+// socketUpdateCmd represents the socket update command
+var socketUpdateCmd = &cobra.Command{
+	Use:               "update [socket]",
+	Short:             "Update a socket",
+	Long:              "Update a socket, only the fields of the flags that are given are changed",
+	Example:           "  border0 socket update prod-db --description \"Production database\" --upstream_password \"$DB_PASSWORD\"",
+	ValidArgsFunction: AutocompleteSocket,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if socketID == "" && (len(args) == 0) {
+			return fmt.Errorf("error: no socket provided")
+		}
+
+		if len(args) > 0 {
+			socketID = args[0]
+		}
+
+		client, err := http.NewClient()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		socket := models.Socket{}
+		err = client.Request("GET", "socket/"+socketID, &socket, nil)
+		if err != nil {
+			log.Fatalf(fmt.Sprintf("Error: %v", err))
+		}
+
+		if err := applySocketUpdateFlags(cmd.LocalNonPersistentFlags(), &socket); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		updateSocket(client, socket)
+		return nil
+	},
+}
+
+// socketEditCmd represents the socket edit command
+var socketEditCmd = &cobra.Command{
+	Use:               "edit [socket]",
+	Short:             "Edit a socket in your editor",
+	Long:              "Edit a socket in your editor, from VISUAL or EDITOR, the socket is updated when the editor exits",
+	ValidArgsFunction: AutocompleteSocket,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if socketID == "" && (len(args) == 0) {
+			return fmt.Errorf("error: no socket provided")
+		}
+
+		if len(args) > 0 {
+			socketID = args[0]
+		}
+
+		client, err := http.NewClient()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		socket := models.Socket{}
+		err = client.Request("GET", "socket/"+socketID, &socket, nil)
+		if err != nil {
+			log.Fatalf(fmt.Sprintf("Error: %v", err))
+		}
+
+		current, err := yaml.Marshal(newEditableSocket(socket))
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		changed := false
+		initial := append([]byte(fmt.Sprintf(socketEditHeader, socket.Name)), current...)
+		err = util.EditUntilValid("border0-socket-"+socket.Name+"-*.yaml", "socket", initial, func(data []byte) (err error) {
+			socket, changed, err = parseEditedSocket(socket, data)
+			return err
+		})
+		if err != nil {
+			log.Fatalf("⛔ %v", err)
+		}
+
+		if !changed {
+			fmt.Println("No changes to the socket")
+			return nil
+		}
+
+		updateSocket(client, socket)
+		return nil
+	},
+}
+
+// applySocketUpdateFlags changes the fields of the socket whose flags are given
+func applySocketUpdateFlags(flags *pflag.FlagSet, socket *models.Socket) error {
+	changes := 0
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed && f.Name != "socket_id" {
+			changes++
+		}
+	})
+	if changes == 0 {
+		return errors.New("nothing to update, see border0 socket update --help for the flags")
+	}
+
+	if flags.Changed("description") {
+		socket.Description, _ = flags.GetString("description")
+	}
+	if flags.Changed("protected") {
+		protected, _ := flags.GetBool("protected")
+		username, _ := flags.GetString("username")
+		password, _ := flags.GetString("password")
+		if protected && (username == "" || password == "") {
+			return errors.New("--username and --password required when using --protected")
+		}
+		socket.ProtectedSocket = protected
+	}
+	if flags.Changed("username") {
+		socket.ProtectedUsername, _ = flags.GetString("username")
+	}
+	if flags.Changed("password") {
+		socket.ProtectedPassword, _ = flags.GetString("password")
+	}
+	if flags.Changed("upstream_type") {
+		upstreamType, _ := flags.GetString("upstream_type")
+		socket.UpstreamType = strings.ToLower(upstreamType)
+	}
+	if flags.Changed("upstream_username") {
+		socket.UpstreamUsername, _ = flags.GetString("upstream_username")
+	}
+	if flags.Changed("upstream_password") {
+		socket.UpstreamPassword, _ = flags.GetString("upstream_password")
+	}
+	if flags.Changed("upstream_http_hostname") {
+		socket.UpstreamHttpHostname, _ = flags.GetString("upstream_http_hostname")
+	}
+	if flags.Changed("connector_auth") {
+		socket.ConnectorAuthenticationEnabled, _ = flags.GetBool("connector_auth")
+	}
+	if flags.Changed("domain") {
+		socket.OrgCustomDomain, _ = flags.GetString("domain")
+	}
+	if flags.Changed("tag") {
+		tags, _ := flags.GetStringToString("tag")
+		if socket.Tags == nil {
+			socket.Tags = map[string]string{}
+		}
+		for k, v := range tags {
+			if v == "" {
+				delete(socket.Tags, k)
+			} else {
+				socket.Tags[k] = v
+			}
+		}
+	}
+
+	files := []struct {
+		flag, what string
+		field      **string
+	}{
+		{"upstream_certificate_filename", "certificate", &socket.UpstreamCert},
+		{"upstream_key_filename", "key", &socket.UpstreamKey},
+		{"upstream_ca_filename", "ca", &socket.UpstreamCa},
+	}
+	for _, file := range files {
+		path, _ := flags.GetString(file.flag)
+		if path == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the upstream %s file: %w", file.what, err)
+		}
+		*file.field = stringPtr(string(content))
+	}
+
+	return validateSocketUpdate(*socket)
+}
+
+// parseEditedSocket applies the edited fields to the socket, changed is false
+// when the edit left them as they were
+func parseEditedSocket(socket models.Socket, data []byte) (models.Socket, bool, error) {
+	var e editableSocket
+	if err := yaml.UnmarshalStrict(data, &e); err != nil {
+		return socket, false, fmt.Errorf("invalid socket: %w", err)
+	}
+
+	updated := socket
+	e.apply(&updated)
+	if err := validateSocketUpdate(updated); err != nil {
+		return socket, false, err
+	}
+
+	current, err := yaml.Marshal(newEditableSocket(socket))
+	if err != nil {
+		return socket, false, err
+	}
+	edited, err := yaml.Marshal(e)
+	if err != nil {
+		return socket, false, err
+	}
+
+	return updated, !bytes.Equal(current, edited), nil
+}
+
+// validateSocketUpdate checks the upstream type of a socket matches its type
+func validateSocketUpdate(s models.Socket) error {
+	switch s.SocketType {
+	case "database":
+		if !isDatabaseUpstreamType(s.UpstreamType) {
+			return fmt.Errorf("upstream_type should be mysql, postgres, redis, mongodb or mssql")
+		}
+	case "http", "https":
+		if s.UpstreamType != "http" && s.UpstreamType != "https" && s.UpstreamType != "" {
+			return fmt.Errorf("upstream_type should be either http, https")
+		}
+	}
+
+	return nil
+}
+
+func updateSocket(client *http.Client, socket models.Socket) {
+	// Force cloud auth
+	socket.CloudAuthEnabled = true
+
+	s := models.Socket{}
+	err := client.Request("PUT", "socket/"+socket.SocketID, &s, socket)
+	if err != nil {
+		log.Fatalf(fmt.Sprintf("Error: %v", err))
+	}
+
+	orgWidePolicies := []models.Policy{}
+	err = client.Request("GET", "policies/?org_wide=true", &orgWidePolicies, nil)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	fmt.Print(print_socket(s, orgWidePolicies))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// addSocketUpdateFlags adds the flags of the fields socket update can change
+func addSocketUpdateFlags(flags *pflag.FlagSet) {
+	flags.StringP("description", "r", "", "Socket description")
+	flags.BoolP("protected", "p", false, "Protected")
+	flags.StringP("username", "u", "", "Username, required when protected set to true")
+	flags.StringP("password", "", "", "Password, required when protected set to true")
+	flags.StringP("upstream_username", "j", "", "Upstream username used to connect to upstream database")
+	flags.StringP("upstream_password", "k", "", "Upstream password used to connect to upstream database")
+	flags.StringP("upstream_http_hostname", "", "", "Upstream http hostname")
+	flags.StringP("upstream_type", "", "", "Upstream type: http, https for http sockets or mysql, postgres, redis, mongodb, mssql for database sockets")
+	flags.BoolP("connector_auth", "c", false, "Enables connector authentication")
+	flags.StringP("domain", "o", "", "Use custom domain for socket")
+	flags.StringToStringP("tag", "", nil, "Tag to set, like --tag env=prod, an empty value removes the tag")
+	flags.StringP("upstream_certificate_filename", "f", "", "path to file from where to read the upstream client certificate")
+	flags.StringP("upstream_key_filename", "y", "", "path to file from where to read the upstream client key")
+	flags.StringP("upstream_ca_filename", "a", "", "path to file from where to read the upstream ca certificate")
+}
+
+func init() {
+	socketCmd.AddCommand(socketUpdateCmd)
+	socketCmd.AddCommand(socketEditCmd)
+
+	socketUpdateCmd.Flags().StringVarP(&socketID, "socket_id", "s", "", "Socket ID")
+	addSocketUpdateFlags(socketUpdateCmd.Flags())
+	socketUpdateCmd.RegisterFlagCompletionFunc("socket_id", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getSockets(toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	socketEditCmd.Flags().StringVarP(&socketID, "socket_id", "s", "", "Socket ID")
+	socketEditCmd.RegisterFlagCompletionFunc("socket_id", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return getSockets(toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplySocketUpdateFlags(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("ca"), 0600))
+
+	tests := []struct {
+		name    string
+		socket  models.Socket
+		args    []string
+		want    models.Socket
+		wantErr string
+	}{
+		{
+			name:    "nothing_to_update",
+			socket:  models.Socket{SocketType: "http"},
+			args:    []string{"--socket_id", "web"},
+			wantErr: "nothing to update, see border0 socket update --help for the flags",
+		},
+		{
+			name:   "only_given_fields",
+			socket: models.Socket{SocketType: "database", UpstreamType: "mysql", Description: "old", UpstreamUsername: "app"},
+			args:   []string{"--description", "new", "--upstream_password", "secret"},
+			want:   models.Socket{SocketType: "database", UpstreamType: "mysql", Description: "new", UpstreamUsername: "app", UpstreamPassword: "secret"},
+		},
+		{
+			name:   "tags_added_and_removed",
+			socket: models.Socket{SocketType: "ssh", Tags: map[string]string{"env": "dev", "team": "ops"}},
+			args:   []string{"--tag", "env=prod", "--tag", "team=", "--tag", "owner=alice"},
+			want:   models.Socket{SocketType: "ssh", Tags: map[string]string{"env": "prod", "owner": "alice"}},
+		},
+		{
+			name:   "tags_on_untagged_socket",
+			socket: models.Socket{SocketType: "ssh"},
+			args:   []string{"--tag", "env=prod"},
+			want:   models.Socket{SocketType: "ssh", Tags: map[string]string{"env": "prod"}},
+		},
+		{
+			name:   "database_upstream_type",
+			socket: models.Socket{SocketType: "database", UpstreamType: "mysql"},
+			args:   []string{"--upstream_type", "Postgres"},
+			want:   models.Socket{SocketType: "database", UpstreamType: "postgres"},
+		},
+		{
+			name:    "http_upstream_type_on_database",
+			socket:  models.Socket{SocketType: "database", UpstreamType: "mysql"},
+			args:    []string{"--upstream_type", "https"},
+			wantErr: "upstream_type should be mysql, postgres, redis, mongodb or mssql",
+		},
+		{
+			name:   "https_upstream_type",
+			socket: models.Socket{SocketType: "http", UpstreamType: "http"},
+			args:   []string{"--upstream_type", "https"},
+			want:   models.Socket{SocketType: "http", UpstreamType: "https"},
+		},
+		{
+			name:    "database_upstream_type_on_http",
+			socket:  models.Socket{SocketType: "http", UpstreamType: "http"},
+			args:    []string{"--upstream_type", "mysql"},
+			wantErr: "upstream_type should be either http, https",
+		},
+		{
+			name:   "any_upstream_type_on_tcp",
+			socket: models.Socket{SocketType: "tcp"},
+			args:   []string{"--upstream_type", "rdp"},
+			want:   models.Socket{SocketType: "tcp", UpstreamType: "rdp"},
+		},
+		{
+			name:    "protected_without_credentials",
+			socket:  models.Socket{SocketType: "http"},
+			args:    []string{"--protected", "--username", "admin"},
+			wantErr: "--username and --password required when using --protected",
+		},
+		{
+			name:   "protected",
+			socket: models.Socket{SocketType: "http"},
+			args:   []string{"--protected", "--username", "admin", "--password", "secret"},
+			want:   models.Socket{SocketType: "http", ProtectedSocket: true, ProtectedUsername: "admin", ProtectedPassword: "secret"},
+		},
+		{
+			name:   "upstream_ca_file",
+			socket: models.Socket{SocketType: "database", UpstreamType: "postgres"},
+			args:   []string{"--upstream_ca_filename", caFile},
+			want:   models.Socket{SocketType: "database", UpstreamType: "postgres", UpstreamCa: stringPtr("ca")},
+		},
+		{
+			name:    "missing_upstream_ca_file",
+			socket:  models.Socket{SocketType: "database", UpstreamType: "postgres"},
+			args:    []string{"--upstream_ca_filename", filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: "failed to read the upstream ca file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("update", pflag.ContinueOnError)
+			flags.String("socket_id", "", "")
+			addSocketUpdateFlags(flags)
+			require.NoError(t, flags.Parse(tt.args))
+
+			socket := tt.socket
+			err := applySocketUpdateFlags(flags, &socket)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, socket)
+		})
+	}
+}
+
+func TestParseEditedSocket(t *testing.T) {
+	socket := models.Socket{
+		SocketID:     "socket-id",
+		Name:         "prod-db",
+		SocketType:   "database",
+		UpstreamType: "postgres",
+		Description:  "Production database",
+		Tags:         map[string]string{"env": "prod"},
+	}
+
+	current, err := yaml.Marshal(newEditableSocket(socket))
+	require.NoError(t, err)
+
+	edit := func(e editableSocket) []byte {
+		data, err := yaml.Marshal(e)
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		got, changed, err := parseEditedSocket(socket, append([]byte(fmt.Sprintf(socketEditHeader, socket.Name)), current...))
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, socket, got)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		e := newEditableSocket(socket)
+		e.Description = "Main database"
+		e.Tags = map[string]string{"env": "prod", "team": "data"}
+
+		got, changed, err := parseEditedSocket(socket, edit(e))
+		assert.NoError(t, err)
+		assert.True(t, changed)
+
+		want := socket
+		want.Description = "Main database"
+		want.Tags = map[string]string{"env": "prod", "team": "data"}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("invalid_upstream_type", func(t *testing.T) {
+		e := newEditableSocket(socket)
+		e.UpstreamType = "https"
+
+		got, _, err := parseEditedSocket(socket, edit(e))
+		assert.EqualError(t, err, "upstream_type should be mysql, postgres, redis, mongodb or mssql")
+		assert.Equal(t, socket, got)
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		_, _, err := parseEditedSocket(socket, append(current, []byte("name: other\n")...))
+		assert.ErrorContains(t, err, "invalid socket")
+	})
+}
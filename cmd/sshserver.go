@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/borderzero/border0-cli/internal/ssh"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// sshServerCmd groups the commands for the built-in ssh server
+var sshServerCmd = &cobra.Command{
+	Use:   "sshserver",
+	Short: "Manage the built-in SSH server",
+	Long: `Manage the built-in SSH server that --localssh runs.
+
+Every identity the socket's policies allow may log in, with a certificate signed for the
+shared border0 principal. Without --user_mapping any such identity may log in as any
+local user except root, use --user_mapping to restrict the local users per identity.`,
+}
+
+var sshServerFingerprintCmd = &cobra.Command{
+	Use:   "fingerprint",
+	Short: "Print the host key fingerprints of the built-in SSH server",
+	Run: func(cmd *cobra.Command, args []string) {
+		signers, err := ssh.LoadHostKeys(hostKeyDir)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		for _, signer := range signers {
+			publicKey := signer.PublicKey()
+			if knownHosts {
+				fmt.Print(string(gossh.MarshalAuthorizedKey(publicKey)))
+			} else {
+				fmt.Printf("%s %s\n", publicKey.Type(), gossh.FingerprintSHA256(publicKey))
+			}
+		}
+	},
+}
+
+// sftpServerCmd serves a single sftp session for the built-in ssh server
+var sftpServerCmd = &cobra.Command{
+	Use:    ssh.SftpServerCommand,
+	Short:  "Serve a single sftp session on stdin/stdout",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ssh.ServeSftp(sftpUser, sftpChroot, sftpReadOnly); err != nil {
+			log.Fatalf("error: %v", err)
+		}
+	},
+}
+
+// addSshServerFlags adds the flags that configure the built-in ssh server
+func addSshServerFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&hostKeyDir, "host_key_dir", "", "", "Directory where the host keys of the local SSH server are stored, default ~/.border0")
+	cmd.Flags().StringArrayVarP(&userMapping, "user_mapping", "", []string{}, "Map certificate identities to local users (identity=user[,user...]), identities can be globs like *@example.com and %u expands to the local part of the email. Without a mapping ANY identity allowed by the socket's policies may log in as ANY local user except root")
+	cmd.Flags().BoolVarP(&allowRoot, "allow_root", "", false, "Allow logging in as root on the local SSH server")
+	cmd.Flags().DurationVarP(&idleTimeout, "idle_timeout", "", 0, "Close SSH sessions without input or output for this long, e.g. 15m")
+	cmd.Flags().DurationVarP(&maxSessionDuration, "max_session_duration", "", 0, "Close SSH sessions after this long, e.g. 8h")
+	cmd.Flags().IntVarP(&maxSessionsPerIdentity, "max_sessions_per_identity", "", 0, "Maximum number of concurrent SSH sessions per identity")
+	cmd.Flags().DurationVarP(&sessionWarning, "session_warning", "", 0, "How long before closing a session a warning is shown, default 1m")
+	cmd.Flags().BoolVarP(&sftpDisabled, "disable_sftp", "", false, "Disable the sftp subsystem of the local SSH server")
+	cmd.Flags().StringVarP(&sftpChroot, "sftp_chroot", "", "", "Chroot directory for sftp sessions, %u is replaced by the username and %h by the home directory")
+	cmd.Flags().BoolVarP(&sftpReadOnly, "sftp_readonly", "", false, "Only allow read access for sftp sessions")
+	cmd.Flags().StringSliceVarP(&allowLocalForward, "allow_local_forward", "", []string{}, "Destinations (host:port) the local SSH server allows local port forwarding (ssh -L) to, host can be a cidr or glob and port a range or *")
+	cmd.Flags().StringSliceVarP(&allowRemoteForward, "allow_remote_forward", "", []string{}, "Addresses (host:port) the local SSH server allows remote port forwarding (ssh -R) on")
+	cmd.Flags().BoolVarP(&allowAgentForwarding, "allow_agent_forwarding", "", false, "Allow agent forwarding (ssh -A) on the local SSH server")
+}
+
+// sshServerConfig returns the built-in ssh server configuration from the command line flags
+func sshServerConfig() *ssh.ServerConfig {
+	mapping, err := ssh.ParseUserMapping(userMapping)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+
+	return &ssh.ServerConfig{
+		HostKeyDir: hostKeyDir,
+		Users: ssh.UserConfig{
+			Mapping:   mapping,
+			AllowRoot: allowRoot,
+		},
+		Sftp: ssh.SftpConfig{
+			Disabled: sftpDisabled,
+			Chroot:   sftpChroot,
+			ReadOnly: sftpReadOnly,
+		},
+		Forwarding: ssh.ForwardingConfig{
+			LocalAllowed:  allowLocalForward,
+			RemoteAllowed: allowRemoteForward,
+			Agent:         allowAgentForwarding,
+		},
+		Limits: ssh.SessionLimits{
+			IdleTimeout:            idleTimeout,
+			MaxDuration:            maxSessionDuration,
+			MaxSessionsPerIdentity: maxSessionsPerIdentity,
+			WarningBefore:          sessionWarning,
+		},
+	}
+}
+
+func init() {
+	sftpServerCmd.Flags().StringVarP(&sftpUser, "user", "", "", "User to serve the sftp session as")
+	sftpServerCmd.Flags().StringVarP(&sftpChroot, "chroot", "", "", "Directory to chroot into")
+	sftpServerCmd.Flags().BoolVarP(&sftpReadOnly, "readonly", "", false, "Read only sftp session")
+	sftpServerCmd.MarkFlagRequired("user")
+	rootCmd.AddCommand(sftpServerCmd)
+
+	sshServerFingerprintCmd.Flags().StringVarP(&hostKeyDir, "host_key_dir", "", "", "Directory where the host keys are stored, default ~/.border0")
+	sshServerFingerprintCmd.Flags().BoolVarP(&knownHosts, "known_hosts", "", false, "Print the public host keys instead of the fingerprints")
+	sshServerCmd.AddCommand(sshServerFingerprintCmd)
+	rootCmd.AddCommand(sshServerCmd)
+}
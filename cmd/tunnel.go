@@ -25,6 +25,7 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/output"
 	"github.com/borderzero/border0-cli/internal/ssh"
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
@@ -55,15 +56,15 @@ var tunnelListCmd = &cobra.Command{
 			log.Fatalf(fmt.Sprintf("Error: %v", err))
 		}
 
-		ta := table.NewWriter()
-		ta.AppendHeader(table.Row{"Socket ID", "Tunnel ID", "Tunnel Server", "Relay Port"})
-
-		for _, t := range tunnels {
-			ta.AppendRow(table.Row{socketID, t.TunnelID, t.TunnelServer, t.LocalPort})
+		columns := []output.Column[models.Tunnel]{
+			{Field: "socket_id", Header: "Socket ID", Value: func(models.Tunnel) interface{} { return socketID }},
+			{Field: "tunnel_id", Header: "Tunnel ID", Value: func(t models.Tunnel) interface{} { return t.TunnelID }},
+			{Field: "tunnel_server", Header: "Tunnel Server", Value: func(t models.Tunnel) interface{} { return t.TunnelServer }},
+			{Field: "local_port", Header: "Relay Port", Value: func(t models.Tunnel) interface{} { return t.LocalPort }},
+		}
+		if err := output.Print(tunnels, columns); err != nil {
+			log.Fatalf("error: %v", err)
 		}
-
-		ta.SetStyle(table.StyleLight)
-		fmt.Printf("%s\n", ta.Render())
 	},
 }
 
@@ -205,7 +206,7 @@ var tunnelConnectCmd = &cobra.Command{
 			}
 		}
 
-		err = ssh.SshConnect(userIDStr, socketID, tunnelID, port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, socket.ConnectorAuthenticationEnabled, caCertPool)
+		err = ssh.SshConnect(userIDStr, socketID, tunnelID, port, hostname, identityFile, proxyHost, version, httpserver, localssh, org.Certificates["ssh_public_key"], "", httpserver_dir, socket.ConnectorAuthenticationEnabled, caCertPool, sshServerConfig())
 		if err != nil {
 			fmt.Println(err)
 		}
@@ -277,6 +278,7 @@ func init() {
 	tunnelConnectCmd.Flags().StringVarP(&proxyHost, "proxy", "", "", "Proxy host used for connection to border0")
 	tunnelConnectCmd.Flags().BoolVarP(&localssh, "localssh", "", false, "Start a local SSH server to accept SSH sessions on this host")
 	tunnelConnectCmd.Flags().BoolVarP(&localssh, "sshserver", "l", false, "Start a local SSH server to accept SSH sessions on this host")
+	addSshServerFlags(tunnelConnectCmd)
 	tunnelConnectCmd.MarkFlagRequired("tunnel_id")
 	tunnelConnectCmd.MarkFlagRequired("socket_id")
 	tunnelConnectCmd.Flags().MarkDeprecated("localssh", "use --sshserver instead")
@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/credstore"
+	"github.com/borderzero/border0-cli/internal/profile"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -83,6 +85,8 @@ func NewAPI(opts ...APIOption) *Border0API {
 func APIURL() string {
 	if os.Getenv("BORDER0_API") != "" {
 		return os.Getenv("BORDER0_API")
+	} else if url := profile.Current().APIURL; url != "" {
+		return url
 	} else {
 		return APIUrl
 	}
@@ -93,10 +97,10 @@ func getToken() (*models.Credentials, error) {
 		return models.NewCredentials(os.Getenv("BORDER0_ADMIN_TOKEN"), models.CredentialsTypeToken), nil
 	}
 
-	if _, err := os.Stat(tokenfile()); os.IsNotExist(err) {
+	content, err := credstore.New().Read(tokenfile())
+	if errors.Is(err, credstore.ErrNotFound) {
 		return nil, errors.New("API: please login first (no token found)")
 	}
-	content, err := ioutil.ReadFile(tokenfile())
 	if err != nil {
 		return nil, err
 	}
@@ -106,13 +110,11 @@ func getToken() (*models.Credentials, error) {
 }
 
 func tokenfile() string {
-	tokenfile := ""
+	dir := fmt.Sprintf("%s/.border0", os.Getenv("HOME"))
 	if runtime.GOOS == "windows" {
-		tokenfile = fmt.Sprintf("%s/.border0/token", os.Getenv("APPDATA"))
-	} else {
-		tokenfile = fmt.Sprintf("%s/.border0/token", os.Getenv("HOME"))
+		dir = fmt.Sprintf("%s/.border0", os.Getenv("APPDATA"))
 	}
-	return tokenfile
+	return profile.Path(dir, "token")
 }
 
 func (a *Border0API) Request(method string, url string, target interface{}, data interface{}, requireAccessToken bool) error {
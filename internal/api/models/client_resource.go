@@ -66,6 +66,8 @@ func (c ClientResource) Instruction() string {
 	case enum.TLSSocket:
 		instruction = fmt.Sprintf("border0 client tls --host %s\n", firstDomain) +
 			fmt.Sprintf("border0 client tls --host %s --listener <local_port>", firstDomain)
+	case enum.TCPSocket:
+		instruction = fmt.Sprintf("border0 client tcp --host %s --listener <local_port>", firstDomain)
 	case enum.DatabaseSocket:
 		instruction = fmt.Sprintf("border0 client db --host %s", firstDomain)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -80,6 +81,7 @@ type Socket struct {
 	PolicyNames                    []string          `json:"policy_names,omitempty"`
 	Policies                       []Policy          `json:"policies,omitempty"`
 	OrgCustomDomain                string            `json:"org_custom_domain,omitempty"`
+	CreatedAt                      *time.Time        `json:"created_at,omitempty"`
 
 	TargetHostname string         `json:"-"`
 	TargetPort     int            `json:"-"`
@@ -141,6 +143,21 @@ func (s *Socket) BuildConnectorDataByTags() {
 	s.ConnectorData = &data
 }
 
+// databasePorts are the default ports of the supported database upstream types
+var databasePorts = map[int]string{
+	3306:  "mysql",
+	5432:  "postgres",
+	6379:  "redis",
+	27017: "mongodb",
+	1433:  "mssql",
+}
+
+// remoteDesktopPorts are the default ports of the remote desktop upstream types of tcp sockets
+var remoteDesktopPorts = map[int]string{
+	3389: "rdp",
+	5900: "vnc",
+}
+
 func (s *Socket) SetupTypeAndUpstreamTypeByPortOrTags() {
 	if s.UpstreamType == "" {
 		s.UpstreamType = "http"
@@ -153,26 +170,33 @@ func (s *Socket) SetupTypeAndUpstreamTypeByPortOrTags() {
 			case "postgres":
 				s.UpstreamType = "postgres"
 				s.SocketType = "database"
+			case "redis", "mongodb", "mssql":
+				s.UpstreamType = s.SocketType
+				s.SocketType = "database"
 			case "database":
-				if s.TargetPort == 3306 {
-					s.UpstreamType = "mysql"
-				}
-				if s.TargetPort == 5432 {
-					s.UpstreamType = "postgres"
+				if upstreamType, ok := databasePorts[s.TargetPort]; ok {
+					s.UpstreamType = upstreamType
 				}
 			case "https":
 				s.SocketType = "http"
 				s.UpstreamType = "https"
+			case "rdp", "vnc":
+				// remote desktops are plain tcp streams for the edge
+				s.UpstreamType = s.SocketType
+				s.SocketType = "tcp"
+			case "tcp":
+				if upstreamType, ok := remoteDesktopPorts[s.TargetPort]; ok {
+					s.UpstreamType = upstreamType
+				}
 			}
 
 		} else {
+			// connectors keep the type of existing untyped sockets, so inferring
+			// a type for more ports doesn't recreate them
 			switch s.TargetPort {
-			case 3306:
+			case 3306, 5432, 6379, 27017, 1433:
 				s.SocketType = "database"
-				s.UpstreamType = "mysql"
-			case 5432:
-				s.SocketType = "database"
-				s.UpstreamType = "postgres"
+				s.UpstreamType = databasePorts[s.TargetPort]
 			case 22:
 				s.SocketType = "ssh"
 			case 80:
@@ -180,6 +204,12 @@ func (s *Socket) SetupTypeAndUpstreamTypeByPortOrTags() {
 			case 443:
 				s.SocketType = "http"
 				s.UpstreamType = "https"
+			case 3389:
+				s.SocketType = "tcp"
+				s.UpstreamType = "rdp"
+			case 5900:
+				s.SocketType = "tcp"
+				s.UpstreamType = "vnc"
 			}
 		}
 	}
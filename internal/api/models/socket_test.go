@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocket_SetupTypeAndUpstreamTypeByPortOrTags(t *testing.T) {
+	tests := []struct {
+		name             string
+		socket           Socket
+		wantSocketType   string
+		wantUpstreamType string
+	}{
+		{name: "mysql port", socket: Socket{TargetPort: 3306}, wantSocketType: "database", wantUpstreamType: "mysql"},
+		{name: "redis port", socket: Socket{TargetPort: 6379}, wantSocketType: "database", wantUpstreamType: "redis"},
+		{name: "mongodb port", socket: Socket{TargetPort: 27017}, wantSocketType: "database", wantUpstreamType: "mongodb"},
+		{name: "mssql port", socket: Socket{TargetPort: 1433}, wantSocketType: "database", wantUpstreamType: "mssql"},
+		{name: "database type on redis port", socket: Socket{SocketType: "database", TargetPort: 6379}, wantSocketType: "database", wantUpstreamType: "redis"},
+		{name: "database type on mongodb port", socket: Socket{SocketType: "database", TargetPort: 27017}, wantSocketType: "database", wantUpstreamType: "mongodb"},
+		{name: "ssh port", socket: Socket{TargetPort: 22}, wantSocketType: "ssh", wantUpstreamType: "http"},
+		{name: "untyped unknown port", socket: Socket{TargetPort: 389}, wantSocketType: "", wantUpstreamType: "http"},
+		{name: "tcp type", socket: Socket{SocketType: "tcp", TargetPort: 25}, wantSocketType: "tcp", wantUpstreamType: "http"},
+		{name: "rdp port", socket: Socket{TargetPort: 3389}, wantSocketType: "tcp", wantUpstreamType: "rdp"},
+		{name: "vnc port", socket: Socket{TargetPort: 5900}, wantSocketType: "tcp", wantUpstreamType: "vnc"},
+		{name: "tcp type on rdp port", socket: Socket{SocketType: "tcp", TargetPort: 3389}, wantSocketType: "tcp", wantUpstreamType: "rdp"},
+		{name: "tcp type on vnc port", socket: Socket{SocketType: "tcp", TargetPort: 5900}, wantSocketType: "tcp", wantUpstreamType: "vnc"},
+		{name: "vnc type", socket: Socket{SocketType: "vnc", TargetPort: 5901}, wantSocketType: "tcp", wantUpstreamType: "vnc"},
+		{name: "redis type", socket: Socket{SocketType: "redis", TargetPort: 6380}, wantSocketType: "database", wantUpstreamType: "redis"},
+		{name: "database type on mssql port", socket: Socket{SocketType: "database", TargetPort: 1433}, wantSocketType: "database", wantUpstreamType: "mssql"},
+		{name: "explicit upstream type", socket: Socket{SocketType: "database", UpstreamType: "mongodb", TargetPort: 6379}, wantSocketType: "database", wantUpstreamType: "mongodb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.socket.SetupTypeAndUpstreamTypeByPortOrTags()
+			assert.Equal(t, tt.wantSocketType, tt.socket.SocketType)
+			assert.Equal(t, tt.wantUpstreamType, tt.socket.UpstreamType)
+		})
+	}
+}
@@ -29,6 +29,8 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api"
 	"github.com/borderzero/border0-cli/internal/client/password"
+	"github.com/borderzero/border0-cli/internal/credstore"
+	"github.com/borderzero/border0-cli/internal/profile"
 	jwt "github.com/golang-jwt/jwt"
 	"github.com/moby/term"
 	"github.com/pavlo-v-chernykh/keystore-go/v4"
@@ -68,8 +70,7 @@ func MTLSLogin(hostname string) (string, jwt.MapClaims, error) {
 			return "", nil, errors.New("token from environment variable BORDER0_CLIENT_TOKEN is expired")
 		}
 	} else {
-		if _, err := os.Stat(tokenFile); err == nil {
-			content, _ := os.ReadFile(tokenFile)
+		if content, err := credstore.New().Read(tokenFile); err == nil {
 			tokenString := strings.TrimRight(string(content), "\n")
 
 			if CheckIfTokenIsExpired(tokenString) {
@@ -97,23 +98,7 @@ func MTLSLogin(hostname string) (string, jwt.MapClaims, error) {
 		url := fmt.Sprintf("%s/mtls-ca/socket/%s/auth?port=%d", api.APIURL(), hostname, localPort)
 		token = Launch(url, listener)
 
-		// create dir if not exists
-		configPath := filepath.Dir(tokenFile)
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			if err := os.Mkdir(configPath, 0700); err != nil {
-				return "", nil, fmt.Errorf("failed to create directory %s : %w", configPath, err)
-			}
-		}
-
-		f, err := os.Create(tokenFile)
-		if err != nil {
-			return "", nil, fmt.Errorf("failed to create token: %w", err)
-		}
-		if err = os.Chmod(tokenFile, 0600); err != nil {
-			return "", nil, fmt.Errorf("failed to write token: %w", err)
-		}
-		defer f.Close()
-		if _, err = f.WriteString(fmt.Sprintf("%s\n", token)); err != nil {
+		if err := credstore.New().Write(tokenFile, []byte(fmt.Sprintf("%s\n", token))); err != nil {
 			return "", nil, fmt.Errorf("failed to write token: %w", err)
 		}
 	}
@@ -222,12 +207,14 @@ func WriteCertToFile(cert *CertificateResponse, socketDNS string) (crtPath, keyP
 	crtPath = filepath.Join(dotDir, socketDNS+".crt")
 	keyPath = filepath.Join(dotDir, socketDNS+".key")
 
-	if err = os.WriteFile(keyPath, []byte(cert.PrivateKey), 0600); err != nil {
+	// database and desktop clients read the certificate and key from disk, so
+	// they are stored in plaintext whatever the credential store
+	if err = (credstore.Plaintext{}).Write(keyPath, []byte(cert.PrivateKey)); err != nil {
 		err = fmt.Errorf("error: failed to write key file : %w", err)
 		return
 	}
 
-	if err = os.WriteFile(crtPath, []byte(cert.Certificate), 0600); err != nil {
+	if err = (credstore.Plaintext{}).Write(crtPath, []byte(cert.Certificate)); err != nil {
 		err = fmt.Errorf("error: failed to write certificate file : %w", err)
 		return
 	}
@@ -246,21 +233,18 @@ func GetSocketPort(name string, token string) (socketPort int, err error) {
 }
 
 func OrgIDFromToken() (orgID string) {
-	tokenfile := MTLSTokenFile()
-	if _, err := os.Stat(tokenfile); os.IsNotExist(err) {
+	content, err := credstore.New().Read(MTLSTokenFile())
+	if err != nil {
 		return
-	} else {
-		content, _ := os.ReadFile(tokenfile)
-		if err == nil {
-			tokenString := strings.TrimRight(string(content), "\n")
-			jwtToken, _ := jwt.Parse(tokenString, nil)
-			if jwtToken != nil {
-				claims := jwtToken.Claims.(jwt.MapClaims)
+	}
 
-				if _, ok := claims["org_id"]; ok {
-					orgID = claims["org_id"].(string)
-				}
-			}
+	tokenString := strings.TrimRight(string(content), "\n")
+	jwtToken, _ := jwt.Parse(tokenString, nil)
+	if jwtToken != nil {
+		claims := jwtToken.Claims.(jwt.MapClaims)
+
+		if _, ok := claims["org_id"]; ok {
+			orgID = claims["org_id"].(string)
 		}
 	}
 
@@ -357,7 +341,7 @@ func MTLSTokenFile() string {
 	if runtime.GOOS == "windows" {
 		home = os.Getenv("APPDATA")
 	}
-	return filepath.Join(home, ".border0/client_token")
+	return profile.Path(filepath.Join(home, ".border0"), "client_token")
 }
 
 func Launch(url string, listener net.Listener) string {
@@ -584,9 +568,10 @@ func GenSSHKey(token, orgID, hostname string) (*SSHSignResponse, error) {
 		return nil, fmt.Errorf("failed to create ssh key: %v", err)
 	}
 
-	// write key
+	// write key, OpenSSH reads it from disk so it's stored in plaintext
+	// whatever the credential store
 	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: parsed})
-	err = os.WriteFile(sshKeyPath, keyPem, 0600)
+	err = (credstore.Plaintext{}).Write(sshKeyPath, keyPem)
 	if err != nil {
 		return nil, fmt.Errorf("failed to write ssh key: %v", err)
 	}
@@ -627,7 +612,7 @@ func GenSSHKey(token, orgID, hostname string) (*SSHSignResponse, error) {
 		log.Fatalln("error: failed to decode certificate")
 	}
 
-	err = os.WriteFile(sshCertPath, []byte(cert.SSHCertSigned), 0600)
+	err = (credstore.Plaintext{}).Write(sshCertPath, []byte(cert.SSHCertSigned))
 	if err != nil {
 		return nil, fmt.Errorf("failed to write ssh key: %w", err)
 	}
@@ -780,6 +765,31 @@ func StartConnectorAuthListener(addr string, certificate tls.Certificate, port i
 		InsecureSkipVerify: true,
 	}
 
+	return startLocalListener(port, func() (net.Conn, error) {
+		return ConnectorAuthConnect(addr, tlsConfig)
+	})
+}
+
+// StartLocalTLSListener starts a local listener for clients that can't present
+// a client certificate themselves, the connections are passed on to the socket
+// over TLS with the certificate, through the connector when connector
+// authentication is enabled
+func StartLocalTLSListener(addr string, certificate tls.Certificate, connectorAuthenticationEnabled bool, port int) (int, error) {
+	if connectorAuthenticationEnabled {
+		return StartConnectorAuthListener(addr, certificate, port)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates:       []tls.Certificate{certificate},
+		InsecureSkipVerify: true,
+	}
+
+	return startLocalListener(port, func() (net.Conn, error) {
+		return tls.Dial("tcp", addr, tlsConfig)
+	})
+}
+
+func startLocalListener(port int, dial func() (net.Conn, error)) (int, error) {
 	l, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
 	if err != nil {
 		return 0, fmt.Errorf("unable to start local TLS listener, %s", err)
@@ -794,9 +804,10 @@ func StartConnectorAuthListener(addr string, certificate tls.Certificate, port i
 			}
 
 			go func() {
-				conn, err := ConnectorAuthConnect(addr, tlsConfig)
+				conn, err := dial()
 				if err != nil {
 					log.Printf("Failed to connect: %v", err)
+					lcon.Close()
 					return
 				}
 
@@ -848,3 +859,48 @@ func handleConnection(src net.Conn, dst net.Conn) {
 
 	<-chDone
 }
+
+// Logout wipes the client token of the current profile, and the certificates
+// and keys of its organization
+func Logout() error {
+	orgID := OrgIDFromToken()
+
+	if err := credstore.New().Delete(MTLSTokenFile()); err != nil {
+		return fmt.Errorf("failed to delete client token: %w", err)
+	}
+	if orgID == "" {
+		return nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home dir: %w", err)
+	}
+
+	files := []string{
+		filepath.Join(home, ".border0", orgID+".crt"),
+		filepath.Join(home, ".border0", orgID+".key"),
+		filepath.Join(home, ".border0", orgID+".jks"),
+		filepath.Join(home, ".ssh", orgID),
+		filepath.Join(home, ".ssh", orgID+"-cert.pub"),
+	}
+
+	// the per host links to the org's ssh key and certificate made by ssh-keysign
+	sshDir := filepath.Join(home, ".ssh")
+	if entries, err := os.ReadDir(sshDir); err == nil {
+		for _, entry := range entries {
+			path := filepath.Join(sshDir, entry.Name())
+			if target, err := os.Readlink(path); err == nil && (target == orgID || target == orgID+"-cert.pub") {
+				files = append(files, path)
+			}
+		}
+	}
+
+	for _, file := range files {
+		if err := credstore.Wipe(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Config maps border0 resources to local listeners, for example:
+//
+//	resources:
+//	  - host: mydb-myorg.border0.io
+//	    port: 5432
+//	  - host: myssh-myorg.border0.io
+//	    socket: /tmp/myssh.sock
+type Config struct {
+	Resources []Resource `mapstructure:"resources"`
+}
+
+// Resource is a border0 host served on a local tcp port or unix socket
+type Resource struct {
+	Host   string `mapstructure:"host"`
+	Port   int    `mapstructure:"port"`
+	Socket string `mapstructure:"socket"`
+}
+
+// Network returns the network and address of the local listener
+func (r Resource) Network() (string, string) {
+	if r.Socket != "" {
+		return "unix", r.Socket
+	}
+	return "tcp", fmt.Sprintf("localhost:%d", r.Port)
+}
+
+func ParseConfig(path string) (Config, error) {
+	var cfg Config
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return cfg, fmt.Errorf("failed to read proxy config %s: %w", path, err)
+	}
+
+	if err := v.Unmarshal(&cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse proxy config %s: %w", path, err)
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if len(c.Resources) == 0 {
+		return errors.New("no resources configured")
+	}
+
+	listeners := map[string]bool{}
+	for i, r := range c.Resources {
+		if r.Host == "" {
+			return fmt.Errorf("resource %d: host is required", i+1)
+		}
+		if (r.Port == 0) == (r.Socket == "") {
+			return fmt.Errorf("resource %s: either port or socket is required", r.Host)
+		}
+		if r.Port < 0 || r.Port > 65535 {
+			return fmt.Errorf("resource %s: invalid port %d", r.Host, r.Port)
+		}
+
+		network, address := r.Network()
+		if listeners[network+address] {
+			return fmt.Errorf("resource %s: %s is used more than once", r.Host, address)
+		}
+		listeners[network+address] = true
+	}
+
+	return nil
+}
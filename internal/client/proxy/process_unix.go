@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package proxy
+
+import (
+	"syscall"
+)
+
+func processRunning(pid int) bool {
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
+
+func stopProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
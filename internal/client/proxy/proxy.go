@@ -0,0 +1,256 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/client"
+)
+
+const (
+	// renewBefore is how long before expiry the client certificate is renewed
+	renewBefore = 30 * time.Minute
+	// checkInterval is how often the certificate is checked and the status written
+	checkInterval = time.Minute
+)
+
+// Proxy serves the resources of a config on local listeners, connections are
+// passed on to the resources over TLS with the org's client certificate
+type Proxy struct {
+	cfg        Config
+	configPath string
+	started    time.Time
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	expiry      time.Time
+	listeners   []*listener
+}
+
+type listener struct {
+	resource Resource
+	net.Listener
+	dial func() (net.Conn, error)
+
+	active    int64
+	total     int64
+	lastError atomic.Value
+}
+
+func New(cfg Config, configPath string) *Proxy {
+	return &Proxy{cfg: cfg, configPath: configPath, started: time.Now()}
+}
+
+// Run starts the listeners and serves them until the context is done
+func (p *Proxy) Run(ctx context.Context) error {
+	if err := p.loadCertificate(); err != nil {
+		return err
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify:   true,
+		GetClientCertificate: p.clientCertificate,
+	}
+
+	for _, r := range p.cfg.Resources {
+		info, err := client.GetResourceInfo(r.Host)
+		if err != nil {
+			p.close()
+			return fmt.Errorf("failed to get resource info for %s: %w", r.Host, err)
+		}
+
+		addr := fmt.Sprintf("%s:%d", r.Host, info.Port)
+		dial := func() (net.Conn, error) { return tls.Dial("tcp", addr, tlsConfig) }
+		if info.ConnectorAuthenticationEnabled {
+			dial = func() (net.Conn, error) { return client.ConnectorAuthConnect(addr, tlsConfig) }
+		}
+
+		l, err := listen(r, dial)
+		if err != nil {
+			p.close()
+			return err
+		}
+
+		p.listeners = append(p.listeners, l)
+		go l.serve()
+	}
+
+	defer os.Remove(StatusFile())
+	defer p.close()
+
+	p.writeStatus()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.renewCertificate()
+			p.writeStatus()
+		}
+	}
+}
+
+func (p *Proxy) close() {
+	for _, l := range p.listeners {
+		l.Close()
+	}
+}
+
+func (p *Proxy) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.certificate, nil
+}
+
+// loadCertificate reads the org's client certificate, fetching a new one when
+// it's missing or expired
+func (p *Proxy) loadCertificate() error {
+	if _, _, valid := client.IsClientCertValid(); !valid {
+		if _, _, err := client.FetchCertAndReturnPaths(p.cfg.Resources[0].Host); err != nil {
+			return fmt.Errorf("failed to fetch client certificate: %w", err)
+		}
+	}
+
+	cert, key, _, _, err := client.ReadOrgCert(client.OrgIDFromToken())
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.certificate = &tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}
+	p.expiry = cert.NotAfter
+
+	return nil
+}
+
+// renewCertificate fetches a new client certificate when the current one is
+// about to expire, new connections use the new certificate
+func (p *Proxy) renewCertificate() {
+	p.mu.Lock()
+	expiry := p.expiry
+	p.mu.Unlock()
+
+	if time.Until(expiry) > renewBefore {
+		return
+	}
+
+	log.Printf("renewing client certificate, it expires at %s", expiry.Format(time.RFC3339))
+	if _, _, err := client.FetchCertAndReturnPaths(p.cfg.Resources[0].Host); err != nil {
+		log.Printf("failed to renew client certificate: %v", err)
+		return
+	}
+	if err := p.loadCertificate(); err != nil {
+		log.Printf("failed to load renewed client certificate: %v", err)
+	}
+}
+
+func (p *Proxy) writeStatus() {
+	p.mu.Lock()
+	status := Status{
+		PID:               os.Getpid(),
+		Config:            p.configPath,
+		StartedAt:         p.started,
+		CertificateExpiry: p.expiry,
+	}
+	p.mu.Unlock()
+
+	for _, l := range p.listeners {
+		status.Listeners = append(status.Listeners, l.status())
+	}
+
+	if err := WriteStatus(status); err != nil {
+		log.Printf("failed to write proxy status: %v", err)
+	}
+}
+
+func listen(r Resource, dial func() (net.Conn, error)) (*listener, error) {
+	network, address := r.Network()
+	if network == "unix" {
+		// a socket file left behind by a previous run
+		os.Remove(address)
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start local listener for %s on %s: %w", r.Host, address, err)
+	}
+
+	// only the user may connect, the resource is reached with their certificate
+	if network == "unix" {
+		if err := os.Chmod(address, 0600); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+
+	return &listener{resource: r, Listener: l, dial: dial}, nil
+}
+
+func (l *listener) serve() {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("%s: accept error: %v", l.resource.Host, err)
+			}
+			return
+		}
+
+		go l.handle(conn)
+	}
+}
+
+func (l *listener) handle(conn net.Conn) {
+	defer conn.Close()
+
+	upstream, err := l.dial()
+	if err != nil {
+		l.lastError.Store(err.Error())
+		log.Printf("%s: failed to connect: %v", l.resource.Host, err)
+		return
+	}
+	defer upstream.Close()
+
+	atomic.AddInt64(&l.active, 1)
+	atomic.AddInt64(&l.total, 1)
+	defer atomic.AddInt64(&l.active, -1)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(upstream, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func (l *listener) status() ListenerStatus {
+	_, address := l.resource.Network()
+	status := ListenerStatus{
+		Host:              l.resource.Host,
+		Listen:            address,
+		ActiveConnections: atomic.LoadInt64(&l.active),
+		TotalConnections:  atomic.LoadInt64(&l.total),
+	}
+	if lastError, ok := l.lastError.Load().(string); ok {
+		status.LastError = lastError
+	}
+
+	return status
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    Config
+		wantErr string
+	}{
+		{
+			name:   "ports and sockets",
+			config: "resources:\n  - host: db.border0.io\n    port: 5432\n  - host: ssh.border0.io\n    socket: /tmp/ssh.sock\n",
+			want: Config{Resources: []Resource{
+				{Host: "db.border0.io", Port: 5432},
+				{Host: "ssh.border0.io", Socket: "/tmp/ssh.sock"},
+			}},
+		},
+		{
+			name:    "no resources",
+			config:  "resources: []\n",
+			wantErr: "no resources configured",
+		},
+		{
+			name:    "port and socket",
+			config:  "resources:\n  - host: db.border0.io\n    port: 5432\n    socket: /tmp/db.sock\n",
+			wantErr: "resource db.border0.io: either port or socket is required",
+		},
+		{
+			name:    "duplicate port",
+			config:  "resources:\n  - host: db.border0.io\n    port: 5432\n  - host: other.border0.io\n    port: 5432\n",
+			wantErr: "resource other.border0.io: localhost:5432 is used more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "proxy.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.config), 0600))
+
+			cfg, err := ParseConfig(path)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	upstream, client := net.Pipe()
+	l, err := listen(Resource{Host: "echo.border0.io", Socket: filepath.Join(t.TempDir(), "echo.sock")}, func() (net.Conn, error) {
+		return client, nil
+	})
+	require.NoError(t, err)
+	defer l.Close()
+	go l.serve()
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(l.resource.Socket)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// echo everything back
+	go io.Copy(upstream, upstream)
+
+	conn, err := net.Dial("unix", l.resource.Socket)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+
+	status := l.status()
+	assert.Equal(t, "echo.border0.io", status.Host)
+	assert.Equal(t, int64(1), status.TotalConnections)
+}
+
+func TestDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	t.Setenv("BORDER0_PROFILE", "default")
+	assert.Equal(t, filepath.Join(home, ".border0", "proxy"), Dir())
+
+	t.Setenv("BORDER0_PROFILE", "staging")
+	assert.Equal(t, filepath.Join(home, ".border0", "profiles", "staging", "proxy"), Dir())
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/profile"
+)
+
+// Status is written by a running proxy for proxy status to report on
+type Status struct {
+	PID               int              `json:"pid"`
+	Config            string           `json:"config"`
+	StartedAt         time.Time        `json:"started_at"`
+	CertificateExpiry time.Time        `json:"certificate_expiry"`
+	Listeners         []ListenerStatus `json:"listeners"`
+}
+
+type ListenerStatus struct {
+	Host              string `json:"host"`
+	Listen            string `json:"listen"`
+	ActiveConnections int64  `json:"active_connections"`
+	TotalConnections  int64  `json:"total_connections"`
+	LastError         string `json:"last_error,omitempty"`
+}
+
+// Dir is where the proxy keeps its status and log files, every profile has a
+// proxy of its own
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return profile.Path(filepath.Join(home, ".border0"), "proxy")
+}
+
+func StatusFile() string {
+	return filepath.Join(Dir(), "status.json")
+}
+
+func LogFile() string {
+	return filepath.Join(Dir(), "proxy.log")
+}
+
+func WriteStatus(status Status) error {
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", Dir(), err)
+	}
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write and rename, so status never reads a partial file
+	tmp := StatusFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, StatusFile())
+}
+
+// ReadStatus returns the status of the running proxy, or nil when no proxy is
+// running
+func ReadStatus() (*Status, error) {
+	data, err := os.ReadFile(StatusFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy status: %w", err)
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse proxy status: %w", err)
+	}
+
+	if !processRunning(status.PID) {
+		// the proxy didn't get to clean up after itself
+		os.Remove(StatusFile())
+		return nil, nil
+	}
+
+	return &status, nil
+}
+
+// Stop stops the running proxy
+func Stop(status *Status) error {
+	return stopProcess(status.PID)
+}
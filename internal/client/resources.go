@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"os/user"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -17,7 +16,9 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/borderzero/border0-cli/internal/api"
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/credstore"
 	"github.com/borderzero/border0-cli/internal/enum"
+	"github.com/borderzero/border0-cli/internal/profile"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/fatih/color"
 	jwt "github.com/golang-jwt/jwt"
@@ -221,27 +222,9 @@ func saveToken(token string) error {
 
 	// Write to client token file
 	tokenFile := ClientTokenFile(currentUser.HomeDir)
-
-	// create dir if not exists
-	configPath := filepath.Dir(tokenFile)
-	if _, err = os.Stat(configPath); os.IsNotExist(err) {
-		if err = os.Mkdir(configPath, 0700); err != nil {
-			return fmt.Errorf("failed to create directory %s : %w", configPath, err)
-		}
-	}
-
-	f, err := os.Create(tokenFile)
-	if err != nil {
+	if err = credstore.New().Write(tokenFile, []byte(fmt.Sprintf("%s\n", token))); err != nil {
 		return fmt.Errorf("couldn't write token: %w", err)
 	}
-	defer f.Close()
-	if err = os.Chmod(tokenFile, 0600); err != nil {
-		return fmt.Errorf("couldn't change permission for token file: %w", err)
-	}
-
-	if _, err = f.WriteString(fmt.Sprintf("%s\n", token)); err != nil {
-		return fmt.Errorf("couldn't write token to file: %w", err)
-	}
 
 	return nil
 }
@@ -271,10 +254,10 @@ func GetClientToken(homeDir string) (string, error) {
 	}
 
 	tokenFile := ClientTokenFile(homeDir)
-	if _, err := os.Stat(tokenFile); os.IsNotExist(err) {
+	content, err := credstore.New().Read(tokenFile)
+	if errors.Is(err, credstore.ErrNotFound) {
 		return "", fmt.Errorf("please login first (no token found in " + tokenFile + ")")
 	}
-	content, err := os.ReadFile(tokenFile)
 	if err != nil {
 		return "", err
 	}
@@ -284,14 +267,12 @@ func GetClientToken(homeDir string) (string, error) {
 }
 
 func ClientTokenFile(homedir string) string {
-	tokenfile := ""
+	dir := fmt.Sprintf("%s/.border0", homedir)
 	if runtime.GOOS == "windows" {
-		tokenfile = fmt.Sprintf("%s/.border0/client_token", os.Getenv("APPDATA"))
-	} else {
-		tokenfile = fmt.Sprintf("%s/.border0/client_token", homedir)
+		dir = fmt.Sprintf("%s/.border0", os.Getenv("APPDATA"))
 	}
 
-	return tokenfile
+	return profile.Path(dir, "client_token")
 }
 
 func ValidateClientToken(token string) (email string, claims jwt.MapClaims, err error) {
@@ -517,7 +498,7 @@ func PickHost(inputHost string, socketTypes ...string) (models.ClientResource, e
 
 func PickResourceTypes(inputFilter string) (pickedTypes []string, err error) {
 	if inputFilter == "prompt" {
-		allTypes := []string{enum.HTTPSocket, enum.TLSSocket, enum.SSHSocket, enum.DatabaseSocket}
+		allTypes := []string{enum.HTTPSocket, enum.TLSSocket, enum.TCPSocket, enum.SSHSocket, enum.DatabaseSocket}
 		if err = survey.AskOne(&survey.MultiSelect{
 			Message: "what types of resources would you like to see:",
 			Options: allTypes,
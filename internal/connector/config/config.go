@@ -8,6 +8,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -24,15 +25,72 @@ type SocketConfig struct {
 	Name                           string
 	Type                           string
 	Description                    string
-	AllowedEmailAddresses          []string `mapstructure:"allowed_email_addresses"`
-	AllowedEmailDomains            []string `mapstructure:"allowed_email_domains"`
-	UpstreamUser                   string   `mapstructure:"upstream_user"`
-	UpstreamPassword               string   `mapstructure:"upstream_password"`
-	UpstreamType                   string   `mapstructure:"upstream_type"`
-	DatabaseCredentials            string   `mapstructure:"database_credentials"`
-	UpstreamHttpHostname           string   `mapstructure:"upstream_http_hostname"`
-	ConnectorAuthenticationEnabled bool     `mapstructure:"connector_authentication"`
-	Policies                       []string `mapstructure:"policies"`
+	AllowedEmailAddresses          []string      `mapstructure:"allowed_email_addresses"`
+	AllowedEmailDomains            []string      `mapstructure:"allowed_email_domains"`
+	UpstreamUser                   string        `mapstructure:"upstream_user"`
+	UpstreamPassword               string        `mapstructure:"upstream_password"`
+	UpstreamType                   string        `mapstructure:"upstream_type"`
+	DatabaseCredentials            string        `mapstructure:"database_credentials"`
+	UpstreamHttpHostname           string        `mapstructure:"upstream_http_hostname"`
+	UpstreamIdentityFile           string        `mapstructure:"upstream_identity_file"`
+	UpstreamPrivateKey             string        `mapstructure:"upstream_private_key"`
+	UpstreamKnownHosts             string        `mapstructure:"upstream_known_hosts"`
+	UpstreamCertFile               string        `mapstructure:"upstream_certificate_filename"`
+	UpstreamKeyFile                string        `mapstructure:"upstream_key_filename"`
+	UpstreamCaFile                 string        `mapstructure:"upstream_ca_filename"`
+	ConnectorAuthenticationEnabled bool          `mapstructure:"connector_authentication"`
+	Policies                       []string      `mapstructure:"policies"`
+	SshServer                      SshServer     `mapstructure:"sshserver"`
+	DatabaseProxy                  DatabaseProxy `mapstructure:"database_proxy"`
+}
+
+// DatabaseProxy makes the connector terminate the mysql or postgres protocol of a
+// database socket and log in to the upstream with the socket's upstream credentials,
+// which are then not sent to Border0. TLS to the upstream is used when enabled,
+// when upstream certificate files are configured or when the socket has the
+// upstream certificates of socket create
+type DatabaseProxy struct {
+	Enabled               bool
+	Database              string
+	TLS                   bool          `mapstructure:"tls"`
+	TLSServerName         string        `mapstructure:"tls_server_name"`
+	TLSInsecureSkipVerify bool          `mapstructure:"tls_insecure_skip_verify"`
+	Audit                 DatabaseAudit `mapstructure:"audit"`
+}
+
+// DatabaseAudit logs the statements run through a proxied database socket, to
+// the connector log, stdout or a file as json lines
+type DatabaseAudit struct {
+	Enabled        bool
+	Sink           string
+	File           string
+	RedactLiterals bool `mapstructure:"redact_literals"`
+}
+
+// SshServer configures the connector's built-in ssh server for an ssh socket,
+// with Upstream the sessions are proxied to the socket host using the upstream
+// credentials of the socket instead of running on the connector host. The
+// credentials can be aws:ssm:name or vault:path#key references
+type SshServer struct {
+	Enabled              bool
+	Upstream             bool
+	HostKeyDir           string              `mapstructure:"host_key_dir"`
+	UserMapping          map[string][]string `mapstructure:"user_mapping"`
+	AllowRoot            bool                `mapstructure:"allow_root"`
+	DisableSftp          bool                `mapstructure:"disable_sftp"`
+	SftpChroot           string              `mapstructure:"sftp_chroot"`
+	SftpReadOnly         bool                `mapstructure:"sftp_readonly"`
+	AllowLocalForward    []string            `mapstructure:"allow_local_forward"`
+	AllowRemoteForward   []string            `mapstructure:"allow_remote_forward"`
+	AllowAgentForwarding bool                `mapstructure:"allow_agent_forwarding"`
+	IdleTimeout          time.Duration       `mapstructure:"idle_timeout"`
+	MaxSessionDuration   time.Duration       `mapstructure:"max_session_duration"`
+	MaxSessions          int                 `mapstructure:"max_sessions_per_identity"`
+	SessionWarning       time.Duration       `mapstructure:"session_warning"`
+
+	// UpstreamInsecureIgnoreHostKey skips verifying the upstream host key,
+	// without it upstream_known_hosts is required
+	UpstreamInsecureIgnoreHostKey bool `mapstructure:"upstream_insecure_ignore_host_key"`
 }
 
 type Credentials struct {
@@ -54,12 +112,23 @@ func (c Credentials) GetUsername() string {
 	return ""
 }
 
+// SshLimits are the session limits of the ssh sockets of a plugin group whose
+// sessions run in the discovered workloads, e.g. with docker exec
+type SshLimits struct {
+	IdleTimeout        time.Duration `mapstructure:"idle_timeout"`
+	MaxSessionDuration time.Duration `mapstructure:"max_session_duration"`
+	MaxSessions        int           `mapstructure:"max_sessions_per_identity"`
+	SessionWarning     time.Duration `mapstructure:"session_warning"`
+}
+
 type ConnectorGroups struct {
 	Group                          string
 	AllowedEmailAddresses          []string `mapstructure:"allowed_email_addresses"`
 	AllowedEmailDomains            []string `mapstructure:"allowed_email_domains"`
 	ConnectorAuthenticationEnabled bool     `mapstructure:"connector_authentication"`
 	Policies                       []string `mapstructure:"policies"`
+
+	SshLimits SshLimits `mapstructure:"ssh_limits"`
 }
 
 type K8Plugin struct {
@@ -69,6 +138,8 @@ type K8Plugin struct {
 	AllowedEmailDomains            []string `mapstructure:"allowed_email_domains"`
 	ConnectorAuthenticationEnabled bool     `mapstructure:"connector_authentication"`
 	Policies                       []string `mapstructure:"policies"`
+
+	SshLimits SshLimits `mapstructure:"ssh_limits"`
 }
 
 type NetworkPlugin struct {
@@ -113,6 +184,23 @@ func (c *Config) Validate() error {
 		return ErrInvalidConnectorName
 	}
 
+	for _, sockets := range c.Sockets {
+		for name, socket := range sockets {
+			server := socket.SshServer
+			if server.Enabled && server.Upstream && socket.UpstreamKnownHosts == "" && !server.UpstreamInsecureIgnoreHostKey {
+				return fmt.Errorf("socket %s: sshserver.upstream requires upstream_known_hosts, or sshserver.upstream_insecure_ignore_host_key to skip verifying the upstream host key", name)
+			}
+			// forwards would be dialed from the connector host, not the upstream
+			if server.Enabled && server.Upstream && (len(server.AllowLocalForward) > 0 || len(server.AllowRemoteForward) > 0 || server.AllowAgentForwarding) {
+				return fmt.Errorf("socket %s: sshserver.upstream doesn't support port or agent forwarding", name)
+			}
+			// the audit log records the identity connector authentication provides
+			if socket.DatabaseProxy.Enabled && socket.DatabaseProxy.Audit.Enabled && !socket.ConnectorAuthenticationEnabled {
+				return fmt.Errorf("socket %s: database_proxy.audit requires connector_authentication", name)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -185,6 +273,10 @@ func (c *ConfigParser) LoadSSMInConfig(ssmAPI ssmiface.SSMAPI, cfg *Config) erro
 				v.UpstreamPassword = SetupSSMField(ssmAPI, v.UpstreamPassword)
 			}
 
+			if strings.HasPrefix(v.UpstreamPrivateKey, "aws:ssm:") {
+				v.UpstreamPrivateKey = SetupSSMField(ssmAPI, v.UpstreamPrivateKey)
+			}
+
 			if strings.HasPrefix(v.UpstreamType, "aws:ssm:") {
 				v.UpstreamType = SetupSSMField(ssmAPI, v.UpstreamType)
 			}
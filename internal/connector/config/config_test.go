@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -131,6 +132,57 @@ func TestConfig_Validate(t *testing.T) {
 			cfg:     &Config{Connector: Connector{Name: "my awesome/connector.lab.border0.com"}},
 			wantErr: ErrInvalidConnectorName,
 		},
+		{
+			name: "upstream_ssh_without_known_hosts",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets:   SocketParams{{"bastion": {SshServer: SshServer{Enabled: true, Upstream: true}}}},
+			},
+			wantErr: errors.New("socket bastion: sshserver.upstream requires upstream_known_hosts, or sshserver.upstream_insecure_ignore_host_key to skip verifying the upstream host key"),
+		},
+		{
+			name: "upstream_ssh_with_known_hosts",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets:   SocketParams{{"bastion": {UpstreamKnownHosts: "/etc/ssh/ssh_known_hosts", SshServer: SshServer{Enabled: true, Upstream: true}}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "upstream_ssh_insecure_ignore_host_key",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets:   SocketParams{{"bastion": {SshServer: SshServer{Enabled: true, Upstream: true, UpstreamInsecureIgnoreHostKey: true}}}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "upstream_ssh_with_forwarding",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets: SocketParams{{"bastion": {
+					UpstreamKnownHosts: "/etc/ssh/ssh_known_hosts",
+					SshServer:          SshServer{Enabled: true, Upstream: true, AllowLocalForward: []string{"10.0.0.0/8:5432"}},
+				}}},
+			},
+			wantErr: errors.New("socket bastion: sshserver.upstream doesn't support port or agent forwarding"),
+		},
+		{
+			name: "database_audit_without_connector_authentication",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets:   SocketParams{{"shop": {DatabaseProxy: DatabaseProxy{Enabled: true, Audit: DatabaseAudit{Enabled: true}}}}},
+			},
+			wantErr: errors.New("socket shop: database_proxy.audit requires connector_authentication"),
+		},
+		{
+			name: "database_audit_with_connector_authentication",
+			cfg: &Config{
+				Connector: Connector{Name: "my-awesome-connector"},
+				Sockets:   SocketParams{{"shop": {ConnectorAuthenticationEnabled: true, DatabaseProxy: DatabaseProxy{Enabled: true, Audit: DatabaseAudit{Enabled: true}}}}},
+			},
+			wantErr: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
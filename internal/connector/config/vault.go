@@ -0,0 +1,94 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const vaultPrefix = "vault:"
+
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// LoadVaultInConfig replaces vault:path#key references in the upstream
+// credentials of the sockets with the key of the Vault secret at path, both
+// kv version 1 and 2 secrets are supported. The Vault server and token are
+// read from VAULT_ADDR and VAULT_TOKEN, and VAULT_NAMESPACE when it's set
+func (c *ConfigParser) LoadVaultInConfig(cfg *Config) error {
+	for _, socketMap := range cfg.Sockets {
+		for k, v := range socketMap {
+			for _, field := range []*string{&v.UpstreamUser, &v.UpstreamPassword, &v.UpstreamPrivateKey} {
+				if !strings.HasPrefix(*field, vaultPrefix) {
+					continue
+				}
+
+				secret, err := fetchFromVault(*field)
+				if err != nil {
+					return fmt.Errorf("socket %s: %w", k, err)
+				}
+				*field = secret
+			}
+
+			socketMap[k] = v
+		}
+	}
+
+	return nil
+}
+
+func fetchFromVault(ref string) (string, error) {
+	path, key, ok := strings.Cut(strings.TrimPrefix(ref, vaultPrefix), "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault reference %q, use vault:path#key", ref)
+	}
+
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN are required for vault references")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch vault secret %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch vault secret %s: %s", path, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode vault secret %s: %w", path, err)
+	}
+
+	data := secret.Data
+	// kv version 2 nests the secret with its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %s", path, key)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigParser_LoadVaultInConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/kv/legacy":
+			w.Write([]byte(`{"data": {"password": "kv1-secret"}}`))
+		case "/v1/secret/data/legacy":
+			w.Write([]byte(`{"data": {"data": {"user": "deploy", "private_key": "PEM"}, "metadata": {"version": 3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		token   string
+		socket  SocketConfig
+		want    SocketConfig
+		wantErr string
+	}{
+		{
+			name:   "kv_version_1",
+			token:  "s.token",
+			socket: SocketConfig{UpstreamPassword: "vault:kv/legacy#password"},
+			want:   SocketConfig{UpstreamPassword: "kv1-secret"},
+		},
+		{
+			name:   "kv_version_2",
+			token:  "s.token",
+			socket: SocketConfig{UpstreamUser: "vault:secret/data/legacy#user", UpstreamPrivateKey: "vault:secret/data/legacy#private_key"},
+			want:   SocketConfig{UpstreamUser: "deploy", UpstreamPrivateKey: "PEM"},
+		},
+		{
+			name:   "no_references",
+			socket: SocketConfig{UpstreamUser: "deploy", UpstreamPassword: "aws:ssm:/legacy/password"},
+			want:   SocketConfig{UpstreamUser: "deploy", UpstreamPassword: "aws:ssm:/legacy/password"},
+		},
+		{
+			name:    "missing_key",
+			token:   "s.token",
+			socket:  SocketConfig{UpstreamPassword: "vault:kv/legacy#passwd"},
+			wantErr: "socket legacy: vault secret kv/legacy has no key passwd",
+		},
+		{
+			name:    "invalid_reference",
+			token:   "s.token",
+			socket:  SocketConfig{UpstreamPassword: "vault:kv/legacy"},
+			wantErr: `socket legacy: invalid vault reference "vault:kv/legacy", use vault:path#key`,
+		},
+		{
+			name:    "forbidden",
+			token:   "s.other",
+			socket:  SocketConfig{UpstreamPassword: "vault:kv/legacy#password"},
+			wantErr: "socket legacy: failed to fetch vault secret kv/legacy: 403 Forbidden",
+		},
+		{
+			name:    "no_token",
+			socket:  SocketConfig{UpstreamPassword: "vault:kv/legacy#password"},
+			wantErr: "socket legacy: VAULT_ADDR and VAULT_TOKEN are required for vault references",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VAULT_ADDR", server.URL)
+			t.Setenv("VAULT_TOKEN", tt.token)
+
+			cfg := &Config{Sockets: SocketParams{{"legacy": tt.socket}}}
+			err := NewConfigParser().LoadVaultInConfig(cfg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Sockets[0]["legacy"])
+		})
+	}
+}
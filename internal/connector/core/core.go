@@ -109,9 +109,17 @@ func (c *ConnectorCore) TunnelConnnect(ctx context.Context, socket models.Socket
 		}
 	}
 
-	err = session.Connect(ctx, *userID, socket.SocketID, "", socket.ConnectorData.Port, socket.ConnectorData.TargetHostname, "", "", "", false, false, org.Certificates["ssh_public_key"], c.border0API.GetAccessToken(), "", socket.ConnectorAuthenticationEnabled, caCertPool)
+	serverConfig := c.sshServerConfig(ctx, socket)
+	dbProxy, err := c.databaseProxy(ctx, socket)
 	if err != nil {
-		c.connectedTunnels.Delete(socket.SocketID)
+		// never fall back to passing the connection on to the database unproxied
+		c.connectedTunnels.DeleteValue(socket.SocketID, session)
+		return fmt.Errorf("not connecting socket %s: %w", socket.Name, err)
+	}
+
+	err = session.Connect(ctx, *userID, socket.SocketID, "", socket.ConnectorData.Port, socket.ConnectorData.TargetHostname, "", "", "", serverConfig != nil, false, org.Certificates["ssh_public_key"], c.border0API.GetAccessToken(), "", socket.ConnectorAuthenticationEnabled, caCertPool, serverConfig, dbProxy)
+	if err != nil {
+		c.connectedTunnels.DeleteValue(socket.SocketID, session)
 		return err
 	}
 
@@ -162,6 +170,29 @@ func (c *ConnectorCore) TunnelConnectJob(ctx context.Context, group *errgroup.Gr
 						session.(*ssh.Connection).Close()
 					}
 				}
+
+				if tunnelConnectData.action == "retarget" {
+					session, ok := c.connectedTunnels.Get(tunnelConnectData.key)
+					if !ok {
+						continue
+					}
+
+					socket := tunnelConnectData.socket
+					if session.(*ssh.Connection).Retarget(socket.ConnectorData.TargetHostname, socket.ConnectorData.Port) {
+						continue
+					}
+
+					// the ssh server and database proxy get their upstream when connecting
+					session.(*ssh.Connection).Close()
+					group.Go(func() error {
+						err := c.TunnelConnnect(ctx, socket)
+						if err != nil {
+							c.logger.Error("error reconnecting to tunnel", zap.String("error", err.Error()))
+						}
+
+						return nil
+					})
+				}
 			}
 		}
 	})
@@ -234,6 +265,19 @@ func (c *ConnectorCore) SocketsCoreHandler(ctx context.Context, socketsToUpdate
 		socketsFromApi[i] = socket
 	}
 
+	// the type of an untyped socket is inferred from its port, an existing
+	// socket keeps its type, so inferring types for more ports doesn't
+	// recreate the sockets connectors created before
+	for i, socket := range discoveredSockets {
+		apiSocket, ok := socketApiMap[socket.ConnectorData.Key()]
+		if !ok || socket.ConnectorData.Type != "" {
+			continue
+		}
+		socket.SocketType, socket.UpstreamType = apiSocket.SocketType, apiSocket.UpstreamType
+		localSocketsMap[socket.ConnectorData.Key()] = socket
+		discoveredSockets[i] = socket
+	}
+
 	logger.Info("sockets found",
 		zap.Int("local connector sockets", len(discoveredSockets)),
 		zap.Int("api sockets", len(socketsFromApi)),
@@ -253,44 +297,153 @@ func (c *ConnectorCore) SocketsCoreHandler(ctx context.Context, socketsToUpdate
 	return socketsToConnect, nil
 }
 
-func (c *ConnectorCore) CheckAndUpdateSocket(ctx context.Context, apiSocket, localSocket models.Socket) (*models.Socket, error) {
-	check := stringSlicesEqual(apiSocket.AllowedEmailAddresses, localSocket.AllowedEmailAddresses) &&
-		stringSlicesEqual(localSocket.AllowedEmailAddresses, apiSocket.AllowedEmailAddresses) &&
-		stringSlicesEqual(apiSocket.AllowedEmailDomains, localSocket.AllowedEmailDomains) &&
-		stringSlicesEqual(localSocket.AllowedEmailDomains, apiSocket.AllowedEmailDomains)
+// socketChanges are the fields of an api socket that differ from the socket
+// found by the discovery
+type socketChanges struct {
+	// fields can be updated in place
+	fields []string
+	// immutable fields can only be changed by recreating the socket
+	immutable []string
+	// retarget is set when the tunnel has to forward to another host or port
+	retarget bool
+}
 
-	if len(apiSocket.PolicyNames) > 0 || len(localSocket.PolicyNames) > 0 {
-		check = check && stringSlicesEqual(apiSocket.PolicyNames, localSocket.PolicyNames) &&
-			stringSlicesEqual(localSocket.PolicyNames, apiSocket.PolicyNames)
-	}
-
-	if !check || apiSocket.UpstreamHttpHostname != localSocket.UpstreamHttpHostname ||
-		apiSocket.UpstreamUsername != localSocket.UpstreamUsername ||
-		apiSocket.UpstreamType != localSocket.UpstreamType ||
-		apiSocket.ConnectorAuthenticationEnabled != localSocket.ConnectorAuthenticationEnabled {
-
-		apiSocket.AllowedEmailAddresses = localSocket.AllowedEmailAddresses
-		apiSocket.AllowedEmailDomains = localSocket.AllowedEmailDomains
-		apiSocket.UpstreamHttpHostname = localSocket.UpstreamHttpHostname
-		apiSocket.UpstreamUsername = localSocket.UpstreamUsername
-		apiSocket.ConnectorAuthenticationEnabled = localSocket.ConnectorAuthenticationEnabled
-		apiSocket.UpstreamType = ""
-		apiSocket.CloudAuthEnabled = true
-		apiSocket.Tags = localSocket.Tags
-
-		_, err := NewPolicyManager(c.logger, c.border0API).ApplyPolicies(ctx, apiSocket, localSocket.PolicyNames)
-		if err != nil {
-			c.logger.Error(err.Error(), zap.String("socket_name", apiSocket.Name))
+// diffSocket compares the fields the connector manages. Optional fields the
+// discovery doesn't set, like the description, are left as they are in the api,
+// and secrets are only compared when the api returns them
+func diffSocket(apiSocket, localSocket models.Socket) socketChanges {
+	var changes socketChanges
+	field := func(name string, changed bool) {
+		if changed {
+			changes.fields = append(changes.fields, name)
+		}
+	}
+	immutable := func(name string, changed bool) {
+		if changed {
+			changes.immutable = append(changes.immutable, name)
 		}
+	}
 
-		apiSocket.PolicyNames = localSocket.PolicyNames
+	apiData, localData := apiSocket.ConnectorData, localSocket.ConnectorData
+	if apiData == nil {
+		apiData = &models.ConnectorData{}
+	}
+	if localData == nil {
+		localData = &models.ConnectorData{}
+	}
+
+	immutable("socket_type", apiSocket.SocketType != localSocket.SocketType)
+	immutable("type", apiData.Type != localData.Type)
+	// the upstream type of a database socket is its protocol, an http socket
+	// can switch between http and https upstreams
+	immutable("upstream_type", apiSocket.SocketType == "database" && apiSocket.UpstreamType != "" && apiSocket.UpstreamType != localSocket.UpstreamType)
+	field("upstream_type", isHTTPSocket(apiSocket) && localSocket.UpstreamType != "" && apiSocket.UpstreamType != localSocket.UpstreamType)
 
-		err = c.border0API.UpdateSocket(ctx, apiSocket.SocketID, apiSocket)
-		if err != nil {
-			return nil, err
+	field("allowed_email_addresses", !stringSlicesEqual(apiSocket.AllowedEmailAddresses, localSocket.AllowedEmailAddresses))
+	field("allowed_email_domains", !stringSlicesEqual(apiSocket.AllowedEmailDomains, localSocket.AllowedEmailDomains))
+	if len(apiSocket.PolicyNames) > 0 || len(localSocket.PolicyNames) > 0 {
+		field("policies", !stringSlicesEqual(apiSocket.PolicyNames, localSocket.PolicyNames))
+	}
+	field("upstream_http_hostname", apiSocket.UpstreamHttpHostname != localSocket.UpstreamHttpHostname)
+	field("upstream_username", apiSocket.UpstreamUsername != localSocket.UpstreamUsername)
+	field("upstream_password", apiSocket.UpstreamPassword != "" && localSocket.UpstreamPassword != "" && apiSocket.UpstreamPassword != localSocket.UpstreamPassword)
+	field("connector_authentication_enabled", apiSocket.ConnectorAuthenticationEnabled != localSocket.ConnectorAuthenticationEnabled)
+	field("description", localSocket.Description != "" && apiSocket.Description != localSocket.Description)
+	field("org_custom_domain", localSocket.OrgCustomDomain != "" && apiSocket.OrgCustomDomain != localSocket.OrgCustomDomain &&
+		!StringInSlice(localSocket.OrgCustomDomain, apiSocket.CustomDomains))
+	field("upstream_cert", localSocket.UpstreamCert != nil && (apiSocket.UpstreamCert == nil || *apiSocket.UpstreamCert != *localSocket.UpstreamCert))
+	field("upstream_key", localSocket.UpstreamKey != nil && apiSocket.UpstreamKey != nil && *apiSocket.UpstreamKey != *localSocket.UpstreamKey)
+	field("upstream_ca", localSocket.UpstreamCa != nil && (apiSocket.UpstreamCa == nil || *apiSocket.UpstreamCa != *localSocket.UpstreamCa))
+
+	tagsChanged := false
+	for k, v := range localSocket.Tags {
+		if apiSocket.Tags[k] != v {
+			tagsChanged = true
 		}
+	}
+	field("tags", tagsChanged)
+
+	changes.retarget = apiData.TargetHostname != localData.TargetHostname || apiData.Port != localData.Port
+
+	return changes
+}
 
-		c.logger.Info("socket updated from local to api", zap.String("socket_name", apiSocket.Name))
+func isHTTPSocket(socket models.Socket) bool {
+	return socket.SocketType == "http" || socket.SocketType == "https"
+}
+
+// CheckAndUpdateSocket updates the fields of the api socket that differ from
+// the local socket, and retargets its tunnel when the target host or port changed
+func (c *ConnectorCore) CheckAndUpdateSocket(ctx context.Context, apiSocket, localSocket models.Socket) (*models.Socket, error) {
+	changes := diffSocket(apiSocket, localSocket)
+	if len(changes.fields) == 0 {
+		return &apiSocket, nil
+	}
+
+	apiSocket.AllowedEmailAddresses = localSocket.AllowedEmailAddresses
+	apiSocket.AllowedEmailDomains = localSocket.AllowedEmailDomains
+	apiSocket.UpstreamHttpHostname = localSocket.UpstreamHttpHostname
+	apiSocket.UpstreamUsername = localSocket.UpstreamUsername
+	apiSocket.ConnectorAuthenticationEnabled = localSocket.ConnectorAuthenticationEnabled
+	apiSocket.CloudAuthEnabled = true
+
+	if localSocket.UpstreamType != "" {
+		apiSocket.UpstreamType = localSocket.UpstreamType
+	}
+	if localSocket.UpstreamPassword != "" {
+		apiSocket.UpstreamPassword = localSocket.UpstreamPassword
+	}
+	if localSocket.Description != "" {
+		apiSocket.Description = localSocket.Description
+	}
+	if localSocket.OrgCustomDomain != "" {
+		apiSocket.OrgCustomDomain = localSocket.OrgCustomDomain
+	}
+	if localSocket.UpstreamCert != nil {
+		apiSocket.UpstreamCert = localSocket.UpstreamCert
+	}
+	if localSocket.UpstreamKey != nil {
+		apiSocket.UpstreamKey = localSocket.UpstreamKey
+	}
+	if localSocket.UpstreamCa != nil {
+		apiSocket.UpstreamCa = localSocket.UpstreamCa
+	}
+
+	// keep tags that weren't set by the connector
+	tags := make(map[string]string, len(apiSocket.Tags)+len(localSocket.Tags))
+	for k, v := range apiSocket.Tags {
+		tags[k] = v
+	}
+	for k, v := range localSocket.Tags {
+		tags[k] = v
+	}
+	apiSocket.Tags = tags
+
+	_, err := NewPolicyManager(c.logger, c.border0API).ApplyPolicies(ctx, apiSocket, localSocket.PolicyNames)
+	if err != nil {
+		c.logger.Error(err.Error(), zap.String("socket_name", apiSocket.Name))
+	}
+
+	apiSocket.PolicyNames = localSocket.PolicyNames
+
+	err = c.border0API.UpdateSocket(ctx, apiSocket.SocketID, apiSocket)
+	if err != nil {
+		return nil, err
+	}
+	apiSocket.BuildConnectorDataByTags()
+
+	c.logger.Info("socket updated from local to api", zap.String("socket_name", apiSocket.Name), zap.Strings("fields", changes.fields))
+
+	if changes.retarget && c.IsSocketConnected(apiSocket.SocketID) {
+		c.logger.Info("socket target changed, retargeting the tunnel",
+			zap.String("socket_name", apiSocket.Name),
+			zap.String("target_hostname", apiSocket.ConnectorData.TargetHostname),
+			zap.Int("target_port", apiSocket.ConnectorData.Port))
+
+		c.connectChan <- connectTunnelData{
+			key:    apiSocket.SocketID,
+			socket: apiSocket,
+			action: "retarget"}
 	}
 
 	return &apiSocket, nil
@@ -318,22 +471,7 @@ func (c *ConnectorCore) CheckSocketsToDelete(ctx context.Context, socketsFromApi
 			continue
 		}
 
-		if s, ok := localSocketsMap[apiSocket.ConnectorData.Key()]; ok {
-			// check if socket needs to be recreated
-			if *s.ConnectorData != *apiSocket.ConnectorData {
-				c.logger.Info("socket data is different, so we are recreating the socket",
-					zap.String("plugin_name", c.discovery.Name()),
-					zap.Any("local connector data", apiSocket.ConnectorData),
-					zap.Any("connector data", s.ConnectorData),
-				)
-
-				createdSocket, err := c.RecreateSocket(ctx, apiSocket.SocketID, s)
-				if err != nil {
-					return err
-				}
-				localSocketsMap[apiSocket.ConnectorData.Key()] = *createdSocket
-			}
-		} else if apiSocket.ConnectorData.Connector == c.cfg.Connector.Name && apiSocket.ConnectorData.PluginName == c.discovery.Name() {
+		if _, ok := localSocketsMap[apiSocket.ConnectorData.Key()]; !ok && apiSocket.ConnectorData.Connector == c.cfg.Connector.Name && apiSocket.ConnectorData.PluginName == c.discovery.Name() {
 			c.logger.Info("socket does not exists locally, deleting the socket ",
 				zap.String("plugin_name", c.discovery.Name()),
 				zap.String("name", apiSocket.Name),
@@ -341,7 +479,7 @@ func (c *ConnectorCore) CheckSocketsToDelete(ctx context.Context, socketsFromApi
 
 			// close tunnel connection before deleting the socket
 			c.connectChan <- connectTunnelData{
-				key:    apiSocket.ConnectorData.Key(),
+				key:    apiSocket.SocketID,
 				socket: apiSocket,
 				action: "disconnect"}
 
@@ -370,6 +508,26 @@ func (c *ConnectorCore) CheckSocketsToCreate(ctx context.Context, localSockets [
 			createdSocket.PluginName = c.discovery.Name()
 			createdSocket.BuildConnectorData(c.cfg.Connector.Name, c.metadata.Principal)
 
+			socketsToConnect = append(socketsToConnect, *createdSocket)
+		} else if changes := diffSocket(apiSocket, localSocket); len(changes.immutable) > 0 {
+			c.logger.Info("immutable socket fields are different, so we are recreating the socket",
+				zap.String("plugin_name", c.discovery.Name()),
+				zap.String("socket_name", apiSocket.Name),
+				zap.Strings("fields", changes.immutable),
+			)
+
+			if c.IsSocketConnected(apiSocket.SocketID) {
+				c.connectChan <- connectTunnelData{
+					key:    apiSocket.SocketID,
+					socket: apiSocket,
+					action: "disconnect"}
+			}
+
+			createdSocket, err := c.RecreateSocket(ctx, apiSocket.SocketID, localSocket)
+			if err != nil {
+				return nil, err
+			}
+
 			socketsToConnect = append(socketsToConnect, *createdSocket)
 		} else {
 			updatedSocket, err := c.CheckAndUpdateSocket(ctx, apiSocket, localSocket)
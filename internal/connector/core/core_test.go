@@ -74,6 +74,145 @@ func TestConnectorCore_SocketsCoreHandler(t *testing.T) {
 	}
 }
 
+func TestConnectorCore_SocketsCoreHandler_KeepsInferredType(t *testing.T) {
+	cfg := validConfig()
+	cfg.Sockets = config.SocketParams{{"cache": {Host: "10.0.0.9", Port: 6379}}}
+	plugin := &discover.StaticSocketFinder{}
+
+	// created when untyped sockets on the redis port weren't database sockets yet
+	existing := models.Socket{Name: "cache", TargetHostname: "10.0.0.9", TargetPort: 6379, PluginName: plugin.Name()}
+	existing.BuildConnectorDataAndTags(cfg.Connector.Name, "")
+	existing.SocketID = "socket-id"
+	existing.SocketType = "http"
+	existing.UpstreamType = "http"
+
+	apiMock := &mocks.API{}
+	apiMock.EXPECT().GetSockets(mock.Anything).Return([]models.Socket{existing}, nil)
+
+	c := NewConnectorCore(zap.NewNop(), cfg, plugin, apiMock, Metadata{})
+	sockets, err := c.discovery.Find(context.Background(), cfg, discover.DiscoverState{})
+	assert.NoError(t, err)
+
+	got, err := c.SocketsCoreHandler(context.Background(), sockets)
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "socket-id", got[0].SocketID)
+		assert.Equal(t, "http", got[0].SocketType)
+	}
+	apiMock.AssertNotCalled(t, "CreateSocket", mock.Anything, mock.Anything)
+	apiMock.AssertNotCalled(t, "DeleteSocket", mock.Anything, mock.Anything)
+}
+
+func TestDiffSocket(t *testing.T) {
+	local := models.Socket{
+		Name:                  "db",
+		SocketType:            "database",
+		UpstreamType:          "mysql",
+		UpstreamUsername:      "admin",
+		UpstreamPassword:      "secret",
+		TargetHostname:        "10.0.0.1",
+		TargetPort:            3306,
+		AllowedEmailDomains:   []string{"border0.com"},
+		AllowedEmailAddresses: []string{"alice@border0.com"},
+	}
+	local.BuildConnectorDataAndTags("connector", "")
+
+	tests := []struct {
+		name          string
+		change        func(api *models.Socket)
+		wantFields    []string
+		wantImmutable []string
+		wantRetarget  bool
+	}{
+		{name: "same", change: func(api *models.Socket) {}},
+		{name: "user tags are kept", change: func(api *models.Socket) { api.Tags["team"] = "data" }},
+		{
+			name: "target hostname",
+			change: func(api *models.Socket) {
+				api.Tags["target_hostname"] = "10.0.0.2"
+				api.BuildConnectorDataByTags()
+			},
+			wantFields:   []string{"tags"},
+			wantRetarget: true,
+		},
+		{
+			name: "credentials and access",
+			change: func(api *models.Socket) {
+				api.UpstreamUsername = "root"
+				api.AllowedEmailDomains = []string{"example.com"}
+			},
+			wantFields: []string{"allowed_email_domains", "upstream_username"},
+		},
+		{name: "description set by hand", change: func(api *models.Socket) { api.Description = "production" }},
+		{name: "password not returned by the api", change: func(api *models.Socket) { api.UpstreamPassword = "" }},
+		{
+			name: "database protocol",
+			change: func(api *models.Socket) {
+				api.UpstreamType = "postgres"
+			},
+			wantImmutable: []string{"upstream_type"},
+		},
+		{
+			name: "socket type",
+			change: func(api *models.Socket) {
+				api.SocketType = "ssh"
+				api.Tags["type"] = "ssh"
+				api.BuildConnectorDataByTags()
+			},
+			wantFields:    []string{"tags"},
+			wantImmutable: []string{"socket_type", "type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := local
+			api.SocketID = "socket-id"
+			api.AllowedEmailDomains = append([]string{}, local.AllowedEmailDomains...)
+			api.Tags = map[string]string{}
+			for k, v := range local.Tags {
+				api.Tags[k] = v
+			}
+			api.BuildConnectorDataByTags()
+			tt.change(&api)
+
+			changes := diffSocket(api, local)
+			assert.Equal(t, tt.wantFields, changes.fields)
+			assert.Equal(t, tt.wantImmutable, changes.immutable)
+			assert.Equal(t, tt.wantRetarget, changes.retarget)
+		})
+	}
+}
+
+func TestConnectorCore_CheckAndUpdateSocket(t *testing.T) {
+	local := models.Socket{Name: "web", SocketType: "http", UpstreamType: "https", TargetHostname: "10.0.0.2", TargetPort: 443, Description: "web server"}
+	local.BuildConnectorDataAndTags("connector", "")
+
+	api := models.Socket{SocketID: "socket-id", Name: "web", SocketType: "http", UpstreamType: "http", Description: "created by connector", Tags: map[string]string{"team": "web"}}
+	for k, v := range local.Tags {
+		api.Tags[k] = v
+	}
+	api.Tags["target_hostname"] = "10.0.0.1"
+	api.BuildConnectorDataByTags()
+
+	apiMock := &mocks.API{}
+	apiMock.EXPECT().UpdateSocket(mock.Anything, "socket-id", mock.MatchedBy(func(s models.Socket) bool {
+		return s.Description == "web server" && s.UpstreamType == "https" && s.Tags["target_hostname"] == "10.0.0.2" && s.Tags["team"] == "web"
+	})).Return(nil)
+
+	// http sockets switch between http and https upstreams in place
+	changes := diffSocket(api, local)
+	assert.Contains(t, changes.fields, "upstream_type")
+	assert.Empty(t, changes.immutable)
+
+	c := NewConnectorCore(zap.NewNop(), validConfig(), &discover.StaticSocketFinder{}, apiMock, Metadata{})
+	updated, err := c.CheckAndUpdateSocket(context.Background(), api, local)
+	assert.NoError(t, err)
+	assert.Equal(t, "socket-id", updated.SocketID)
+	assert.Equal(t, "10.0.0.2", updated.ConnectorData.TargetHostname)
+	apiMock.AssertNotCalled(t, "DeleteSocket", mock.Anything, mock.Anything)
+	apiMock.AssertExpectations(t)
+}
+
 func validConfig() config.Config {
 	validConfig := config.Config{
 		Credentials: config.Credentials{Username: "", Password: "AVeryLongAndSecurePassword", Token: ""},
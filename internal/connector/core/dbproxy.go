@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/connector/discover"
+	"github.com/borderzero/border0-cli/internal/dbproxy"
+	"go.uber.org/zap"
+)
+
+// databaseProxy returns the proxy for the socket's upstream database, or nil
+// when the database protocol of the socket is not terminated by the connector.
+// An error means the socket should be proxied but the proxy can't be set up
+func (c *ConnectorCore) databaseProxy(ctx context.Context, socket models.Socket) (*dbproxy.Proxy, error) {
+	if socket.SocketType != "database" || socket.ConnectorData == nil {
+		return nil, nil
+	}
+
+	proxyConfig, err := c.databaseProxyConfig(ctx, socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database proxy config: %w", err)
+	}
+	if proxyConfig == nil {
+		return nil, nil
+	}
+
+	proxyConfig.Name = socket.Name
+
+	proxy, err := dbproxy.NewProxy(c.logger.With(zap.String("socket", socket.Name)), *proxyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database proxy: %w", err)
+	}
+
+	return proxy, nil
+}
+
+func (c *ConnectorCore) databaseProxyConfig(ctx context.Context, socket models.Socket) (*dbproxy.Config, error) {
+	proxyConfig, err := c.discoveredDatabaseProxyConfig(ctx, socket)
+	if err != nil || proxyConfig == nil {
+		return proxyConfig, err
+	}
+
+	if err := useSocketCertificates(proxyConfig, socket); err != nil {
+		return nil, err
+	}
+
+	return proxyConfig, nil
+}
+
+// useSocketCertificates uses the upstream certificates of the socket, as set with
+// socket create --upstream_certificate_filename and friends, for TLS to the
+// upstream when the connector config doesn't configure certificate files
+func useSocketCertificates(proxyConfig *dbproxy.Config, socket models.Socket) error {
+	if socket.UpstreamCert == nil && socket.UpstreamKey == nil && socket.UpstreamCa == nil {
+		return nil
+	}
+	if proxyConfig.TLS != nil && (len(proxyConfig.TLS.Certificates) > 0 || proxyConfig.TLS.RootCAs != nil) {
+		return nil
+	}
+
+	var serverName string
+	var insecureSkipVerify bool
+	if proxyConfig.TLS != nil {
+		serverName, insecureSkipVerify = proxyConfig.TLS.ServerName, proxyConfig.TLS.InsecureSkipVerify
+	}
+
+	tlsConfig, err := dbproxy.NewTLSConfigFromPEM(pemBytes(socket.UpstreamCert), pemBytes(socket.UpstreamKey), pemBytes(socket.UpstreamCa), serverName, insecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("invalid upstream certificates of socket %s: %w", socket.Name, err)
+	}
+	proxyConfig.TLS = tlsConfig
+
+	return nil
+}
+
+func pemBytes(s *string) []byte {
+	if s == nil {
+		return nil
+	}
+	return []byte(*s)
+}
+
+func (c *ConnectorCore) discoveredDatabaseProxyConfig(ctx context.Context, socket models.Socket) (*dbproxy.Config, error) {
+	if databaseDiscover, ok := c.discovery.(discover.DatabaseDiscover); ok {
+		return databaseDiscover.DatabaseProxy(ctx, c.cfg, socket)
+	}
+
+	socketConfig, ok := c.staticSocketConfig(socket)
+	if !ok || !socketConfig.DatabaseProxy.Enabled {
+		return nil, nil
+	}
+
+	return c.newDatabaseProxyConfig(socketConfig)
+}
+
+func (c *ConnectorCore) newDatabaseProxyConfig(socketConfig config.SocketConfig) (*dbproxy.Config, error) {
+	cfg := socketConfig.DatabaseProxy
+
+	configSocket := models.Socket{SocketType: socketConfig.Type, UpstreamType: socketConfig.UpstreamType, TargetPort: socketConfig.Port}
+	configSocket.SetupTypeAndUpstreamTypeByPortOrTags()
+
+	proxyConfig := &dbproxy.Config{
+		Type:     configSocket.UpstreamType,
+		Host:     socketConfig.Host,
+		Port:     socketConfig.Port,
+		Username: socketConfig.UpstreamUser,
+		Password: socketConfig.UpstreamPassword,
+		Database: cfg.Database,
+	}
+
+	if cfg.TLS || socketConfig.UpstreamCertFile != "" || socketConfig.UpstreamCaFile != "" {
+		tlsConfig, err := dbproxy.NewTLSConfig(socketConfig.UpstreamCertFile, socketConfig.UpstreamKeyFile, socketConfig.UpstreamCaFile, cfg.TLSServerName, cfg.TLSInsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		proxyConfig.TLS = tlsConfig
+	}
+
+	if cfg.Audit.Enabled {
+		sink, err := dbproxy.NewAuditSink(c.logger, cfg.Audit.Sink, cfg.Audit.File)
+		if err != nil {
+			return nil, err
+		}
+		proxyConfig.Audit = sink
+		proxyConfig.RedactLiterals = cfg.Audit.RedactLiterals
+	}
+
+	return proxyConfig, nil
+}
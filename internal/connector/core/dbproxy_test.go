@@ -0,0 +1,166 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/connector/discover"
+	"github.com/borderzero/border0-cli/internal/dbproxy"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestConnectorCore_databaseProxyConfig(t *testing.T) {
+	cfg := validConfig()
+	cfg.Sockets = append(cfg.Sockets, map[string]config.SocketConfig{
+		"orders.db": {
+			Host:             "10.0.0.7",
+			Port:             5432,
+			Type:             "database",
+			UpstreamUser:     "orders",
+			UpstreamPassword: "secret",
+			DatabaseProxy:    config.DatabaseProxy{Enabled: true, Database: "orders"},
+		},
+		"legacy.db": {
+			Host:             "10.0.0.8",
+			Port:             3306,
+			Type:             "database",
+			UpstreamUser:     "root",
+			UpstreamPassword: "secret",
+		},
+	})
+
+	staticSocketPlugin := &discover.StaticSocketFinder{}
+
+	tests := []struct {
+		name   string
+		socket models.Socket
+		want   *dbproxy.Config
+	}{
+		{
+			name: "proxied_database",
+			socket: models.Socket{
+				SocketType:    "database",
+				ConnectorData: &models.ConnectorData{Name: "orders-db", PluginName: staticSocketPlugin.Name()},
+			},
+			want: &dbproxy.Config{
+				Type:     "postgres",
+				Host:     "10.0.0.7",
+				Port:     5432,
+				Username: "orders",
+				Password: "secret",
+				Database: "orders",
+			},
+		},
+		{
+			name: "database_without_proxy",
+			socket: models.Socket{
+				SocketType:    "database",
+				ConnectorData: &models.ConnectorData{Name: "legacy-db", PluginName: staticSocketPlugin.Name()},
+			},
+		},
+		{
+			name: "other_plugin",
+			socket: models.Socket{
+				SocketType:    "database",
+				ConnectorData: &models.ConnectorData{Name: "orders-db", PluginName: "DockerFinder"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnectorCore(zap.NewNop(), cfg, staticSocketPlugin, nil, Metadata{})
+			got, err := c.databaseProxyConfig(context.Background(), tt.socket)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConnectorCore_databaseProxy_FailsClosed(t *testing.T) {
+	cfg := validConfig()
+	cfg.Sockets = append(cfg.Sockets, map[string]config.SocketConfig{
+		"orders.db": {
+			Host:           "10.0.0.7",
+			Port:           5432,
+			Type:           "database",
+			UpstreamCaFile: "/nonexistent/ca.pem",
+			DatabaseProxy:  config.DatabaseProxy{Enabled: true},
+		},
+	})
+
+	staticSocketPlugin := &discover.StaticSocketFinder{}
+	c := NewConnectorCore(zap.NewNop(), cfg, staticSocketPlugin, nil, Metadata{})
+
+	proxy, err := c.databaseProxy(context.Background(), models.Socket{
+		SocketType:    "database",
+		ConnectorData: &models.ConnectorData{Name: "orders-db", PluginName: staticSocketPlugin.Name()},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, proxy)
+}
+
+func TestConnectorCore_databaseProxyConfig_SocketCertificates(t *testing.T) {
+	cfg := validConfig()
+	cfg.Sockets = append(cfg.Sockets, map[string]config.SocketConfig{
+		"orders.db": {
+			Host:          "10.0.0.7",
+			Port:          5432,
+			Type:          "database",
+			DatabaseProxy: config.DatabaseProxy{Enabled: true},
+		},
+	})
+
+	cert, key := selfSignedCertificate(t)
+	staticSocketPlugin := &discover.StaticSocketFinder{}
+	c := NewConnectorCore(zap.NewNop(), cfg, staticSocketPlugin, nil, Metadata{})
+
+	socket := models.Socket{
+		SocketType:    "database",
+		UpstreamCert:  &cert,
+		UpstreamKey:   &key,
+		UpstreamCa:    &cert,
+		ConnectorData: &models.ConnectorData{Name: "orders-db", PluginName: staticSocketPlugin.Name()},
+	}
+	got, err := c.databaseProxyConfig(context.Background(), socket)
+	assert.NoError(t, err)
+	if assert.NotNil(t, got.TLS) {
+		assert.Len(t, got.TLS.Certificates, 1)
+		assert.NotNil(t, got.TLS.RootCAs)
+	}
+
+	// without the key the upstream can't be reached, so the socket fails closed
+	socket.UpstreamKey = nil
+	_, err = c.databaseProxyConfig(context.Background(), socket)
+	assert.Error(t, err)
+}
+
+func selfSignedCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "orders-db"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/connector/discover"
+	"github.com/borderzero/border0-cli/internal/ssh"
+	"go.uber.org/zap"
+)
+
+// sshServerConfig returns the settings of the built-in ssh server for the socket,
+// or nil when the socket is not served by the connector's ssh server
+func (c *ConnectorCore) sshServerConfig(ctx context.Context, socket models.Socket) *ssh.ServerConfig {
+	if socket.SocketType != "ssh" || socket.ConnectorData == nil {
+		return nil
+	}
+
+	if execDiscover, ok := c.discovery.(discover.ExecDiscover); ok {
+		backend, err := execDiscover.ExecBackend(ctx, c.cfg, socket)
+		if err != nil {
+			c.logger.Error("failed to get exec backend for socket", zap.String("socket", socket.Name), zap.Error(err))
+			return nil
+		}
+
+		if backend != nil {
+			return &ssh.ServerConfig{Exec: backend, Limits: c.execSessionLimits(socket)}
+		}
+	}
+
+	socketConfig, ok := c.staticSocketConfig(socket)
+	if !ok || !socketConfig.SshServer.Enabled {
+		return nil
+	}
+
+	return newSshServerConfig(socketConfig)
+}
+
+// execSessionLimits returns the session limits of the plugin group of a socket
+// whose sessions run on an exec backend
+func (c *ConnectorCore) execSessionLimits(socket models.Socket) ssh.SessionLimits {
+	var limits config.SshLimits
+
+	switch socket.ConnectorData.PluginName {
+	case (&discover.DockerFinder{}).Name():
+		for _, group := range c.cfg.DockerPlugin {
+			if group.Group == socket.ConnectorData.PolicyGroup {
+				limits = group.SshLimits
+			}
+		}
+	case (&discover.K8Discover{}).Name():
+		for _, group := range c.cfg.K8Plugin {
+			if group.Group == socket.ConnectorData.PolicyGroup {
+				limits = group.SshLimits
+			}
+		}
+	}
+
+	return ssh.SessionLimits{
+		IdleTimeout:            limits.IdleTimeout,
+		MaxDuration:            limits.MaxSessionDuration,
+		MaxSessionsPerIdentity: limits.MaxSessions,
+		WarningBefore:          limits.SessionWarning,
+	}
+}
+
+// staticSocketConfig returns the config file entry of a socket found by the
+// static sockets plugin
+func (c *ConnectorCore) staticSocketConfig(socket models.Socket) (config.SocketConfig, bool) {
+	if socket.ConnectorData.PluginName != (&discover.StaticSocketFinder{}).Name() {
+		return config.SocketConfig{}, false
+	}
+
+	for _, socketMap := range c.cfg.Sockets {
+		for name, socketConfig := range socketMap {
+			configSocket := models.Socket{Name: name}
+			configSocket.SanitizeName()
+
+			if configSocket.Name == socket.ConnectorData.Name {
+				return socketConfig, true
+			}
+		}
+	}
+
+	return config.SocketConfig{}, false
+}
+
+func newSshServerConfig(socketConfig config.SocketConfig) *ssh.ServerConfig {
+	cfg := socketConfig.SshServer
+
+	serverConfig := &ssh.ServerConfig{
+		HostKeyDir: cfg.HostKeyDir,
+		Users: ssh.UserConfig{
+			Mapping:   cfg.UserMapping,
+			AllowRoot: cfg.AllowRoot,
+		},
+		Sftp: ssh.SftpConfig{
+			Disabled: cfg.DisableSftp,
+			Chroot:   cfg.SftpChroot,
+			ReadOnly: cfg.SftpReadOnly,
+		},
+		Forwarding: ssh.ForwardingConfig{
+			LocalAllowed:  cfg.AllowLocalForward,
+			RemoteAllowed: cfg.AllowRemoteForward,
+			Agent:         cfg.AllowAgentForwarding,
+		},
+		Limits: ssh.SessionLimits{
+			IdleTimeout:            cfg.IdleTimeout,
+			MaxDuration:            cfg.MaxSessionDuration,
+			MaxSessionsPerIdentity: cfg.MaxSessions,
+			WarningBefore:          cfg.SessionWarning,
+		},
+	}
+
+	if cfg.Upstream {
+		serverConfig.Upstream = &ssh.UpstreamConfig{
+			Host:           socketConfig.Host,
+			Port:           socketConfig.Port,
+			Username:       socketConfig.UpstreamUser,
+			Password:       socketConfig.UpstreamPassword,
+			PrivateKey:     socketConfig.UpstreamPrivateKey,
+			PrivateKeyFile: socketConfig.UpstreamIdentityFile,
+			KnownHostsFile: socketConfig.UpstreamKnownHosts,
+
+			InsecureIgnoreHostKey: cfg.UpstreamInsecureIgnoreHostKey,
+		}
+	}
+
+	return serverConfig
+}
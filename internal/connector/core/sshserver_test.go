@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/connector/discover"
+	"github.com/borderzero/border0-cli/internal/ssh"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestConnectorCore_sshServerConfig(t *testing.T) {
+	cfg := validConfig()
+	cfg.Sockets = append(cfg.Sockets, map[string]config.SocketConfig{
+		"legacy.host": {
+			Host:                 "10.0.0.5",
+			Port:                 2222,
+			Type:                 "ssh",
+			UpstreamUser:         "deploy",
+			UpstreamPassword:     "secret",
+			UpstreamIdentityFile: "/etc/border0/legacy_key",
+			SshServer:            config.SshServer{Enabled: true, Upstream: true, AllowRoot: true},
+		},
+	})
+
+	staticSocketPlugin := &discover.StaticSocketFinder{}
+
+	tests := []struct {
+		name   string
+		socket models.Socket
+		want   *ssh.ServerConfig
+	}{
+		{
+			name: "upstream_ssh_socket",
+			socket: models.Socket{
+				SocketType:    "ssh",
+				ConnectorData: &models.ConnectorData{Name: "legacy-host", PluginName: staticSocketPlugin.Name()},
+			},
+			want: &ssh.ServerConfig{
+				Users: ssh.UserConfig{AllowRoot: true},
+				Upstream: &ssh.UpstreamConfig{
+					Host:           "10.0.0.5",
+					Port:           2222,
+					Username:       "deploy",
+					Password:       "secret",
+					PrivateKeyFile: "/etc/border0/legacy_key",
+				},
+			},
+		},
+		{
+			name: "not_an_ssh_socket",
+			socket: models.Socket{
+				SocketType:    "http",
+				ConnectorData: &models.ConnectorData{Name: "webserver-connector-lab", PluginName: staticSocketPlugin.Name()},
+			},
+		},
+		{
+			name: "other_plugin",
+			socket: models.Socket{
+				SocketType:    "ssh",
+				ConnectorData: &models.ConnectorData{Name: "legacy-host", PluginName: "DockerFinder"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnectorCore(zap.NewNop(), cfg, staticSocketPlugin, nil, Metadata{})
+			assert.Equal(t, tt.want, c.sshServerConfig(context.Background(), tt.socket))
+		})
+	}
+}
+
+func TestConnectorCore_execSessionLimits(t *testing.T) {
+	cfg := validConfig()
+	cfg.DockerPlugin = []config.ConnectorGroups{
+		{Group: "ops", SshLimits: config.SshLimits{IdleTimeout: 15 * time.Minute, MaxSessions: 2}},
+	}
+	cfg.K8Plugin = []config.K8Plugin{
+		{Group: "ops", SshLimits: config.SshLimits{MaxSessionDuration: time.Hour, SessionWarning: 5 * time.Minute}},
+	}
+
+	tests := []struct {
+		name   string
+		socket models.Socket
+		want   ssh.SessionLimits
+	}{
+		{
+			name:   "docker_group",
+			socket: models.Socket{ConnectorData: &models.ConnectorData{PluginName: "DockerFinder", PolicyGroup: "ops"}},
+			want:   ssh.SessionLimits{IdleTimeout: 15 * time.Minute, MaxSessionsPerIdentity: 2},
+		},
+		{
+			name:   "k8_group",
+			socket: models.Socket{ConnectorData: &models.ConnectorData{PluginName: "K8Discover", PolicyGroup: "ops"}},
+			want:   ssh.SessionLimits{MaxDuration: time.Hour, WarningBefore: 5 * time.Minute},
+		},
+		{
+			name:   "other_group",
+			socket: models.Socket{ConnectorData: &models.ConnectorData{PluginName: "DockerFinder", PolicyGroup: "dev"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnectorCore(zap.NewNop(), cfg, &discover.DockerFinder{}, nil, Metadata{})
+			assert.Equal(t, tt.want, c.execSessionLimits(tt.socket))
+		})
+	}
+}
@@ -28,6 +28,16 @@ func (s *SyncMap) Delete(key interface{}) {
 	s.mutex.Unlock()
 }
 
+// DeleteValue deletes the key only when it still has the value, so a value
+// that replaced it isn't deleted
+func (s *SyncMap) DeleteValue(key, value interface{}) {
+	s.mutex.Lock()
+	if current, ok := s.m.Load(key); ok && current == value {
+		s.m.Delete(key)
+	}
+	s.mutex.Unlock()
+}
+
 func (s *SyncMap) Len() int {
 	count := 0
 	s.m.Range(func(k, v interface{}) bool {
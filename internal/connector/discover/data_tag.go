@@ -15,6 +15,12 @@ type SocketDataTag struct {
 	UpstreamUsername string `mapstructure:"upstream_username"`
 	UpstreamPassword string `mapstructure:"upstream_password"`
 	UpstreamType     string `mapstructure:"upstream_type"`
+	Exec             string `mapstructure:"exec"`
+	User             string `mapstructure:"user"`
+	Shell            string `mapstructure:"shell"`
+	Proxy            string `mapstructure:"proxy"`
+	Database         string `mapstructure:"database"`
+	Audit            string `mapstructure:"audit"`
 }
 
 // Parse the tag and transform it into a structured data called SocketDataTag
@@ -22,6 +28,12 @@ type SocketDataTag struct {
 // border0_ssh="port=22,type=ssh,group=allowed_users"
 // border0_81="type=http,port=81,group=docker_team,name=ngx-srv1-p81"
 // border0_01="type=database,port=3306,group=docker_team,upstream_type=mysql,upstream_user=root,upstream_pass=my-secret-pw,name=my-docker-mysql-db"
+// border0_ldap="type=tcp,port=389,group=docker_team,name=my-docker-ldap"
+// border0_cache="type=database,port=6379,group=docker_team,upstream_type=redis,name=my-docker-redis"
+// border0_shell="type=ssh,exec=docker,group=docker_team,user=app,shell=/bin/bash"
+// border0_db="type=database,port=5432,group=docker_team,upstream_type=postgres,upstream_username=app,upstream_password=my-secret-pw,proxy=connector,database=shop,audit=log"
+// audit=log needs connector_authentication enabled in the docker_plugin group
+// exec=docker needs user, the container user the sessions run as
 // NOTE: be aware of single and double quoting across different platforms, docker compose for example:
 // labels:
 // - "border0_80=type=http,port=80,group=my_super_ops_team"
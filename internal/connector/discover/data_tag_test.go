@@ -0,0 +1,53 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		label       string
+		want        SocketDataTag
+		wantExec    bool
+		wantDbProxy bool
+	}{
+		{
+			name:     "docker_exec",
+			label:    "type=ssh,exec=docker,group=docker_team,user=app,shell=/bin/bash",
+			want:     SocketDataTag{Type: "ssh", Exec: "docker", Group: "docker_team", User: "app", Shell: "/bin/bash"},
+			wantExec: true,
+		},
+		{
+			name:  "docker_exec_without_ssh_type",
+			label: "type=tcp, exec=docker, group=docker_team",
+			want:  SocketDataTag{Type: "tcp", Exec: "docker", Group: "docker_team"},
+		},
+		{
+			name:  "ssh_to_container_port",
+			label: "port=22,type=ssh,group=allowed_users",
+			want:  SocketDataTag{Port: "22", Type: "ssh", Group: "allowed_users"},
+		},
+		{
+			name:        "database_proxy",
+			label:       "type=database,port=5432,upstream_type=postgres,proxy=connector,database=shop,audit=log",
+			want:        SocketDataTag{Type: "database", Port: "5432", UpstreamType: "postgres", Proxy: "connector", Database: "shop", Audit: "log"},
+			wantDbProxy: true,
+		},
+		{
+			name:  "ignores_labels_without_value",
+			label: "type=http,group,=,port=80",
+			want:  SocketDataTag{Type: "http", Port: "80"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseLabels(tt.label)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantExec, isDockerExec(got))
+			assert.Equal(t, tt.wantDbProxy, isDatabaseProxy(got))
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/dbproxy"
+	"github.com/borderzero/border0-cli/internal/ssh"
 )
 
 type DiscoverState struct {
@@ -18,3 +20,19 @@ type Discover interface {
 	WaitSeconds() int64
 	Name() string
 }
+
+// ExecDiscover is implemented by plugins whose ssh sockets can run sessions in
+// the discovered workload, e.g. a container, instead of requiring sshd there
+type ExecDiscover interface {
+	// ExecBackend returns the exec backend for the socket, or nil when the
+	// socket's sessions don't run in the workload
+	ExecBackend(ctx context.Context, cfg config.Config, socket models.Socket) (ssh.ExecBackend, error)
+}
+
+// DatabaseDiscover is implemented by plugins whose database sockets can be
+// proxied by the connector, so the upstream credentials stay on the connector
+type DatabaseDiscover interface {
+	// DatabaseProxy returns the upstream database of the socket, or nil when the
+	// socket is not proxied by the connector
+	DatabaseProxy(ctx context.Context, cfg config.Config, socket models.Socket) (*dbproxy.Config, error)
+}
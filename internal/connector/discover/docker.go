@@ -2,6 +2,8 @@ package discover
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"reflect"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/dbproxy"
+	"github.com/borderzero/border0-cli/internal/ssh"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
@@ -23,6 +27,8 @@ type DockerFinder struct {
 }
 
 var _ Discover = (*DockerFinder)(nil)
+var _ ExecDiscover = (*DockerFinder)(nil)
+var _ DatabaseDiscover = (*DockerFinder)(nil)
 
 func (s *DockerFinder) SkipRun(ctx context.Context, cfg config.Config, state DiscoverState) bool {
 	return false
@@ -82,6 +88,12 @@ func (s *DockerFinder) Find(ctx context.Context, cfg config.Config, state Discov
 					if metadata.Group != "" && group.Group == metadata.Group {
 						s.Logger.Debug("matching group", zap.String("containerID", container.ID), zap.String("group", group.Group))
 
+						if isDockerExec(metadata) {
+							s.Logger.Info("add instance as docker exec socket", zap.String("instanceName", instanceName))
+							sockets = append(sockets, s.buildSocket(cfg.Connector.Name, group, metadata, container, instanceName, instanceName, 0))
+							continue
+						}
+
 						ip := s.extractIPAddress(container.NetworkSettings.Networks, connectorNetworkId, connectorGwIp)
 
 						// Now determine the port
@@ -114,6 +126,12 @@ func (s *DockerFinder) Find(ctx context.Context, cfg config.Config, state Discov
 							continue
 						}
 
+						// the audit log needs the identity connector authentication provides
+						if isDatabaseProxy(metadata) && metadata.Audit != "" && !group.ConnectorAuthenticationEnabled {
+							s.Logger.Error("audit label requires connector_authentication in the docker_plugin group... ignoring instance: ", zap.String("instanceName", instanceName))
+							continue
+						}
+
 						s.Logger.Info("add instance as socket", zap.String("instanceName", instanceName))
 						sockets = append(sockets, s.buildSocket(cfg.Connector.Name, group, metadata, container, instanceName, ip, port))
 					} else {
@@ -141,6 +159,12 @@ func (s *DockerFinder) buildSocket(connectorName string, group config.ConnectorG
 	socket.UpstreamUsername = socketData.UpstreamUsername
 	socket.UpstreamPassword = socketData.UpstreamPassword
 
+	// upstream credentials stay on the connector when it proxies the database
+	if isDatabaseProxy(socketData) {
+		socket.UpstreamUsername = ""
+		socket.UpstreamPassword = ""
+	}
+
 	socket.ConnectorAuthenticationEnabled = group.ConnectorAuthenticationEnabled
 
 	socket.TargetHostname = socketData.Host
@@ -244,6 +268,116 @@ func (s *DockerFinder) extractPort(ports []types.Port, portType string) uint16 {
 	return 0
 }
 
+// ExecBackend returns the docker exec backend for ssh sockets of containers
+// labeled with exec=docker
+func (s *DockerFinder) ExecBackend(ctx context.Context, cfg config.Config, socket models.Socket) (ssh.ExecBackend, error) {
+	if socket.SocketType != "ssh" {
+		return nil, nil
+	}
+
+	container, metadata, err := s.socketLabel(ctx, cfg, socket, isDockerExec)
+	if err != nil || metadata == nil {
+		return nil, err
+	}
+
+	// without a user the requested login would pick any user of the container
+	if metadata.User == "" {
+		return nil, fmt.Errorf("container %s: exec=docker requires the user label", container.Name)
+	}
+
+	return &ssh.DockerExec{
+		ContainerID: container.ID,
+		User:        metadata.User,
+		Shell:       metadata.Shell,
+	}, nil
+}
+
+// DatabaseProxy returns the upstream database for database sockets of containers
+// labeled with proxy=connector
+func (s *DockerFinder) DatabaseProxy(ctx context.Context, cfg config.Config, socket models.Socket) (*dbproxy.Config, error) {
+	if socket.SocketType != "database" {
+		return nil, nil
+	}
+
+	_, metadata, err := s.socketLabel(ctx, cfg, socket, isDatabaseProxy)
+	if err != nil || metadata == nil {
+		return nil, err
+	}
+
+	labelSocket := models.Socket{SocketType: metadata.Type, UpstreamType: metadata.UpstreamType, TargetPort: socket.ConnectorData.Port}
+	labelSocket.SetupTypeAndUpstreamTypeByPortOrTags()
+
+	proxyConfig := &dbproxy.Config{
+		Type:     labelSocket.UpstreamType,
+		Host:     socket.ConnectorData.TargetHostname,
+		Port:     socket.ConnectorData.Port,
+		Username: metadata.UpstreamUsername,
+		Password: metadata.UpstreamPassword,
+		Database: metadata.Database,
+	}
+
+	// audit=log or audit=stdout sends the statements to that audit sink
+	if metadata.Audit != "" {
+		if !socket.ConnectorAuthenticationEnabled {
+			return nil, errors.New("audit requires connector authentication")
+		}
+		sink, err := dbproxy.NewAuditSink(s.Logger, metadata.Audit, "")
+		if err != nil {
+			return nil, err
+		}
+		proxyConfig.Audit = sink
+	}
+
+	return proxyConfig, nil
+}
+
+// socketLabel returns the container of the socket and its border0 label that
+// matches and built the socket
+func (s *DockerFinder) socketLabel(ctx context.Context, cfg config.Config, socket models.Socket, match func(SocketDataTag) bool) (*types.ContainerJSON, *SocketDataTag, error) {
+	if socket.ConnectorData == nil || socket.ConnectorData.InstanceId == "" {
+		return nil, nil, nil
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cli.Close()
+
+	container, err := cli.ContainerInspect(ctx, socket.ConnectorData.InstanceId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instanceName := strings.Replace(container.Name, "/", "", -1)
+	for k, v := range container.Config.Labels {
+		if !strings.HasPrefix(strings.ToLower(k), "border0") {
+			continue
+		}
+
+		metadata := parseLabels(v)
+		if !match(metadata) {
+			continue
+		}
+
+		labelSocket := models.Socket{Name: buildSocketName(instanceName, cfg.Connector.Name, metadata.Type, metadata.Name)}
+		labelSocket.SanitizeName()
+		if labelSocket.Name == socket.ConnectorData.Name {
+			return &container, &metadata, nil
+		}
+	}
+
+	return nil, nil, nil
+}
+
+func isDockerExec(metadata SocketDataTag) bool {
+	return metadata.Type == "ssh" && metadata.Exec == "docker"
+}
+
+func isDatabaseProxy(metadata SocketDataTag) bool {
+	return metadata.Proxy == "connector"
+}
+
 func (s *DockerFinder) Name() string {
 	return reflect.TypeOf(s).Elem().Name()
 }
@@ -8,8 +8,10 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api/models"
 	"github.com/borderzero/border0-cli/internal/connector/config"
+	"github.com/borderzero/border0-cli/internal/ssh"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -19,6 +21,7 @@ type K8Discover struct {
 }
 
 var _ Discover = (*K8Discover)(nil)
+var _ ExecDiscover = (*K8Discover)(nil)
 
 func NewK8Discover() *K8Discover {
 	config, err := rest.InClusterConfig()
@@ -73,7 +76,10 @@ func (s *K8Discover) buildSocket(connectorName string, group config.K8Plugin, se
 	socket := models.Socket{}
 	socket.PolicyGroup = group.Group
 	socket.InstanceId = string(service.UID)
-	socket.TargetPort = int(service.Spec.Ports[0].Port)
+	// exec sockets run sessions in the pods, so the service may have no ports
+	if len(service.Spec.Ports) > 0 {
+		socket.TargetPort = int(service.Spec.Ports[0].Port)
+	}
 	socket.TargetHostname = service.Spec.ClusterIP
 
 	switch service.Annotations["border0.com/socketType"] {
@@ -81,11 +87,13 @@ func (s *K8Discover) buildSocket(connectorName string, group config.K8Plugin, se
 		socket.SocketType = "http"
 	case "ssh":
 		socket.SocketType = "ssh"
+	case "tcp":
+		socket.SocketType = "tcp"
 	case "database":
 		socket.SocketType = "database"
-	case "postgres":
+	case "mysql", "postgres", "redis", "mongodb", "mssql":
 		socket.SocketType = "database"
-		socket.UpstreamType = "postgres"
+		socket.UpstreamType = service.Annotations["border0.com/socketType"]
 	default:
 		socket.SocketType = "tls"
 	}
@@ -117,6 +125,62 @@ func (s *K8Discover) buildSocket(connectorName string, group config.K8Plugin, se
 	return &socket
 }
 
+// ExecBackend returns the pods/exec backend for ssh services annotated with
+// border0.com/exec, the pods are selected by the border0.com/execPod or
+// border0.com/execSelector annotations and default to the service's selector
+func (s *K8Discover) ExecBackend(ctx context.Context, cfg config.Config, socket models.Socket) (ssh.ExecBackend, error) {
+	if socket.SocketType != "ssh" || socket.ConnectorData == nil || socket.ConnectorData.InstanceId == "" {
+		return nil, nil
+	}
+
+	clientset, err := kubernetes.NewForConfig(s.clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range cfg.K8Plugin {
+		if group.Group != socket.ConnectorData.PolicyGroup {
+			continue
+		}
+
+		services, err := clientset.CoreV1().Services(group.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, service := range services.Items {
+			if string(service.UID) != socket.ConnectorData.InstanceId {
+				continue
+			}
+
+			if service.Annotations["border0.com/exec"] != "true" {
+				return nil, nil
+			}
+
+			selector := service.Annotations["border0.com/execSelector"]
+			if selector == "" {
+				selector = labels.SelectorFromSet(service.Spec.Selector).String()
+			}
+
+			backend, err := ssh.NewKubernetesExec(
+				s.clusterConfig,
+				service.Namespace,
+				service.Annotations["border0.com/execPod"],
+				selector,
+				service.Annotations["border0.com/execContainer"],
+				service.Annotations["border0.com/execShell"],
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			return backend, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (s *K8Discover) Name() string {
 	return reflect.TypeOf(s).Elem().Name()
 }
@@ -27,6 +27,7 @@ func (s *StaticSocketFinder) Find(ctx context.Context, cfg config.Config, state
 
 		for k, v := range socketMap {
 			socket.Name = k
+			socket.Description = v.Description
 			socket.AllowedEmailAddresses = v.AllowedEmailAddresses
 			socket.AllowedEmailDomains = v.AllowedEmailDomains
 			socket.SocketType = v.Type
@@ -40,6 +41,12 @@ func (s *StaticSocketFinder) Find(ctx context.Context, cfg config.Config, state
 			socket.PolicyNames = v.Policies
 
 			socket.UpstreamType = v.UpstreamType
+
+			// upstream credentials stay on the connector when it serves the socket itself
+			if v.SshServer.Enabled || v.DatabaseProxy.Enabled {
+				socket.UpstreamUsername = ""
+				socket.UpstreamPassword = ""
+			}
 		}
 
 		sockets = append(sockets, socket)
@@ -0,0 +1,147 @@
+package credstore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/profile"
+	"github.com/borderzero/border0-cli/internal/util"
+)
+
+const (
+	// AgentCommand is the hidden command that runs the credential agent
+	AgentCommand = "credential-agent"
+
+	defaultAgentTTL = 12 * time.Hour
+)
+
+// agentSocket is where the credential agent of the current profile listens
+func agentSocket() string {
+	home, _ := os.UserHomeDir()
+	return profile.Path(filepath.Join(home, ".border0"), "credential-agent.sock")
+}
+
+// agentTTL is how long the agent keeps the passphrase, BORDER0_CREDENTIAL_CACHE_TTL
+// overrides the default of 12 hours
+func agentTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("BORDER0_CREDENTIAL_CACHE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return defaultAgentTTL
+}
+
+// RunAgent keeps the passphrase in memory for other border0 commands of the
+// same user until the ttl runs out or the agent is locked
+func RunAgent(passphrase string) error {
+	path := agentSocket()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// a socket left behind by a previous agent
+	os.Remove(path)
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to start credential agent: %w", err)
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return err
+	}
+
+	time.AfterFunc(agentTTL(), func() { l.Close() })
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return nil
+		}
+
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		command, _ := bufio.NewReader(conn).ReadString('\n')
+		switch strings.TrimSpace(command) {
+		case "get":
+			fmt.Fprintln(conn, passphrase)
+		case "lock":
+			conn.Close()
+			l.Close()
+			return nil
+		}
+		conn.Close()
+	}
+}
+
+func agentRequest(command string) (string, error) {
+	conn, err := net.DialTimeout("unix", agentSocket(), time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && command != "lock" {
+		return "", err
+	}
+
+	return strings.TrimRight(reply, "\n"), nil
+}
+
+// agentPassphrase returns the passphrase cached by the agent, if one is running
+func agentPassphrase() (string, error) {
+	passphrase, err := agentRequest("get")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("credential agent has no passphrase")
+	}
+
+	return passphrase, nil
+}
+
+// startAgent starts the agent in the background, the passphrase is handed over
+// on its stdin so it doesn't show up in the process list
+func startAgent(passphrase string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, AgentCommand)
+	util.Detach(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdin, passphrase)
+	stdin.Close()
+	if err != nil {
+		return err
+	}
+
+	return cmd.Process.Release()
+}
+
+// Lock stops the credential agent, the passphrase is asked for again next time
+func Lock() {
+	agentRequest("lock")
+}
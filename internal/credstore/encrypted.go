@@ -0,0 +1,244 @@
+package credstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// encryptedExt is added to the path of encrypted credentials
+const encryptedExt = ".age"
+
+var defaultEncrypted = &Encrypted{}
+
+// Encrypted stores credentials encrypted with age. The key is the age identity
+// in BORDER0_AGE_IDENTITY_FILE, or a passphrase that is asked for once and
+// cached by the credential agent, or taken from BORDER0_CREDENTIAL_PASSPHRASE
+type Encrypted struct {
+	mu        sync.Mutex
+	identity  age.Identity
+	recipient age.Recipient
+	// passphrase is set when the key is a passphrase that isn't cached yet
+	passphrase string
+	// verified is set once the key decrypted existing credentials, or was
+	// confirmed for a new store
+	verified bool
+	// cache holds decrypted credentials, scrypt makes decrypting slow
+	cache map[string][]byte
+}
+
+func (e *Encrypted) Read(path string) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if data, ok := e.cache[path]; ok {
+		return data, nil
+	}
+
+	ciphertext, err := os.ReadFile(path + encryptedExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		// credentials written before the store was encrypted
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.unlock(); err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), e.identity)
+	if err != nil {
+		e.identity, e.recipient = nil, nil
+		return nil, fmt.Errorf("failed to decrypt %s, wrong passphrase or identity: %w", path+encryptedExt, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path+encryptedExt, err)
+	}
+
+	e.verified = true
+	e.unlocked()
+	e.remember(path, data)
+
+	return data, nil
+}
+
+func (e *Encrypted) Write(path string, data []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.unlock(); err != nil {
+		return err
+	}
+	if err := e.verify(path); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, e.recipient)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", path, err)
+	}
+
+	if err := writeFile(path+encryptedExt, buf.Bytes()); err != nil {
+		return err
+	}
+
+	e.unlocked()
+	e.remember(path, data)
+
+	// don't leave a plaintext copy behind
+	return Wipe(path)
+}
+
+func (e *Encrypted) Delete(path string) error {
+	e.mu.Lock()
+	delete(e.cache, path)
+	e.mu.Unlock()
+
+	if err := Wipe(path + encryptedExt); err != nil {
+		return err
+	}
+
+	return Wipe(path)
+}
+
+func (e *Encrypted) remember(path string, data []byte) {
+	if e.cache == nil {
+		e.cache = map[string][]byte{}
+	}
+	e.cache[path] = data
+}
+
+// unlock loads the key, asking for the passphrase when it isn't cached
+func (e *Encrypted) unlock() error {
+	if e.identity != nil {
+		return nil
+	}
+	e.verified = false
+
+	if identityFile := os.Getenv("BORDER0_AGE_IDENTITY_FILE"); identityFile != "" {
+		return e.loadIdentityFile(identityFile)
+	}
+
+	passphrase := os.Getenv("BORDER0_CREDENTIAL_PASSPHRASE")
+	if passphrase == "" {
+		passphrase, _ = agentPassphrase()
+	}
+	if passphrase == "" {
+		if err := survey.AskOne(&survey.Password{
+			Message: "credential store passphrase:",
+		}, &passphrase, survey.WithValidator(survey.Required)); err != nil {
+			return fmt.Errorf("couldn't capture passphrase: %w", err)
+		}
+		e.passphrase = passphrase
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	e.identity, e.recipient = identity, recipient
+	return nil
+}
+
+// verify checks the key decrypts the existing credentials next to path, so
+// credentials are never written with a mistyped passphrase. When there are none
+// yet the store is new, and a passphrase that was typed in is asked for again
+func (e *Encrypted) verify(path string) error {
+	if e.verified {
+		return nil
+	}
+
+	existing, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"+encryptedExt))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path + encryptedExt); err == nil {
+		existing = append([]string{path + encryptedExt}, existing...)
+	}
+
+	if len(existing) == 0 {
+		if e.passphrase != "" {
+			var confirmation string
+			if err := survey.AskOne(&survey.Password{
+				Message: "confirm the new credential store passphrase:",
+			}, &confirmation); err != nil {
+				return fmt.Errorf("couldn't capture passphrase: %w", err)
+			}
+			if confirmation != e.passphrase {
+				e.identity, e.recipient, e.passphrase = nil, nil, ""
+				return errors.New("passphrases don't match")
+			}
+		}
+		e.verified = true
+		return nil
+	}
+
+	ciphertext, err := os.ReadFile(existing[0])
+	if err != nil {
+		return err
+	}
+	if _, err := age.Decrypt(bytes.NewReader(ciphertext), e.identity); err != nil {
+		e.identity, e.recipient, e.passphrase = nil, nil, ""
+		return fmt.Errorf("failed to decrypt %s, wrong passphrase or identity: %w", existing[0], err)
+	}
+
+	e.verified = true
+	return nil
+}
+
+// unlocked caches a passphrase that was typed in, once it's known to be right
+func (e *Encrypted) unlocked() {
+	if e.passphrase == "" {
+		return
+	}
+
+	if err := startAgent(e.passphrase); err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: could not start credential agent:", err)
+	}
+	e.passphrase = ""
+}
+
+func (e *Encrypted) loadIdentityFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse age identity file %s: %w", path, err)
+	}
+
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			e.identity, e.recipient = x25519, x25519.Recipient()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no X25519 identity found in %s", path)
+}
@@ -0,0 +1,113 @@
+package credstore
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/borderzero/border0-cli/internal/profile"
+)
+
+const (
+	// PlaintextBackend stores credentials as files only readable by the user
+	PlaintextBackend = "file"
+	// EncryptedBackend stores credentials encrypted with age, with a passphrase
+	// or an age identity file
+	EncryptedBackend = "encrypted"
+)
+
+// ErrNotFound is returned for credentials that aren't stored
+var ErrNotFound = fs.ErrNotExist
+
+// Store keeps credentials like tokens and private keys, they are looked up by
+// the path they have always been stored at
+type Store interface {
+	Read(path string) ([]byte, error)
+	Write(path string, data []byte) error
+	Delete(path string) error
+}
+
+// New returns the store of the current profile, BORDER0_CREDENTIAL_STORE
+// overrides the backend picked for the profile
+func New() Store {
+	backend := os.Getenv("BORDER0_CREDENTIAL_STORE")
+	if backend == "" {
+		backend = profile.Current().CredentialStore
+	}
+
+	if backend == EncryptedBackend {
+		return defaultEncrypted
+	}
+
+	return Plaintext{}
+}
+
+// ValidateBackend makes sure the backend is a known one
+func ValidateBackend(backend string) error {
+	switch backend {
+	case "", PlaintextBackend, EncryptedBackend:
+		return nil
+	}
+	return fmt.Errorf("unknown credential store %q, use %s or %s", backend, PlaintextBackend, EncryptedBackend)
+}
+
+// Plaintext stores credentials in files only readable by the user. Files that
+// are used by other programs, like ssh keys and client certificates, are
+// always stored in plaintext
+type Plaintext struct{}
+
+func (Plaintext) Read(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (Plaintext) Write(path string, data []byte) error {
+	return writeFile(path, data)
+}
+
+func (Plaintext) Delete(path string) error {
+	return Wipe(path)
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	// WriteFile keeps the mode of existing files
+	return os.Chmod(path, 0600)
+}
+
+// Wipe overwrites a file with random data before removing it, files that don't
+// exist are ignored. Symlinks are removed without touching what they point to
+func Wipe(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().IsRegular() {
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			noise := make([]byte, info.Size())
+			rand.Read(noise)
+			f.WriteAt(noise, 0)
+			f.Sync()
+			f.Close()
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package credstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_RoundTrip(t *testing.T) {
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+
+	tests := []struct {
+		name     string
+		store    Store
+		fileName string
+	}{
+		{name: "plaintext", store: Plaintext{}, fileName: "token"},
+		{name: "encrypted", store: &Encrypted{}, fileName: "token" + encryptedExt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "token")
+
+			require.NoError(t, tt.store.Write(path, []byte("secret")))
+			assert.FileExists(t, filepath.Join(filepath.Dir(path), tt.fileName))
+
+			data, err := tt.store.Read(path)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", string(data))
+
+			require.NoError(t, tt.store.Delete(path))
+			_, err = tt.store.Read(path)
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestEncrypted_DoesNotStorePlaintext(t *testing.T) {
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+	path := filepath.Join(t.TempDir(), "token")
+
+	// a legacy plaintext token is still readable and replaced on write
+	require.NoError(t, os.WriteFile(path, []byte("legacy"), 0600))
+	store := &Encrypted{}
+	data, err := store.Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", string(data))
+
+	require.NoError(t, store.Write(path, []byte("secret")))
+	assert.NoFileExists(t, path)
+	ciphertext, err := os.ReadFile(path + encryptedExt)
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "secret")
+
+	// a new store has no cache, so it has to decrypt the file
+	data, err = (&Encrypted{}).Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "wrong")
+	_, err = (&Encrypted{}).Read(path)
+	assert.Error(t, err)
+}
+
+func TestEncrypted_WriteVerifiesPassphrase(t *testing.T) {
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+	dir := t.TempDir()
+	require.NoError(t, (&Encrypted{}).Write(filepath.Join(dir, "token"), []byte("secret")))
+
+	// a wrong passphrase doesn't get to write credentials next to the existing ones
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "wrong")
+	err := (&Encrypted{}).Write(filepath.Join(dir, "user_1"), []byte("other"))
+	assert.ErrorContains(t, err, "wrong passphrase")
+	assert.NoFileExists(t, filepath.Join(dir, "user_1"+encryptedExt))
+
+	t.Setenv("BORDER0_CREDENTIAL_PASSPHRASE", "correct horse battery staple")
+	assert.NoError(t, (&Encrypted{}).Write(filepath.Join(dir, "user_1"), []byte("other")))
+}
+
+func TestWipe(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "key")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.WriteFile(target, []byte("private key"), 0600))
+	require.NoError(t, os.Symlink(target, link))
+
+	require.NoError(t, Wipe(link))
+	assert.NoFileExists(t, link)
+	assert.FileExists(t, target)
+
+	require.NoError(t, Wipe(target))
+	assert.NoFileExists(t, target)
+
+	assert.NoError(t, Wipe(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,257 @@
+package dbproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditRecord is a statement run through the proxy
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Socket    string    `json:"socket,omitempty"`
+	Identity  string    `json:"identity"`
+	Database  string    `json:"database,omitempty"`
+	Statement string    `json:"statement"`
+	Duration  float64   `json:"duration_ms"`
+	Rows      int64     `json:"rows"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditSink receives the audit records of the proxied connections
+type AuditSink interface {
+	Write(record AuditRecord)
+}
+
+// NewAuditSink returns the sink for the kind, log writes records to the
+// connector log, stdout writes them as json lines to stdout and file appends
+// them as json lines to the file at path
+func NewAuditSink(logger *zap.Logger, kind, path string) (AuditSink, error) {
+	switch kind {
+	case "", "log":
+		return &logAuditSink{logger: logger}, nil
+	case "stdout":
+		return &jsonAuditSink{w: os.Stdout}, nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("the file audit sink requires a file")
+		}
+		return fileAuditSink(path)
+	default:
+		return nil, fmt.Errorf("unsupported audit sink %q, supported sinks are log, stdout and file", kind)
+	}
+}
+
+type logAuditSink struct {
+	logger *zap.Logger
+}
+
+func (s *logAuditSink) Write(record AuditRecord) {
+	fields := []zap.Field{
+		zap.Time("time", record.Time),
+		zap.String("socket", record.Socket),
+		zap.String("identity", record.Identity),
+		zap.String("database", record.Database),
+		zap.String("statement", record.Statement),
+		zap.Float64("duration_ms", record.Duration),
+		zap.Int64("rows", record.Rows),
+	}
+	if record.Error != "" {
+		fields = append(fields, zap.String("error", record.Error))
+	}
+
+	s.logger.Info("database query", fields...)
+}
+
+type jsonAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *jsonAuditSink) Write(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.Write(append(line, '\n'))
+}
+
+var (
+	fileSinksMu sync.Mutex
+	fileSinks   = map[string]*jsonAuditSink{}
+)
+
+// fileAuditSink returns the sink of the file, the file is opened once and shared
+// by all sockets auditing to it
+func fileAuditSink(path string) (AuditSink, error) {
+	fileSinksMu.Lock()
+	defer fileSinksMu.Unlock()
+
+	if sink, ok := fileSinks[path]; ok {
+		return sink, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+
+	sink := &jsonAuditSink{w: f}
+	fileSinks[path] = sink
+	return sink, nil
+}
+
+// auditor builds the audit records of a connection
+type auditor struct {
+	sink     AuditSink
+	redact   bool
+	dialect  string
+	socket   string
+	identity string
+	database string
+}
+
+func (a *auditor) write(statement string, start time.Time, rows int64, err string) {
+	if a.redact {
+		statement = RedactLiterals(statement, a.dialect)
+	}
+
+	a.sink.Write(AuditRecord{
+		Time:      start,
+		Socket:    a.socket,
+		Identity:  a.identity,
+		Database:  a.database,
+		Statement: statement,
+		Duration:  float64(time.Since(start).Microseconds()) / 1000,
+		Rows:      rows,
+		Error:     err,
+	})
+}
+
+// RedactLiterals replaces the string and numeric literals of a statement with ?,
+// the dialect decides how quotes are read, in mysql double quotes are strings
+// and backslashes escape, in postgres double quotes are identifiers and dollar
+// quoted strings are literals
+func RedactLiterals(statement, dialect string) string {
+	mysql := dialect == "mysql"
+
+	var b strings.Builder
+	b.Grow(len(statement))
+
+	for i := 0; i < len(statement); {
+		c := statement[i]
+
+		switch {
+		case c == '\'' || (c == '"' && mysql):
+			i = skipQuoted(statement, i, c, mysql)
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			end := skipQuoted(statement, i, c, false)
+			b.WriteString(statement[i:end])
+			i = end
+		case c == '$' && !mysql && (i == 0 || !isIdentChar(statement[i-1])):
+			if end, ok := skipDollarQuoted(statement, i); ok {
+				b.WriteByte('?')
+				i = end
+				continue
+			}
+			// a positional parameter like $1
+			end := i + 1
+			for end < len(statement) && isDigit(statement[end]) {
+				end++
+			}
+			b.WriteString(statement[i:end])
+			i = end
+		case isDigit(c) && (i == 0 || !isIdentChar(statement[i-1])):
+			i = skipNumber(statement, i)
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipQuoted returns the index after the quoted string starting at i, doubled
+// quotes are escaped quotes
+func skipQuoted(s string, i int, quote byte, backslash bool) int {
+	for i++; i < len(s); i++ {
+		switch {
+		case backslash && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return len(s)
+}
+
+func skipDollarQuoted(s string, i int) (int, bool) {
+	end := strings.IndexByte(s[i+1:], '$')
+	if end < 0 {
+		return 0, false
+	}
+
+	tag := s[i : i+end+2]
+	for j := 1; j < len(tag)-1; j++ {
+		if tag[j] == '$' || !isIdentChar(tag[j]) || (j == 1 && isDigit(tag[j])) {
+			return 0, false
+		}
+	}
+
+	closing := strings.Index(s[i+len(tag):], tag)
+	if closing < 0 {
+		return len(s), true
+	}
+
+	return i + len(tag) + closing + len(tag), true
+}
+
+func skipNumber(s string, i int) int {
+	if s[i] == '0' && i+1 < len(s) && (s[i+1] == 'x' || s[i+1] == 'X') {
+		for i += 2; i < len(s) && isHexDigit(s[i]); i++ {
+		}
+		return i
+	}
+
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isDigit(c) || c == '.':
+		case (c == 'e' || c == 'E') && i+1 < len(s) && (isDigit(s[i+1]) || s[i+1] == '-' || s[i+1] == '+'):
+			i++
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,52 @@
+package dbproxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactLiterals(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		dialect   string
+		want      string
+	}{
+		{
+			name:      "strings_and_numbers",
+			statement: "SELECT * FROM users WHERE email = 'bob@example.com' AND age > 42 AND score < 1.5e3",
+			dialect:   "postgres",
+			want:      "SELECT * FROM users WHERE email = ? AND age > ? AND score < ?",
+		},
+		{
+			name:      "identifiers_with_digits",
+			statement: `SELECT col1, "Table2".x FROM t3 LIMIT 10`,
+			dialect:   "postgres",
+			want:      `SELECT col1, "Table2".x FROM t3 LIMIT ?`,
+		},
+		{
+			name:      "postgres_escaped_quote_and_dollar_quoting",
+			statement: "INSERT INTO notes VALUES ('it''s', $body$secret 'text'$body$, $1)",
+			dialect:   "postgres",
+			want:      "INSERT INTO notes VALUES (?, ?, $1)",
+		},
+		{
+			name:      "mysql_double_quotes_and_backslashes",
+			statement: "UPDATE `users` SET name = \"O\\\"Brien\", token = 'a\\'b', flags = 0xFF WHERE id = 7",
+			dialect:   "mysql",
+			want:      "UPDATE `users` SET name = ?, token = ?, flags = ? WHERE id = ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactLiterals(tt.statement, tt.dialect))
+		})
+	}
+}
+
+func TestCommandTagRows(t *testing.T) {
+	assert.Equal(t, int64(5), commandTagRows("INSERT 0 5"))
+	assert.Equal(t, int64(3), commandTagRows("SELECT 3"))
+	assert.Equal(t, int64(0), commandTagRows("CREATE TABLE"))
+}
@@ -0,0 +1,670 @@
+package dbproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// mysql capability flags used by the proxy
+const (
+	mysqlClientConnectWithDB        = 0x00000008
+	mysqlClientCompress             = 0x00000020
+	mysqlClientProtocol41           = 0x00000200
+	mysqlClientSSL                  = 0x00000800
+	mysqlClientSecureConnection     = 0x00008000
+	mysqlClientPluginAuth           = 0x00080000
+	mysqlClientConnectAttrs         = 0x00100000
+	mysqlClientPluginAuthLenencData = 0x00200000
+	mysqlClientDeprecateEOF         = 0x01000000
+)
+
+const (
+	mysqlOK          = 0x00
+	mysqlAuthMore    = 0x01
+	mysqlAuthSwitch  = 0xfe
+	mysqlErr         = 0xff
+	mysqlMaxPacket   = 1<<24 - 1
+	mysqlScrambleLen = 20
+
+	mysqlNativePassword       = "mysql_native_password"
+	mysqlCachingSha2Password  = "caching_sha2_password"
+	mysqlSha256Password       = "sha256_password"
+	mysqlClearPassword        = "mysql_clear_password"
+	mysqlCachingSha2FastAuth  = 0x03
+	mysqlCachingSha2FullAuth  = 0x04
+	mysqlRequestPublicKeyByte = 0x02
+)
+
+// mysqlConn reads and writes mysql packets, keeping track of the sequence id
+type mysqlConn struct {
+	net.Conn
+	r   *bufio.Reader
+	seq byte
+}
+
+func newMysqlConn(conn net.Conn) *mysqlConn {
+	return &mysqlConn{Conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *mysqlConn) readPacket() ([]byte, error) {
+	packet, err := c.readRawPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	return packet[4:], nil
+}
+
+// readRawPacket returns the packet including its header
+func (c *mysqlConn) readRawPacket() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	c.seq = header[3] + 1
+
+	packet := make([]byte, 4+length)
+	copy(packet, header[:])
+	if _, err := io.ReadFull(c.r, packet[4:]); err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+func (c *mysqlConn) writePacket(payload []byte) error {
+	if len(payload) >= mysqlMaxPacket {
+		return errors.New("mysql handshake packet too large")
+	}
+
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), c.seq}
+	c.seq++
+
+	_, err := c.Write(append(header, payload...))
+	return err
+}
+
+// upgradeTLS switches the connection to tls, the reader must not hold buffered data
+func (c *mysqlConn) upgradeTLS(cfg *tls.Config) error {
+	tlsConn := tls.Client(c.Conn, cfg)
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("upstream tls handshake failed: %w", err)
+	}
+
+	c.Conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *mysqlConn) buffered() net.Conn {
+	return &bufferedConn{Conn: c.Conn, r: c.r}
+}
+
+// mysqlHandshake is the initial handshake packet (protocol version 10) of a server
+type mysqlHandshake struct {
+	serverVersion string
+	connectionID  uint32
+	scramble      []byte
+	capabilities  uint32
+	charset       byte
+	status        uint16
+	authPlugin    string
+}
+
+func parseMysqlHandshake(data []byte) (*mysqlHandshake, error) {
+	if len(data) == 0 || data[0] != 10 {
+		return nil, errors.New("unsupported mysql protocol version")
+	}
+
+	r := bytes.NewBuffer(data[1:])
+	h := &mysqlHandshake{}
+
+	version, err := r.ReadBytes(0)
+	if err != nil {
+		return nil, errors.New("malformed mysql handshake")
+	}
+	h.serverVersion = string(version[:len(version)-1])
+
+	if r.Len() < 4+8+1+2 {
+		return nil, errors.New("malformed mysql handshake")
+	}
+	h.connectionID = binary.LittleEndian.Uint32(r.Next(4))
+	h.scramble = append([]byte{}, r.Next(8)...)
+	r.Next(1)
+	h.capabilities = uint32(binary.LittleEndian.Uint16(r.Next(2)))
+
+	if r.Len() >= 1+2+2+1+10 {
+		h.charset = r.Next(1)[0]
+		h.status = binary.LittleEndian.Uint16(r.Next(2))
+		h.capabilities |= uint32(binary.LittleEndian.Uint16(r.Next(2))) << 16
+		authDataLen := int(r.Next(1)[0])
+		r.Next(10)
+
+		if h.capabilities&mysqlClientSecureConnection != 0 {
+			n := authDataLen - 8
+			if n < 13 {
+				n = 13
+			}
+			h.scramble = append(h.scramble, r.Next(n)...)
+		}
+
+		if h.capabilities&mysqlClientPluginAuth != 0 {
+			h.authPlugin = string(bytes.TrimRight(r.Bytes(), "\x00"))
+		}
+	}
+
+	if len(h.scramble) > mysqlScrambleLen {
+		h.scramble = h.scramble[:mysqlScrambleLen]
+	}
+
+	return h, nil
+}
+
+func (h *mysqlHandshake) encode() []byte {
+	var b bytes.Buffer
+	b.WriteByte(10)
+	b.WriteString(h.serverVersion)
+	b.WriteByte(0)
+	binary.Write(&b, binary.LittleEndian, h.connectionID)
+	b.Write(h.scramble[:8])
+	b.WriteByte(0)
+	binary.Write(&b, binary.LittleEndian, uint16(h.capabilities))
+	b.WriteByte(h.charset)
+	binary.Write(&b, binary.LittleEndian, h.status)
+	binary.Write(&b, binary.LittleEndian, uint16(h.capabilities>>16))
+	b.WriteByte(byte(len(h.scramble) + 1))
+	b.Write(make([]byte, 10))
+	b.Write(h.scramble[8:])
+	b.WriteByte(0)
+	b.WriteString(h.authPlugin)
+	b.WriteByte(0)
+
+	return b.Bytes()
+}
+
+// mysqlHandshakeResponse is the protocol 4.1 handshake response of a client
+type mysqlHandshakeResponse struct {
+	capabilities  uint32
+	maxPacketSize uint32
+	charset       byte
+	username      string
+	authResponse  []byte
+	database      string
+	authPlugin    string
+}
+
+func parseMysqlHandshakeResponse(data []byte) (*mysqlHandshakeResponse, error) {
+	if len(data) < 32 {
+		return nil, errors.New("malformed mysql handshake response")
+	}
+
+	resp := &mysqlHandshakeResponse{
+		capabilities:  binary.LittleEndian.Uint32(data[0:4]),
+		maxPacketSize: binary.LittleEndian.Uint32(data[4:8]),
+		charset:       data[8],
+	}
+
+	if resp.capabilities&mysqlClientProtocol41 == 0 {
+		return nil, errors.New("mysql clients without protocol 4.1 support are not supported")
+	}
+
+	if resp.capabilities&mysqlClientSSL != 0 && len(data) == 32 {
+		return nil, errors.New("client requested tls, which is not offered by the proxy")
+	}
+
+	r := bytes.NewBuffer(data[32:])
+
+	username, err := r.ReadBytes(0)
+	if err != nil {
+		return nil, errors.New("malformed mysql handshake response")
+	}
+	resp.username = string(username[:len(username)-1])
+
+	switch {
+	case resp.capabilities&mysqlClientPluginAuthLenencData != 0:
+		n, err := readLenencInt(r)
+		if err != nil {
+			return nil, err
+		}
+		resp.authResponse = r.Next(int(n))
+	case resp.capabilities&mysqlClientSecureConnection != 0:
+		n, err := r.ReadByte()
+		if err != nil {
+			return nil, errors.New("malformed mysql handshake response")
+		}
+		resp.authResponse = r.Next(int(n))
+	default:
+		auth, _ := r.ReadBytes(0)
+		resp.authResponse = bytes.TrimRight(auth, "\x00")
+	}
+
+	if resp.capabilities&mysqlClientConnectWithDB != 0 {
+		database, _ := r.ReadBytes(0)
+		resp.database = string(bytes.TrimRight(database, "\x00"))
+	}
+
+	if resp.capabilities&mysqlClientPluginAuth != 0 {
+		plugin, _ := r.ReadBytes(0)
+		resp.authPlugin = string(bytes.TrimRight(plugin, "\x00"))
+	}
+
+	return resp, nil
+}
+
+func (resp *mysqlHandshakeResponse) encode() []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, resp.capabilities)
+	binary.Write(&b, binary.LittleEndian, resp.maxPacketSize)
+	b.WriteByte(resp.charset)
+	b.Write(make([]byte, 23))
+	b.WriteString(resp.username)
+	b.WriteByte(0)
+
+	if resp.capabilities&mysqlClientPluginAuthLenencData != 0 {
+		b.Write(lenencInt(uint64(len(resp.authResponse))))
+	} else {
+		b.WriteByte(byte(len(resp.authResponse)))
+	}
+	b.Write(resp.authResponse)
+
+	if resp.capabilities&mysqlClientConnectWithDB != 0 {
+		b.WriteString(resp.database)
+		b.WriteByte(0)
+	}
+
+	if resp.capabilities&mysqlClientPluginAuth != 0 {
+		b.WriteString(resp.authPlugin)
+		b.WriteByte(0)
+	}
+
+	return b.Bytes()
+}
+
+// mysqlHandshake connects to the upstream first so the client gets the
+// upstream's server version, connection id and capabilities. The client's login
+// is accepted as is, and the upstream login uses the configured credentials with
+// the capabilities the client asked for, so both connections speak the same
+// protocol once the handshake is done
+func (p *Proxy) mysqlHandshake(ctx context.Context, conn net.Conn) (*session, error) {
+	upstreamConn, err := p.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		upstreamConn.SetDeadline(deadline)
+		conn.SetDeadline(deadline)
+	}
+
+	client := newMysqlConn(conn)
+	upstream := newMysqlConn(upstreamConn)
+
+	s, err := p.mysqlLogin(client, upstream)
+	if err != nil {
+		upstreamConn.Close()
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Time{})
+	upstream.SetDeadline(time.Time{})
+
+	return s, nil
+}
+
+func (p *Proxy) mysqlLogin(client, upstream *mysqlConn) (*session, error) {
+	data, err := upstream.readPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstream handshake: %w", err)
+	}
+	if len(data) > 0 && data[0] == mysqlErr {
+		client.writePacket(data)
+		return nil, fmt.Errorf("upstream refused connection: %s", mysqlErrorMessage(data))
+	}
+
+	serverHandshake, err := parseMysqlHandshake(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.cfg.TLS != nil && serverHandshake.capabilities&mysqlClientSSL == 0 {
+		err := errors.New("upstream database does not support tls")
+		client.writePacket(mysqlErrorPacket(err))
+		return nil, err
+	}
+
+	// the client's credentials are not checked, so the scramble is only there to
+	// keep the handshake well formed
+	clientHandshake := *serverHandshake
+	clientHandshake.capabilities &^= mysqlClientSSL
+	if p.cfg.Audit != nil {
+		// audited connections are read packet by packet, which requires them uncompressed
+		clientHandshake.capabilities &^= mysqlClientCompress
+	}
+	clientHandshake.authPlugin = mysqlNativePassword
+	clientHandshake.scramble = make([]byte, mysqlScrambleLen)
+	if _, err := rand.Read(clientHandshake.scramble); err != nil {
+		return nil, err
+	}
+
+	if err := client.writePacket(clientHandshake.encode()); err != nil {
+		return nil, fmt.Errorf("failed to send handshake to client: %w", err)
+	}
+
+	data, err = client.readPacket()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client handshake response: %w", err)
+	}
+
+	clientResponse, err := parseMysqlHandshakeResponse(data)
+	if err != nil {
+		client.writePacket(mysqlErrorPacket(err))
+		return nil, err
+	}
+
+	// capabilities that only matter during the handshake are set for the
+	// upstream login, the others are the ones the client negotiated
+	handshakeOnly := uint32(mysqlClientSSL | mysqlClientConnectWithDB | mysqlClientConnectAttrs |
+		mysqlClientPluginAuth | mysqlClientPluginAuthLenencData | mysqlClientSecureConnection)
+
+	upstreamResponse := &mysqlHandshakeResponse{
+		capabilities:  clientResponse.capabilities & serverHandshake.capabilities &^ handshakeOnly,
+		maxPacketSize: clientResponse.maxPacketSize,
+		charset:       clientResponse.charset,
+		username:      p.cfg.Username,
+		database:      clientResponse.database,
+		authPlugin:    serverHandshake.authPlugin,
+	}
+	upstreamResponse.capabilities |= serverHandshake.capabilities & (mysqlClientPluginAuth | mysqlClientPluginAuthLenencData | mysqlClientSecureConnection)
+
+	if upstreamResponse.database == "" {
+		upstreamResponse.database = p.cfg.Database
+	}
+	if upstreamResponse.database != "" {
+		upstreamResponse.capabilities |= mysqlClientConnectWithDB
+	}
+	if upstreamResponse.authPlugin == "" {
+		upstreamResponse.authPlugin = mysqlNativePassword
+	}
+
+	if p.cfg.TLS != nil {
+		upstreamResponse.capabilities |= mysqlClientSSL
+		if err := upstream.writePacket(upstreamResponse.encode()[:32]); err != nil {
+			return nil, fmt.Errorf("failed to request upstream tls: %w", err)
+		}
+		if err := upstream.upgradeTLS(p.tlsConfig()); err != nil {
+			client.writePacket(mysqlErrorPacket(err))
+			return nil, err
+		}
+	}
+
+	upstreamResponse.authResponse, err = p.mysqlAuthResponse(upstreamResponse.authPlugin, serverHandshake.scramble, upstream)
+	if err != nil {
+		client.writePacket(mysqlErrorPacket(err))
+		return nil, err
+	}
+
+	if err := upstream.writePacket(upstreamResponse.encode()); err != nil {
+		return nil, fmt.Errorf("failed to send upstream handshake response: %w", err)
+	}
+
+	scramble := serverHandshake.scramble
+	plugin := upstreamResponse.authPlugin
+	for {
+		data, err := upstream.readPacket()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upstream authentication result: %w", err)
+		}
+		if len(data) == 0 {
+			return nil, errors.New("empty upstream authentication packet")
+		}
+
+		switch data[0] {
+		case mysqlOK:
+			// the client is logged in once it gets the upstream's ok
+			if err := client.writePacket(data); err != nil {
+				return nil, fmt.Errorf("failed to send ok to client: %w", err)
+			}
+			return &session{
+				client:       client.buffered(),
+				upstream:     upstream.buffered(),
+				database:     upstreamResponse.database,
+				capabilities: upstreamResponse.capabilities,
+			}, nil
+		case mysqlErr:
+			client.writePacket(data)
+			return nil, fmt.Errorf("upstream authentication failed: %s", mysqlErrorMessage(data))
+		case mysqlAuthSwitch:
+			parts := bytes.SplitN(data[1:], []byte{0}, 2)
+			plugin = string(parts[0])
+			scramble = nil
+			if len(parts) == 2 {
+				scramble = bytes.TrimRight(parts[1], "\x00")
+			}
+
+			auth, err := p.mysqlAuthResponse(plugin, scramble, upstream)
+			if err != nil {
+				client.writePacket(mysqlErrorPacket(err))
+				return nil, err
+			}
+			if err := upstream.writePacket(auth); err != nil {
+				return nil, err
+			}
+		case mysqlAuthMore:
+			if err := p.mysqlAuthMoreData(plugin, scramble, data[1:], upstream); err != nil {
+				client.writePacket(mysqlErrorPacket(err))
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unexpected upstream authentication packet 0x%02x", data[0])
+		}
+	}
+}
+
+func (p *Proxy) mysqlAuthResponse(plugin string, scramble []byte, upstream *mysqlConn) ([]byte, error) {
+	_, overTLS := upstream.Conn.(*tls.Conn)
+
+	switch plugin {
+	case mysqlNativePassword:
+		return mysqlNativePasswordAuth(scramble, p.cfg.Password), nil
+	case mysqlCachingSha2Password:
+		return mysqlCachingSha2PasswordAuth(scramble, p.cfg.Password), nil
+	case mysqlSha256Password:
+		if p.cfg.Password == "" {
+			return []byte{0}, nil
+		}
+		if overTLS {
+			return append([]byte(p.cfg.Password), 0), nil
+		}
+		// ask for the server's public key, the password is sent encrypted after that
+		return []byte{1}, nil
+	case mysqlClearPassword:
+		if !overTLS {
+			return nil, errors.New("refusing to send the upstream password in clear text without tls")
+		}
+		return append([]byte(p.cfg.Password), 0), nil
+	default:
+		return nil, fmt.Errorf("unsupported upstream authentication plugin %q", plugin)
+	}
+}
+
+// mysqlAuthMoreData handles the extra round trips of the sha256 plugins, where
+// the server asks for the full password, either over tls or rsa encrypted
+func (p *Proxy) mysqlAuthMoreData(plugin string, scramble, data []byte, upstream *mysqlConn) error {
+	_, overTLS := upstream.Conn.(*tls.Conn)
+
+	if plugin == mysqlCachingSha2Password && len(data) == 1 {
+		switch data[0] {
+		case mysqlCachingSha2FastAuth:
+			return nil
+		case mysqlCachingSha2FullAuth:
+			if overTLS {
+				return upstream.writePacket(append([]byte(p.cfg.Password), 0))
+			}
+			return upstream.writePacket([]byte{mysqlRequestPublicKeyByte})
+		}
+	}
+
+	if plugin != mysqlCachingSha2Password && plugin != mysqlSha256Password {
+		return fmt.Errorf("unexpected authentication data for plugin %q", plugin)
+	}
+
+	// anything else is the server's public key
+	encrypted, err := mysqlEncryptPassword(data, scramble, p.cfg.Password)
+	if err != nil {
+		return err
+	}
+
+	return upstream.writePacket(encrypted)
+}
+
+func mysqlNativePasswordAuth(scramble []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+
+	// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+
+	h := sha1.New()
+	h.Write(scramble)
+	h.Write(stage2[:])
+	result := h.Sum(nil)
+
+	for i := range result {
+		result[i] ^= stage1[i]
+	}
+
+	return result
+}
+
+func mysqlCachingSha2PasswordAuth(scramble []byte, password string) []byte {
+	if password == "" {
+		return nil
+	}
+
+	// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+	stage1 := sha256.Sum256([]byte(password))
+	stage2 := sha256.Sum256(stage1[:])
+
+	h := sha256.New()
+	h.Write(stage2[:])
+	h.Write(scramble)
+	result := h.Sum(nil)
+
+	for i := range result {
+		result[i] ^= stage1[i]
+	}
+
+	return result
+}
+
+func mysqlEncryptPassword(publicKey, scramble []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, errors.New("failed to decode upstream public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upstream public key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("upstream public key is not an rsa key")
+	}
+
+	plain := append([]byte(password), 0)
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, rsaKey, plain, nil)
+}
+
+func mysqlErrorPacket(err error) []byte {
+	// ER_ACCESS_DENIED_ERROR with sql state 28000
+	var b bytes.Buffer
+	b.WriteByte(mysqlErr)
+	binary.Write(&b, binary.LittleEndian, uint16(1045))
+	b.WriteString("#28000")
+	b.WriteString(fmt.Sprintf("border0 connector failed to connect to the upstream database: %s", err))
+
+	return b.Bytes()
+}
+
+func mysqlErrorMessage(data []byte) string {
+	if len(data) < 3 {
+		return "unknown error"
+	}
+
+	message := data[3:]
+	if len(message) > 0 && message[0] == '#' && len(message) >= 6 {
+		message = message[6:]
+	}
+
+	return string(message)
+}
+
+func readLenencInt(r *bytes.Buffer) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, errors.New("malformed length encoded integer")
+	}
+
+	var n int
+	switch first {
+	case 0xfc:
+		n = 2
+	case 0xfd:
+		n = 3
+	case 0xfe:
+		n = 8
+	default:
+		return uint64(first), nil
+	}
+
+	if r.Len() < n {
+		return 0, errors.New("malformed length encoded integer")
+	}
+
+	var value uint64
+	for i, b := range r.Next(n) {
+		value |= uint64(b) << (8 * i)
+	}
+
+	return value, nil
+}
+
+func lenencInt(n uint64) []byte {
+	switch {
+	case n < 0xfb:
+		return []byte{byte(n)}
+	case n < 1<<16:
+		return []byte{0xfc, byte(n), byte(n >> 8)}
+	case n < 1<<24:
+		return []byte{0xfd, byte(n), byte(n >> 8), byte(n >> 16)}
+	default:
+		b := make([]byte, 9)
+		b[0] = 0xfe
+		binary.LittleEndian.PutUint64(b[1:], n)
+		return b
+	}
+}
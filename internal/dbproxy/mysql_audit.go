@@ -0,0 +1,239 @@
+package dbproxy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	mysqlComInitDB      = 0x02
+	mysqlComQuery       = 0x03
+	mysqlComStmtPrepare = 0x16
+	mysqlComStmtExecute = 0x17
+	mysqlComStmtClose   = 0x19
+
+	mysqlEOF                     = 0xfe
+	mysqlLocalInfile             = 0xfb
+	mysqlServerMoreResultsExists = 0x0008
+)
+
+// states of the response to an audited command
+const (
+	mysqlStateResult = iota
+	mysqlStateColumns
+	mysqlStateColumnsEOF
+	mysqlStateRows
+	mysqlStateInfile
+)
+
+// mysqlAuditor follows the commands of a connection and the responses to them,
+// mysql is strictly request response, so a response belongs to the last command
+type mysqlAuditor struct {
+	*auditor
+	deprecateEOF bool
+
+	mu         sync.Mutex
+	command    *mysqlCommand
+	preparing  bool
+	prepare    string
+	statements map[uint32]string
+}
+
+type mysqlCommand struct {
+	statement string
+	start     time.Time
+	state     int
+	columns   uint64
+	rows      int64
+}
+
+func (p *Proxy) mysqlAudit(s *session, a *auditor) {
+	m := &mysqlAuditor{
+		auditor:      a,
+		deprecateEOF: s.capabilities&mysqlClientDeprecateEOF != 0,
+		statements:   map[uint32]string{},
+	}
+
+	client := newMysqlConn(s.client)
+	upstream := newMysqlConn(s.upstream)
+
+	relay(s.client, s.upstream,
+		func() { m.copy(s.upstream, client, m.fromClient) },
+		func() { m.copy(s.client, upstream, m.fromUpstream) },
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.command != nil {
+		m.finish("connection closed")
+	}
+}
+
+// copy forwards the packets as they are, the first packet of a payload split
+// over several packets is inspected
+func (m *mysqlAuditor) copy(dst io.Writer, src *mysqlConn, inspect func(payload []byte)) {
+	continued := false
+	for {
+		packet, err := src.readRawPacket()
+		if err != nil {
+			return
+		}
+
+		if !continued {
+			inspect(packet[4:])
+		}
+		continued = len(packet)-4 == mysqlMaxPacket
+
+		if _, err := dst.Write(packet); err != nil {
+			return
+		}
+	}
+}
+
+func (m *mysqlAuditor) fromClient(payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.command != nil {
+		if m.command.state == mysqlStateInfile {
+			// the contents of a LOAD DATA LOCAL INFILE
+			return
+		}
+		m.finish("")
+	}
+
+	if len(payload) == 0 {
+		return
+	}
+
+	switch payload[0] {
+	case mysqlComQuery:
+		m.command = &mysqlCommand{statement: string(payload[1:]), start: time.Now()}
+	case mysqlComStmtPrepare:
+		m.preparing = true
+		m.prepare = string(payload[1:])
+	case mysqlComStmtExecute:
+		if len(payload) < 5 {
+			return
+		}
+		statement, ok := m.statements[binary.LittleEndian.Uint32(payload[1:5])]
+		if !ok {
+			statement = "unknown prepared statement"
+		}
+		m.command = &mysqlCommand{statement: statement, start: time.Now()}
+	case mysqlComStmtClose:
+		if len(payload) >= 5 {
+			delete(m.statements, binary.LittleEndian.Uint32(payload[1:5]))
+		}
+	case mysqlComInitDB:
+		m.database = string(payload[1:])
+	}
+}
+
+func (m *mysqlAuditor) fromUpstream(payload []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(payload) == 0 {
+		return
+	}
+
+	if m.preparing {
+		// the first packet of the prepare response holds the statement id
+		m.preparing = false
+		if payload[0] == mysqlOK && len(payload) >= 5 {
+			m.statements[binary.LittleEndian.Uint32(payload[1:5])] = m.prepare
+		}
+		return
+	}
+
+	c := m.command
+	if c == nil {
+		return
+	}
+
+	switch c.state {
+	case mysqlStateResult, mysqlStateInfile:
+		switch payload[0] {
+		case mysqlOK:
+			affectedRows, status := parseMysqlOK(payload)
+			c.rows += int64(affectedRows)
+			m.endResult(status)
+		case mysqlErr:
+			m.finish(mysqlErrorMessage(payload))
+		case mysqlLocalInfile:
+			c.state = mysqlStateInfile
+		default:
+			columns, err := readLenencInt(bytes.NewBuffer(payload))
+			if err != nil || columns == 0 {
+				return
+			}
+			c.columns = columns
+			c.state = mysqlStateColumns
+		}
+	case mysqlStateColumns:
+		c.columns--
+		if c.columns == 0 {
+			c.state = mysqlStateColumnsEOF
+			if m.deprecateEOF {
+				c.state = mysqlStateRows
+			}
+		}
+	case mysqlStateColumnsEOF:
+		c.state = mysqlStateRows
+	case mysqlStateRows:
+		switch {
+		case payload[0] == mysqlErr:
+			m.finish(mysqlErrorMessage(payload))
+		case payload[0] == mysqlEOF && !m.deprecateEOF && len(payload) < 9:
+			var status uint16
+			if len(payload) >= 5 {
+				status = binary.LittleEndian.Uint16(payload[3:5])
+			}
+			m.endResult(status)
+		case payload[0] == mysqlEOF && m.deprecateEOF:
+			// an ok packet ends the rows, rows starting with 0xfe are at least 16MB
+			_, status := parseMysqlOK(payload)
+			m.endResult(status)
+		default:
+			c.rows++
+		}
+	}
+}
+
+// endResult ends a result of the command, the command is done unless the
+// server has more results for it
+func (m *mysqlAuditor) endResult(status uint16) {
+	if status&mysqlServerMoreResultsExists != 0 {
+		m.command.state = mysqlStateResult
+		return
+	}
+
+	m.finish("")
+}
+
+func (m *mysqlAuditor) finish(err string) {
+	m.write(m.command.statement, m.command.start, m.command.rows, err)
+	m.command = nil
+}
+
+// parseMysqlOK returns the affected rows and the status flags of an ok packet
+func parseMysqlOK(payload []byte) (uint64, uint16) {
+	r := bytes.NewBuffer(payload[1:])
+
+	affectedRows, err := readLenencInt(r)
+	if err != nil {
+		return 0, 0
+	}
+	if _, err := readLenencInt(r); err != nil {
+		return affectedRows, 0
+	}
+	if r.Len() < 2 {
+		return affectedRows, 0
+	}
+
+	return affectedRows, binary.LittleEndian.Uint16(r.Next(2))
+}
@@ -0,0 +1,148 @@
+package dbproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+)
+
+// postgresHandshake accepts the client's startup without authentication, logs in
+// to the upstream with pgconn and replays the upstream's session parameters to
+// the client, after which both connections are in the same state
+func (p *Proxy) postgresHandshake(ctx context.Context, client net.Conn) (*session, error) {
+	backend := pgproto3.NewBackend(pgproto3.NewChunkReader(client), client)
+
+	var startup *pgproto3.StartupMessage
+	for startup == nil {
+		msg, err := backend.ReceiveStartupMessage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive startup message: %w", err)
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.StartupMessage:
+			startup = msg
+		case *pgproto3.SSLRequest, *pgproto3.GSSEncRequest:
+			// the tunnel is already encrypted, tls is only used towards the upstream
+			if _, err := client.Write([]byte("N")); err != nil {
+				return nil, err
+			}
+		case *pgproto3.CancelRequest:
+			return nil, p.postgresCancel(ctx, msg)
+		default:
+			return nil, fmt.Errorf("unexpected startup message %T", msg)
+		}
+	}
+
+	config, err := pgconn.ParseConfig("sslmode=disable")
+	if err != nil {
+		return nil, err
+	}
+	config.Host = p.cfg.Host
+	config.Port = uint16(p.cfg.Port)
+	config.User = p.cfg.Username
+	config.Password = p.cfg.Password
+	config.Database = p.cfg.Database
+	if p.cfg.TLS != nil {
+		config.TLSConfig = p.tlsConfig()
+	}
+	config.Fallbacks = nil
+
+	for k, v := range startup.Parameters {
+		switch k {
+		case "user":
+		case "database":
+			if v != "" {
+				config.Database = v
+			}
+		default:
+			config.RuntimeParams[k] = v
+		}
+	}
+
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		backend.Send(postgresError(err))
+		return nil, fmt.Errorf("failed to connect to upstream database %s: %w", p.address(), err)
+	}
+
+	upstream, err := conn.Hijack()
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+
+	if err := sendPostgresStartup(backend, upstream); err != nil {
+		upstream.Conn.Close()
+		return nil, err
+	}
+
+	return &session{client: client, upstream: upstream.Conn, database: config.Database}, nil
+}
+
+func sendPostgresStartup(backend *pgproto3.Backend, upstream *pgconn.HijackedConn) error {
+	msgs := []pgproto3.BackendMessage{&pgproto3.AuthenticationOk{}}
+
+	names := make([]string, 0, len(upstream.ParameterStatuses))
+	for name := range upstream.ParameterStatuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		msgs = append(msgs, &pgproto3.ParameterStatus{Name: name, Value: upstream.ParameterStatuses[name]})
+	}
+
+	// the client gets the upstream's key, so its cancel requests can be passed on as they are
+	msgs = append(msgs,
+		&pgproto3.BackendKeyData{ProcessID: upstream.PID, SecretKey: upstream.SecretKey},
+		&pgproto3.ReadyForQuery{TxStatus: upstream.TxStatus},
+	)
+
+	for _, msg := range msgs {
+		if err := backend.Send(msg); err != nil {
+			return fmt.Errorf("failed to send startup to client: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// postgresCancel passes a cancel request on to the upstream, postgres accepts
+// cancel requests without tls or authentication
+func (p *Proxy) postgresCancel(ctx context.Context, msg *pgproto3.CancelRequest) error {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(msg.Encode(nil)); err != nil {
+		return fmt.Errorf("failed to send cancel request: %w", err)
+	}
+
+	return nil
+}
+
+func postgresError(err error) *pgproto3.ErrorResponse {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &pgproto3.ErrorResponse{
+			Severity: pgErr.Severity,
+			Code:     pgErr.Code,
+			Message:  pgErr.Message,
+			Detail:   pgErr.Detail,
+			Hint:     pgErr.Hint,
+		}
+	}
+
+	return &pgproto3.ErrorResponse{
+		Severity: "FATAL",
+		Code:     "08006",
+		Message:  fmt.Sprintf("border0 connector failed to connect to the upstream database: %s", err),
+	}
+}
@@ -0,0 +1,213 @@
+package dbproxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgproto3/v2"
+)
+
+// postgresAuditor follows the queries of a connection and the results of them.
+// Extended protocol executes are queued until their results come in, statements
+// skipped after an error are dropped at the next ReadyForQuery
+type postgresAuditor struct {
+	*auditor
+
+	mu         sync.Mutex
+	queue      []*postgresQuery
+	statements map[string]string
+	portals    map[string]string
+}
+
+type postgresQuery struct {
+	statement string
+	start     time.Time
+	// simple queries are done at ReadyForQuery and may run several statements
+	simple bool
+	// sync marks the end of an extended protocol pipeline
+	sync     bool
+	rows     int64
+	dataRows int64
+	err      string
+}
+
+func (p *Proxy) postgresAudit(s *session, a *auditor) {
+	pa := &postgresAuditor{
+		auditor:    a,
+		statements: map[string]string{},
+		portals:    map[string]string{},
+	}
+
+	relay(s.client, s.upstream,
+		func() { pa.copy(s.upstream, bufio.NewReader(s.client), pa.fromClient) },
+		func() { pa.copy(s.client, bufio.NewReader(s.upstream), pa.fromUpstream) },
+	)
+}
+
+// copy forwards the messages as they are, after inspecting them
+func (pa *postgresAuditor) copy(dst io.Writer, src *bufio.Reader, inspect func(typ byte, body []byte)) {
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(src, header[:]); err != nil {
+			return
+		}
+
+		length := int(binary.BigEndian.Uint32(header[1:]))
+		if length < 4 {
+			return
+		}
+
+		msg := make([]byte, 1+length)
+		copy(msg, header[:])
+		if _, err := io.ReadFull(src, msg[5:]); err != nil {
+			return
+		}
+
+		inspect(msg[0], msg[5:])
+
+		if _, err := dst.Write(msg); err != nil {
+			return
+		}
+	}
+}
+
+func (pa *postgresAuditor) fromClient(typ byte, body []byte) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	switch typ {
+	case 'Q':
+		var msg pgproto3.Query
+		if msg.Decode(body) == nil {
+			pa.queue = append(pa.queue, &postgresQuery{statement: msg.String, start: time.Now(), simple: true})
+		}
+	case 'P':
+		var msg pgproto3.Parse
+		if msg.Decode(body) == nil {
+			pa.statements[msg.Name] = msg.Query
+		}
+	case 'B':
+		var msg pgproto3.Bind
+		if msg.Decode(body) == nil {
+			pa.portals[msg.DestinationPortal] = pa.statements[msg.PreparedStatement]
+		}
+	case 'E':
+		var msg pgproto3.Execute
+		if msg.Decode(body) == nil {
+			pa.queue = append(pa.queue, &postgresQuery{statement: pa.portals[msg.Portal], start: time.Now()})
+		}
+	case 'S':
+		pa.queue = append(pa.queue, &postgresQuery{sync: true})
+	case 'C':
+		var msg pgproto3.Close
+		if msg.Decode(body) == nil {
+			if msg.ObjectType == 'S' {
+				delete(pa.statements, msg.Name)
+			} else {
+				delete(pa.portals, msg.Name)
+			}
+		}
+	}
+}
+
+func (pa *postgresAuditor) fromUpstream(typ byte, body []byte) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+
+	if typ == 'Z' {
+		pa.readyForQuery()
+		return
+	}
+
+	// results belong to the first query that is still running
+	var query *postgresQuery
+	for _, q := range pa.queue {
+		if !q.sync {
+			query = q
+			break
+		}
+	}
+	if query == nil {
+		return
+	}
+
+	switch typ {
+	case 'D':
+		query.dataRows++
+	case 'C':
+		var msg pgproto3.CommandComplete
+		if msg.Decode(body) == nil {
+			query.rows += commandTagRows(string(msg.CommandTag))
+		}
+		if !query.simple {
+			pa.finish(query)
+		}
+	case 's':
+		// an execute with a row limit that has more rows
+		query.rows += query.dataRows
+		pa.finish(query)
+	case 'I':
+		if !query.simple {
+			pa.remove(query)
+		}
+	case 'E':
+		var msg pgproto3.ErrorResponse
+		if msg.Decode(body) == nil {
+			query.err = msg.Message
+		}
+		if !query.simple {
+			pa.finish(query)
+		}
+	}
+}
+
+// readyForQuery ends a simple query or an extended protocol pipeline up to its
+// sync, executes that are still queued were skipped because of an error
+func (pa *postgresAuditor) readyForQuery() {
+	for len(pa.queue) > 0 {
+		query := pa.queue[0]
+		pa.queue = pa.queue[1:]
+
+		if query.simple {
+			pa.write(query.statement, query.start, query.rows, query.err)
+			return
+		}
+		if query.sync {
+			return
+		}
+	}
+}
+
+func (pa *postgresAuditor) finish(query *postgresQuery) {
+	pa.write(query.statement, query.start, query.rows, query.err)
+	pa.remove(query)
+}
+
+func (pa *postgresAuditor) remove(query *postgresQuery) {
+	for i, q := range pa.queue {
+		if q == query {
+			pa.queue = append(pa.queue[:i], pa.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// commandTagRows returns the row count of a command tag like "INSERT 0 5" or "SELECT 3"
+func commandTagRows(tag string) int64 {
+	fields := strings.Fields(tag)
+	if len(fields) < 2 {
+		return 0
+	}
+
+	rows, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return rows
+}
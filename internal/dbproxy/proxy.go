@@ -0,0 +1,223 @@
+package dbproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Config is the upstream database of a socket proxied by the connector, the
+// credentials and certificates never leave the connector
+type Config struct {
+	// Type is the database protocol, mysql or postgres
+	Type     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Database is used when the client doesn't ask for a database
+	Database string
+	// TLS enables TLS to the upstream, with a client certificate for mTLS
+	TLS *tls.Config
+
+	// Name is the socket name used in audit records
+	Name string
+	// Audit receives a record for every statement run through the proxy, nil
+	// disables auditing
+	Audit AuditSink
+	// RedactLiterals replaces the literals of audited statements with ?
+	RedactLiterals bool
+}
+
+// Proxy terminates the database protocol of connections coming in over the
+// tunnel and logs in to the upstream database with the configured credentials,
+// whatever credentials the client presented
+type Proxy struct {
+	logger    *zap.Logger
+	cfg       Config
+	handshake func(ctx context.Context, client net.Conn) (*session, error)
+	audit     func(s *session, a *auditor)
+}
+
+// session is a client connection that is logged in to the upstream
+type session struct {
+	client   net.Conn
+	upstream net.Conn
+	// database is the database the client is connected to
+	database string
+	// capabilities are the negotiated mysql capabilities
+	capabilities uint32
+}
+
+func NewProxy(logger *zap.Logger, cfg Config) (*Proxy, error) {
+	p := &Proxy{logger: logger.With(zap.String("upstream_type", cfg.Type)), cfg: cfg}
+
+	switch cfg.Type {
+	case "mysql":
+		p.handshake = p.mysqlHandshake
+		p.audit = p.mysqlAudit
+	case "postgres":
+		p.handshake = p.postgresHandshake
+		p.audit = p.postgresAudit
+	default:
+		return nil, fmt.Errorf("unsupported database type %q, supported types are mysql and postgres", cfg.Type)
+	}
+
+	return p, nil
+}
+
+// HandleConn serves a client connection until either side closes it, the
+// identity is the client certificate's identity used in audit records
+func (p *Proxy) HandleConn(conn net.Conn, identity string) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	s, err := p.handshake(ctx, conn)
+	cancel()
+	if err != nil {
+		p.logger.Error("database proxy handshake failed", zap.Error(err))
+		return
+	}
+	if s == nil {
+		return
+	}
+	defer s.upstream.Close()
+
+	if p.cfg.Audit == nil {
+		pipe(s.client, s.upstream)
+		return
+	}
+
+	p.audit(s, &auditor{
+		sink:     p.cfg.Audit,
+		redact:   p.cfg.RedactLiterals,
+		dialect:  p.cfg.Type,
+		socket:   p.cfg.Name,
+		identity: identity,
+		database: s.database,
+	})
+}
+
+func (p *Proxy) address() string {
+	return net.JoinHostPort(p.cfg.Host, fmt.Sprint(p.cfg.Port))
+}
+
+func (p *Proxy) dial(ctx context.Context) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to upstream database %s: %w", p.address(), err)
+	}
+
+	return conn, nil
+}
+
+func (p *Proxy) tlsConfig() *tls.Config {
+	cfg := p.cfg.TLS.Clone()
+	if cfg.ServerName == "" {
+		cfg.ServerName = p.cfg.Host
+	}
+
+	return cfg
+}
+
+// NewTLSConfig returns the tls config for upstream connections, the certificate
+// and key are the client certificate for mTLS and the ca verifies the upstream
+func NewTLSConfig(certFile, keyFile, caFile, serverName string, insecureSkipVerify bool) (*tls.Config, error) {
+	var cert, key, ca []byte
+	var err error
+	if certFile != "" {
+		if cert, err = os.ReadFile(certFile); err != nil {
+			return nil, fmt.Errorf("failed to read upstream client certificate: %w", err)
+		}
+	}
+	if keyFile != "" {
+		if key, err = os.ReadFile(keyFile); err != nil {
+			return nil, fmt.Errorf("failed to read upstream client key: %w", err)
+		}
+	}
+	if caFile != "" {
+		if ca, err = os.ReadFile(caFile); err != nil {
+			return nil, fmt.Errorf("failed to read upstream ca certificate: %w", err)
+		}
+	}
+
+	return NewTLSConfigFromPEM(cert, key, ca, serverName, insecureSkipVerify)
+}
+
+// NewTLSConfigFromPEM is NewTLSConfig with the PEM encoded certificates instead of files
+func NewTLSConfigFromPEM(cert, key, ca []byte, serverName string, insecureSkipVerify bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+
+	if len(cert) > 0 || len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load upstream client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	if len(ca) > 0 {
+		cfg.RootCAs = x509.NewCertPool()
+		if ok := cfg.RootCAs.AppendCertsFromPEM(ca); !ok {
+			return nil, fmt.Errorf("failed to parse upstream ca certificate")
+		}
+	}
+
+	return cfg, nil
+}
+
+func pipe(client, upstream net.Conn) {
+	relay(client, upstream,
+		func() { io.Copy(upstream, client) },
+		func() { io.Copy(client, upstream) },
+	)
+}
+
+// relay runs both directions of a connection and closes both sides as soon as
+// one of the directions is done
+func relay(client, upstream net.Conn, toUpstream, toClient func()) {
+	var once sync.Once
+	closeBoth := func() {
+		client.Close()
+		upstream.Close()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		toUpstream()
+		once.Do(closeBoth)
+	}()
+	go func() {
+		defer wg.Done()
+		toClient()
+		once.Do(closeBoth)
+	}()
+	wg.Wait()
+}
+
+// bufferedConn reads through the reader used during the handshake, so no
+// buffered bytes get lost when the connection is handed over to the pipe
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,276 @@
+package dbproxy
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) Write(record AuditRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+func (s *memoryAuditSink) Records() []AuditRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records
+}
+
+// fakeUpstream accepts a single connection and serves it with handle
+func fakeUpstream(t *testing.T, handle func(conn net.Conn)) (string, int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestProxy_mysql(t *testing.T) {
+	scramble := []byte("abcdefghijklmnopqrst")
+	capabilities := uint32(mysqlClientProtocol41 | mysqlClientSecureConnection | mysqlClientPluginAuth | mysqlClientConnectWithDB)
+
+	tests := []struct {
+		name     string
+		plugin   string
+		password string
+		wantOK   bool
+	}{
+		{name: "native_password", plugin: mysqlNativePassword, password: "secret", wantOK: true},
+		{name: "caching_sha2_password", plugin: mysqlCachingSha2Password, password: "secret", wantOK: true},
+		{name: "wrong_password", plugin: mysqlNativePassword, password: "wrong", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := fakeUpstream(t, func(conn net.Conn) {
+				server := newMysqlConn(conn)
+				handshake := &mysqlHandshake{
+					serverVersion: "8.0.31",
+					connectionID:  7,
+					scramble:      scramble,
+					capabilities:  capabilities | mysqlClientSSL,
+					charset:       33,
+					status:        2,
+					authPlugin:    tt.plugin,
+				}
+				server.writePacket(handshake.encode())
+
+				data, err := server.readPacket()
+				if err != nil {
+					return
+				}
+				resp, err := parseMysqlHandshakeResponse(data)
+				if err != nil {
+					return
+				}
+
+				want := mysqlNativePasswordAuth(scramble, "secret")
+				if tt.plugin == mysqlCachingSha2Password {
+					want = mysqlCachingSha2PasswordAuth(scramble, "secret")
+				}
+				if resp.username != "app" || resp.database != "shop" || string(resp.authResponse) != string(want) {
+					server.writePacket(mysqlErrorPacket(assert.AnError))
+					return
+				}
+
+				if tt.plugin == mysqlCachingSha2Password {
+					server.writePacket([]byte{mysqlAuthMore, mysqlCachingSha2FastAuth})
+				}
+				server.writePacket([]byte{mysqlOK, 0, 0, 2, 0, 0, 0})
+
+				// an update of two rows
+				if _, err := server.readPacket(); err != nil {
+					return
+				}
+				server.writePacket([]byte{mysqlOK, 2, 0, 2, 0, 0, 0})
+			})
+
+			sink := &memoryAuditSink{}
+			proxy, err := NewProxy(zap.NewNop(), Config{
+				Type:           "mysql",
+				Host:           host,
+				Port:           port,
+				Username:       "app",
+				Password:       tt.password,
+				Name:           "shop-db",
+				Audit:          sink,
+				RedactLiterals: true,
+			})
+			require.NoError(t, err)
+
+			conn, proxyConn := net.Pipe()
+			defer conn.Close()
+			go proxy.HandleConn(proxyConn, "alice@example.com")
+
+			client := newMysqlConn(conn)
+			data, err := client.readPacket()
+			require.NoError(t, err)
+
+			handshake, err := parseMysqlHandshake(data)
+			require.NoError(t, err)
+			assert.Equal(t, "8.0.31", handshake.serverVersion)
+			assert.Equal(t, uint32(7), handshake.connectionID)
+			assert.Zero(t, handshake.capabilities&mysqlClientSSL)
+
+			resp := &mysqlHandshakeResponse{
+				capabilities:  capabilities,
+				maxPacketSize: mysqlMaxPacket,
+				charset:       33,
+				username:      "edge",
+				database:      "shop",
+				authPlugin:    mysqlNativePassword,
+			}
+			require.NoError(t, client.writePacket(resp.encode()))
+
+			data, err = client.readPacket()
+			require.NoError(t, err)
+			if !tt.wantOK {
+				assert.Equal(t, byte(mysqlErr), data[0])
+				return
+			}
+			assert.Equal(t, byte(mysqlOK), data[0])
+
+			client.seq = 0
+			require.NoError(t, client.writePacket(append([]byte{mysqlComQuery}, "update orders set state = 'paid' where id = 5"...)))
+			data, err = client.readPacket()
+			require.NoError(t, err)
+			assert.Equal(t, byte(mysqlOK), data[0])
+
+			records := sink.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, "shop-db", records[0].Socket)
+			assert.Equal(t, "alice@example.com", records[0].Identity)
+			assert.Equal(t, "shop", records[0].Database)
+			assert.Equal(t, "update orders set state = ? where id = ?", records[0].Statement)
+			assert.Equal(t, int64(2), records[0].Rows)
+			assert.Empty(t, records[0].Error)
+		})
+	}
+}
+
+func TestProxy_postgres(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantOK   bool
+	}{
+		{name: "login", password: "secret", wantOK: true},
+		{name: "wrong_password", password: "wrong", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := fakeUpstream(t, func(conn net.Conn) {
+				backend := pgproto3.NewBackend(pgproto3.NewChunkReader(conn), conn)
+				msg, err := backend.ReceiveStartupMessage()
+				if err != nil {
+					return
+				}
+				startup, ok := msg.(*pgproto3.StartupMessage)
+				if !ok || startup.Parameters["user"] != "app" || startup.Parameters["database"] != "shop" || startup.Parameters["application_name"] != "psql" {
+					backend.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28000", Message: "unexpected startup"})
+					return
+				}
+
+				backend.Send(&pgproto3.AuthenticationCleartextPassword{})
+				backend.SetAuthType(pgproto3.AuthTypeCleartextPassword)
+				msg, err = backend.Receive()
+				if err != nil {
+					return
+				}
+				if password, ok := msg.(*pgproto3.PasswordMessage); !ok || password.Password != "secret" {
+					backend.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28P01", Message: "password authentication failed"})
+					return
+				}
+
+				backend.Send(&pgproto3.AuthenticationOk{})
+				backend.Send(&pgproto3.ParameterStatus{Name: "server_version", Value: "14.5"})
+				backend.Send(&pgproto3.BackendKeyData{ProcessID: 42, SecretKey: 1234})
+				backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+
+				if _, err := backend.Receive(); err != nil {
+					return
+				}
+				backend.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")})
+				backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			})
+
+			sink := &memoryAuditSink{}
+			proxy, err := NewProxy(zap.NewNop(), Config{Type: "postgres", Host: host, Port: port, Username: "app", Password: tt.password, Audit: sink})
+			require.NoError(t, err)
+
+			conn, proxyConn := net.Pipe()
+			defer conn.Close()
+			go proxy.HandleConn(proxyConn, "alice@example.com")
+
+			frontend := pgproto3.NewFrontend(pgproto3.NewChunkReader(conn), conn)
+			require.NoError(t, frontend.Send(&pgproto3.SSLRequest{}))
+			reply := make([]byte, 1)
+			_, err = conn.Read(reply)
+			require.NoError(t, err)
+			assert.Equal(t, "N", string(reply))
+
+			require.NoError(t, frontend.Send(&pgproto3.StartupMessage{
+				ProtocolVersion: pgproto3.ProtocolVersionNumber,
+				Parameters:      map[string]string{"user": "edge", "database": "shop", "application_name": "psql"},
+			}))
+
+			msg, err := frontend.Receive()
+			require.NoError(t, err)
+			if !tt.wantOK {
+				assert.IsType(t, &pgproto3.ErrorResponse{}, msg)
+				return
+			}
+			assert.IsType(t, &pgproto3.AuthenticationOk{}, msg)
+
+			var keyData *pgproto3.BackendKeyData
+			for {
+				msg, err := frontend.Receive()
+				require.NoError(t, err)
+				if data, ok := msg.(*pgproto3.BackendKeyData); ok {
+					keyData = data
+				}
+				if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+					break
+				}
+			}
+			require.NotNil(t, keyData)
+			assert.Equal(t, uint32(42), keyData.ProcessID)
+
+			require.NoError(t, frontend.Send(&pgproto3.Query{String: "select 1"}))
+			msg, err = frontend.Receive()
+			require.NoError(t, err)
+			assert.Equal(t, &pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")}, msg)
+			msg, err = frontend.Receive()
+			require.NoError(t, err)
+			assert.IsType(t, &pgproto3.ReadyForQuery{}, msg)
+
+			records := sink.Records()
+			require.Len(t, records, 1)
+			assert.Equal(t, "alice@example.com", records[0].Identity)
+			assert.Equal(t, "shop", records[0].Database)
+			assert.Equal(t, "select 1", records[0].Statement)
+			assert.Equal(t, int64(1), records[0].Rows)
+		})
+	}
+}
@@ -9,12 +9,13 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/borderzero/border0-cli/internal/api"
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/credstore"
+	"github.com/borderzero/border0-cli/internal/profile"
 	jwt "github.com/golang-jwt/jwt"
 )
 
@@ -36,6 +37,8 @@ type Client struct {
 func WebUrl() string {
 	if os.Getenv("BORDER0_WEB_URL") != "" {
 		return os.Getenv("BORDER0_WEB_URL")
+	} else if url := profile.Current().WebURL; url != "" {
+		return url
 	} else {
 		return "https://portal.border0.com"
 	}
@@ -46,13 +49,11 @@ func TokenFilePath() string {
 }
 
 func tokenfile() string {
-	tokenfile := ""
+	dir := fmt.Sprintf("%s/.border0", os.Getenv("HOME"))
 	if runtime.GOOS == "windows" {
-		tokenfile = fmt.Sprintf("%s/.border0/token", os.Getenv("APPDATA"))
-	} else {
-		tokenfile = fmt.Sprintf("%s/.border0/token", os.Getenv("HOME"))
+		dir = fmt.Sprintf("%s/.border0", os.Getenv("APPDATA"))
 	}
-	return tokenfile
+	return profile.Path(dir, "token")
 }
 
 func NewClient() (*Client, error) {
@@ -183,25 +184,7 @@ func RefreshLogin() (string, error) {
 		return "", err
 	}
 
-	// create dir if not exists
-	configPath := filepath.Dir(tokenfile())
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		if err := os.Mkdir(configPath, 0700); err != nil {
-			return "", fmt.Errorf("failed to create directory %s : %s", configPath, err)
-		}
-	}
-
-	f, err := os.Create(tokenfile())
-	if err != nil {
-		return "", err
-	}
-	if err := os.Chmod(tokenfile(), 0600); err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	_, err = f.WriteString(fmt.Sprintf("%s\n", res.Token))
-	if err != nil {
+	if err := SaveTokenInDisk(res.Token); err != nil {
 		return "", err
 	}
 	return res.Token, nil
@@ -222,22 +205,7 @@ func MFAChallenge(code string) error {
 
 	c.token = res.Token
 
-	f, err := os.Create(tokenfile())
-	if err != nil {
-		return err
-	}
-
-	if err := os.Chmod(tokenfile(), 0600); err != nil {
-		return err
-	}
-
-	defer f.Close()
-	_, err2 := f.WriteString(fmt.Sprintf("%s\n", c.token))
-	if err2 != nil {
-		return err2
-	}
-
-	return nil
+	return SaveTokenInDisk(c.token)
 }
 
 func CreateDeviceAuthorization() (string, error) {
@@ -316,30 +284,12 @@ func Login(email, password string) (bool, error) {
 }
 
 func SaveTokenInDisk(accessToken string) error {
-	// create dir if not exists
-	configPath := filepath.Dir(tokenfile())
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		if err := os.Mkdir(configPath, 0700); err != nil {
-			return fmt.Errorf("failed to create directory %s : %s", configPath, err)
-		}
-	}
-
-	f, err := os.Create(tokenfile())
-	if err != nil {
-		return err
-	}
-
-	if err := os.Chmod(tokenfile(), 0600); err != nil {
-		return err
-	}
-
-	defer f.Close()
-	_, err2 := f.WriteString(fmt.Sprintf("%s\n", accessToken))
-	if err2 != nil {
-		return err2
-	}
+	return credstore.New().Write(tokenfile(), []byte(fmt.Sprintf("%s\n", accessToken)))
+}
 
-	return nil
+// DeleteToken wipes the admin token of the current profile
+func DeleteToken() error {
+	return credstore.New().Delete(tokenfile())
 }
 
 func Register(name, email, password, sshkey string) error {
@@ -455,10 +405,10 @@ func GetToken() (string, error) {
 		return os.Getenv("BORDER0_ADMIN_TOKEN"), nil
 	}
 
-	if _, err := os.Stat(tokenfile()); os.IsNotExist(err) {
+	content, err := credstore.New().Read(tokenfile())
+	if errors.Is(err, credstore.ErrNotFound) {
 		return "", errors.New("please login first (no token found)")
 	}
-	content, err := ioutil.ReadFile(tokenfile())
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,283 @@
+package manifest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+)
+
+// Apply makes the changes of the plan in order, done is called after each change
+func (p *Plan) Apply(client Client, done func(Change)) error {
+	a := &applier{
+		client:    client,
+		current:   p.current,
+		socketIDs: map[string]string{},
+		policyIDs: map[string]string{},
+	}
+	for name, s := range p.current.sockets {
+		a.socketIDs[name] = s.SocketID
+	}
+	for name, policy := range p.current.policies {
+		a.policyIDs[name] = policy.ID
+	}
+
+	for _, change := range p.Changes {
+		if err := a.apply(change); err != nil {
+			return fmt.Errorf("failed to %s %s %s: %w", change.Action, change.Kind, change.Name, err)
+		}
+		if done != nil {
+			done(change)
+		}
+	}
+
+	return nil
+}
+
+type applier struct {
+	client    Client
+	current   *State
+	socketIDs map[string]string
+	policyIDs map[string]string
+}
+
+func (a *applier) apply(c Change) error {
+	switch c.Action {
+	case ActionAttach, ActionDetach:
+		return a.attachment(c)
+	case ActionDelete:
+		return a.delete(c.Kind, c.Name)
+	case ActionReplace:
+		if err := a.delete(c.Kind, c.Name); err != nil {
+			return err
+		}
+		return a.create(c)
+	case ActionCreate:
+		return a.create(c)
+	case ActionUpdate:
+		return a.update(c)
+	}
+	return fmt.Errorf("unknown action %s", c.Action)
+}
+
+func (a *applier) create(c Change) error {
+	switch desired := c.desired.(type) {
+	case Socket:
+		socket := models.Socket{CloudAuthEnabled: true}
+		mergeSocket(&socket, desired)
+		var created models.Socket
+		if err := a.client.Request(http.MethodPost, "socket", &created, socket); err != nil {
+			return err
+		}
+		a.socketIDs[desired.Name] = created.SocketID
+	case Policy:
+		req := models.CreatePolicyRequest{
+			Name:        desired.Name,
+			Description: desired.Description,
+			Orgwide:     desired.OrgWide != nil && *desired.OrgWide,
+		}
+		if desired.PolicyData != nil {
+			req.PolicyData = *desired.PolicyData
+		} else {
+			req.PolicyData.Version = defaultPolicyVersion
+		}
+		if err := a.client.Request(http.MethodPost, "policies", nil, req); err != nil {
+			return err
+		}
+		var created models.Policy
+		if err := a.client.Request(http.MethodGet, "policies/find?name="+url.QueryEscape(desired.Name), &created, nil); err != nil {
+			return err
+		}
+		a.policyIDs[desired.Name] = created.ID
+	case Notification:
+		notification := models.Notification{
+			Name:            desired.Name,
+			Type:            desired.Type,
+			Enabled:         desired.Enabled == nil || *desired.Enabled,
+			Events:          desired.Events,
+			WebhookURL:      desired.WebhookURL,
+			EmailRecipients: desired.EmailRecipients,
+		}
+		return a.client.Request(http.MethodPost, "organizations/notifications", nil, notification)
+	case Domain:
+		domain := models.Domain{Domain: desired.Domain, Default: desired.Default != nil && *desired.Default}
+		return a.client.Request(http.MethodPost, "organizations/customdomains", nil, domain)
+	case IdentityProvider:
+		if err := a.client.Request(http.MethodPost, "organization/identity_provider", nil, identityProviderRequest(desired, nil)); err != nil {
+			return err
+		}
+		if desired.Enabled != nil {
+			return a.setIdentityProviderStatus(desired.Name, desired.Type, *desired.Enabled)
+		}
+	default:
+		return fmt.Errorf("can't create %s", c.Kind)
+	}
+	return nil
+}
+
+func (a *applier) update(c Change) error {
+	switch desired := c.desired.(type) {
+	case Socket:
+		socket := a.current.sockets[desired.Name]
+		mergeSocket(&socket, desired)
+		socket.CloudAuthEnabled = true
+		return a.client.Request(http.MethodPut, "socket/"+a.socketIDs[desired.Name], nil, socket)
+	case Policy:
+		current := a.current.policies[desired.Name]
+		req := models.UpdatePolicyRequest{
+			Name:        &current.Name,
+			Description: &current.Description,
+			PolicyData:  &current.PolicyData,
+		}
+		if desired.Description != "" {
+			req.Description = &desired.Description
+		}
+		if desired.PolicyData != nil {
+			req.PolicyData = desired.PolicyData
+		}
+		return a.client.Request(http.MethodPut, "policy/"+a.policyIDs[desired.Name], nil, req)
+	case Notification:
+		update := models.NotificationUpdate{
+			Enabled:         desired.Enabled,
+			Events:          desired.Events,
+			EmailRecipients: desired.EmailRecipients,
+		}
+		if desired.WebhookURL != "" {
+			update.WebhookURL = &desired.WebhookURL
+		}
+		return a.client.Request(http.MethodPut, "organizations/notifications/"+desired.Name, nil, update)
+	case Domain:
+		domain := models.Domain{Domain: desired.Domain, Default: desired.Default != nil && *desired.Default}
+		return a.client.Request(http.MethodPut, "organizations/customdomains", nil, domain)
+	case IdentityProvider:
+		current, _ := c.current.(IdentityProvider)
+		if current.Type != identityProviderTypeGlobal && hasIdentityProviderChanges(c.Fields) {
+			if err := a.client.Request(http.MethodPatch, "organization/identity_provider", nil, identityProviderRequest(desired, &current)); err != nil {
+				return err
+			}
+		}
+		if desired.Enabled != nil && (current.Enabled == nil || *current.Enabled != *desired.Enabled) {
+			return a.setIdentityProviderStatus(desired.Name, current.Type, *desired.Enabled)
+		}
+	default:
+		return fmt.Errorf("can't update %s", c.Kind)
+	}
+	return nil
+}
+
+func (a *applier) delete(kind, name string) error {
+	switch kind {
+	case KindSocket:
+		return a.client.Request(http.MethodDelete, "socket/"+a.socketIDs[name], nil, nil)
+	case KindPolicy:
+		return a.client.Request(http.MethodDelete, "policy/"+a.policyIDs[name], nil, nil)
+	case KindNotification:
+		return a.client.Request(http.MethodDelete, "organizations/notifications/"+name, nil, nil)
+	case KindDomain:
+		return a.client.Request(http.MethodDelete, "organizations/customdomains", nil, models.Domain{Domain: name})
+	case KindIdentityProvider:
+		return a.client.Request(http.MethodDelete, "organization/identity_provider/"+name, nil, nil)
+	}
+	return fmt.Errorf("can't delete %s", kind)
+}
+
+func (a *applier) attachment(c Change) error {
+	action := "add"
+	if c.Action == ActionDetach {
+		action = "remove"
+	}
+	body := models.AddSocketToPolicyRequest{
+		Actions: []models.PolicyActionUpdateRequest{{
+			ID:     a.socketIDs[c.Socket],
+			Action: action,
+		}},
+	}
+	return a.client.Request(http.MethodPut, "policy/"+a.policyIDs[c.Name]+"/socket", nil, body)
+}
+
+func (a *applier) setIdentityProviderStatus(name, idpType string, enable bool) error {
+	status := struct {
+		Name   string `json:"name"`
+		Global bool   `json:"global"`
+		Enable bool   `json:"enable"`
+	}{
+		Name:   name,
+		Global: idpType == identityProviderTypeGlobal,
+		Enable: enable,
+	}
+	return a.client.Request(http.MethodPut, "organization/identity_provider_status", nil, status)
+}
+
+// mergeSocket sets the fields of the api socket that are set in the manifest
+func mergeSocket(socket *models.Socket, s Socket) {
+	socket.Name = s.Name
+	if s.SocketType != "" {
+		socket.SocketType = s.SocketType
+	}
+	if s.UpstreamType != "" {
+		socket.UpstreamType = s.UpstreamType
+	}
+	if s.Description != "" {
+		socket.Description = s.Description
+	}
+	if s.UpstreamUsername != "" {
+		socket.UpstreamUsername = s.UpstreamUsername
+	}
+	if s.UpstreamPassword != "" {
+		socket.UpstreamPassword = s.UpstreamPassword
+	}
+	if s.UpstreamHttpHostname != "" {
+		socket.UpstreamHttpHostname = s.UpstreamHttpHostname
+	}
+	if s.ConnectorAuthenticationEnabled != nil {
+		socket.ConnectorAuthenticationEnabled = *s.ConnectorAuthenticationEnabled
+	}
+	if s.AllowedEmailAddresses != nil {
+		socket.AllowedEmailAddresses = s.AllowedEmailAddresses
+	}
+	if s.AllowedEmailDomains != nil {
+		socket.AllowedEmailDomains = s.AllowedEmailDomains
+	}
+	if s.Tags != nil {
+		socket.Tags = s.Tags
+	}
+}
+
+// identityProviderRequest builds the body to create or update an identity
+// provider, the configuration of the current provider is kept for fields
+// that aren't set in the manifest
+func identityProviderRequest(desired IdentityProvider, current *IdentityProvider) map[string]interface{} {
+	configuration := map[string]interface{}{}
+	req := map[string]interface{}{
+		"name": desired.Name,
+		"type": desired.Type,
+	}
+	if current != nil {
+		for k, v := range current.Configuration {
+			configuration[k] = v
+		}
+		req["display_name"] = current.DisplayName
+		req["logo_url"] = current.LogoURL
+	}
+	for k, v := range desired.Configuration {
+		configuration[k] = v
+	}
+	if desired.DisplayName != "" {
+		req["display_name"] = desired.DisplayName
+	}
+	if desired.LogoURL != "" {
+		req["logo_url"] = desired.LogoURL
+	}
+	req[identityProviderTypeConfiguration[desired.Type]] = configuration
+	return req
+}
+
+func hasIdentityProviderChanges(fields []FieldChange) bool {
+	for _, f := range fields {
+		if f.Field != "enabled" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// SecretsEnv replaces secrets with env: references
+	SecretsEnv = "env"
+	// SecretsRedact leaves secrets out, apply doesn't change fields that aren't set
+	SecretsRedact = "redact"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// ExportOptions controls what's exported
+type ExportOptions struct {
+	// Secrets is SecretsEnv or SecretsRedact
+	Secrets string
+	// ConnectorSockets includes the sockets created by connectors
+	ConnectorSockets bool
+}
+
+// Export returns the state as a manifest that apply can use. With SecretsEnv
+// the names of the environment variables the secrets should be in are returned
+func (s *State) Export(opts ExportOptions) (*Manifest, []string, error) {
+	if opts.Secrets != SecretsEnv && opts.Secrets != SecretsRedact {
+		return nil, nil, fmt.Errorf("secrets must be %s or %s", SecretsEnv, SecretsRedact)
+	}
+
+	m := &Manifest{}
+	var envVars []string
+	secret := func(value, owner, field string) string {
+		if value == "" || opts.Secrets == SecretsRedact {
+			return ""
+		}
+		name := nonAlphanumeric.ReplaceAllString(strings.ToUpper("BORDER0_"+owner+"_"+field), "_")
+		envVars = append(envVars, name)
+		return envPrefix + name
+	}
+
+	exported := map[string]bool{}
+	for _, socket := range s.Sockets {
+		if s.IsConnectorSocket(socket.Name) && !opts.ConnectorSockets {
+			continue
+		}
+		exported[socket.Name] = true
+		socket.UpstreamPassword = secret(socket.UpstreamPassword, socket.Name, "upstream_password")
+		socket.ConnectorAuthenticationEnabled = omitFalse(socket.ConnectorAuthenticationEnabled)
+		m.Sockets = append(m.Sockets, socket)
+	}
+
+	for _, policy := range s.Policies {
+		// attachments are exported with the sockets, attachments to sockets that
+		// aren't exported stay with the policy so apply --prune keeps them
+		var sockets []string
+		for _, name := range policy.Sockets {
+			if !exported[name] {
+				sockets = append(sockets, name)
+			}
+		}
+		policy.Sockets = sockets
+		policy.OrgWide = omitFalse(policy.OrgWide)
+		m.Policies = append(m.Policies, policy)
+	}
+
+	m.Notifications = append(m.Notifications, s.Notifications...)
+	m.Domains = append(m.Domains, s.Domains...)
+
+	for _, idp := range s.IdentityProviders {
+		if len(idp.Configuration) > 0 {
+			configuration := map[string]interface{}{}
+			for k, v := range idp.Configuration {
+				if value, ok := v.(string); ok && secretFields[k] {
+					if v = secret(value, idp.Name, k); v == "" {
+						continue
+					}
+				}
+				configuration[k] = v
+			}
+			idp.Configuration = configuration
+		}
+		m.IdentityProviders = append(m.IdentityProviders, idp)
+	}
+
+	sort.Slice(m.Sockets, func(i, j int) bool { return m.Sockets[i].Name < m.Sockets[j].Name })
+	sort.Slice(m.Policies, func(i, j int) bool { return m.Policies[i].Name < m.Policies[j].Name })
+	sort.Slice(m.Notifications, func(i, j int) bool { return m.Notifications[i].Name < m.Notifications[j].Name })
+	sort.Slice(m.Domains, func(i, j int) bool { return m.Domains[i].Domain < m.Domains[j].Domain })
+	sort.Slice(m.IdentityProviders, func(i, j int) bool { return m.IdentityProviders[i].Name < m.IdentityProviders[j].Name })
+
+	return m, envVars, nil
+}
+
+// WriteDir writes the manifest to a directory, with one file per kind of object
+func (m *Manifest) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	files := map[string]Manifest{
+		"sockets.yaml":            {Sockets: m.Sockets},
+		"policies.yaml":           {Policies: m.Policies},
+		"notifications.yaml":      {Notifications: m.Notifications},
+		"domains.yaml":            {Domains: m.Domains},
+		"identity_providers.yaml": {IdentityProviders: m.IdentityProviders},
+	}
+	for name, part := range files {
+		path := filepath.Join(dir, name)
+		data, err := part.Marshal()
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			// don't leave objects of an older export behind
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func omitFalse(b *bool) *bool {
+	if b == nil || !*b {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,283 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"sigs.k8s.io/yaml"
+)
+
+// envPrefix marks a secret that is read from an environment variable
+const envPrefix = "env:"
+
+// Manifest describes the sockets, policies and settings of an organization.
+// Fields that are left empty in a manifest are not managed
+type Manifest struct {
+	Sockets           []Socket           `json:"sockets,omitempty"`
+	Policies          []Policy           `json:"policies,omitempty"`
+	Notifications     []Notification     `json:"notifications,omitempty"`
+	Domains           []Domain           `json:"domains,omitempty"`
+	IdentityProviders []IdentityProvider `json:"identity_providers,omitempty"`
+
+	// kinds are the kinds of objects listed in the manifests, even as an empty
+	// list, only these kinds are pruned
+	kinds map[string]bool
+}
+
+type Socket struct {
+	Name                           string            `json:"name"`
+	SocketType                     string            `json:"socket_type,omitempty"`
+	UpstreamType                   string            `json:"upstream_type,omitempty"`
+	Description                    string            `json:"description,omitempty"`
+	UpstreamUsername               string            `json:"upstream_username,omitempty"`
+	UpstreamPassword               string            `json:"upstream_password,omitempty"`
+	UpstreamHttpHostname           string            `json:"upstream_http_hostname,omitempty"`
+	ConnectorAuthenticationEnabled *bool             `json:"connector_authentication_enabled,omitempty"`
+	AllowedEmailAddresses          []string          `json:"allowed_email_addresses,omitempty"`
+	AllowedEmailDomains            []string          `json:"allowed_email_domains,omitempty"`
+	Tags                           map[string]string `json:"tags,omitempty"`
+	// Policies are the names of the policies attached to the socket
+	Policies []string `json:"policies,omitempty"`
+}
+
+type Policy struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	OrgWide     *bool              `json:"org_wide,omitempty"`
+	PolicyData  *models.PolicyData `json:"policy_data,omitempty"`
+	// Sockets are the names of the sockets the policy is attached to
+	Sockets []string `json:"sockets,omitempty"`
+}
+
+type Notification struct {
+	Name            string   `json:"name"`
+	Type            string   `json:"type,omitempty"`
+	Enabled         *bool    `json:"enabled,omitempty"`
+	Events          []string `json:"events,omitempty"`
+	WebhookURL      string   `json:"webhook_url,omitempty"`
+	EmailRecipients []string `json:"email_recipients,omitempty"`
+}
+
+type Domain struct {
+	Domain  string `json:"domain"`
+	Default *bool  `json:"default,omitempty"`
+}
+
+type IdentityProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+	LogoURL     string `json:"logo_url,omitempty"`
+	// Configuration is the provider type specific configuration,
+	// like discovery_url, client_id and client_secret for oidc
+	Configuration map[string]interface{} `json:"configuration,omitempty"`
+}
+
+// secretFields are the fields that hold secrets, they can be env: references
+var secretFields = map[string]bool{
+	"upstream_password": true,
+	"client_secret":     true,
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Load reads the yaml manifests in the given files and directories
+func Load(paths ...string) (*Manifest, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+	sort.Strings(files)
+
+	m := &Manifest{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range documentSeparator.Split(string(data), -1) {
+			if strings.TrimSpace(doc) == "" {
+				continue
+			}
+			var part Manifest
+			if err := yaml.UnmarshalStrict([]byte(doc), &part); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			m.merge(part)
+		}
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Marshal returns the manifest as yaml
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimPrefix(data, []byte("{}\n")), nil
+}
+
+// Validate checks that every object has a name and that names are unique
+func (m *Manifest) Validate() error {
+	checks := []struct {
+		kind  string
+		names []string
+	}{
+		{kind: "socket", names: m.socketNames()},
+		{kind: "policy", names: m.policyNames()},
+		{kind: "notification", names: m.notificationNames()},
+		{kind: "domain", names: m.domainNames()},
+		{kind: "identity provider", names: m.identityProviderNames()},
+	}
+	for _, check := range checks {
+		seen := map[string]bool{}
+		for _, name := range check.names {
+			if name == "" {
+				return fmt.Errorf("%s without a name", check.kind)
+			}
+			if seen[name] {
+				return fmt.Errorf("%s %s is defined more than once", check.kind, name)
+			}
+			seen[name] = true
+		}
+	}
+
+	sockets := map[string]bool{}
+	for _, name := range m.socketNames() {
+		sockets[name] = true
+	}
+	policies := map[string]Policy{}
+	for _, p := range m.Policies {
+		policies[p.Name] = p
+		if p.OrgWide != nil && *p.OrgWide && len(p.Sockets) > 0 {
+			return fmt.Errorf("policy %s is organization wide and can't list sockets", p.Name)
+		}
+	}
+	for _, s := range m.Sockets {
+		for _, name := range s.Policies {
+			if p, ok := policies[name]; ok && p.OrgWide != nil && *p.OrgWide {
+				return fmt.Errorf("socket %s lists policy %s, which is organization wide", s.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Manifest) merge(other Manifest) {
+	if m.kinds == nil {
+		m.kinds = map[string]bool{}
+	}
+	for kind, listed := range map[string]bool{
+		KindSocket:           other.Sockets != nil,
+		KindPolicy:           other.Policies != nil,
+		KindNotification:     other.Notifications != nil,
+		KindDomain:           other.Domains != nil,
+		KindIdentityProvider: other.IdentityProviders != nil,
+	} {
+		if listed {
+			m.kinds[kind] = true
+		}
+	}
+
+	m.Sockets = append(m.Sockets, other.Sockets...)
+	m.Policies = append(m.Policies, other.Policies...)
+	m.Notifications = append(m.Notifications, other.Notifications...)
+	m.Domains = append(m.Domains, other.Domains...)
+	m.IdentityProviders = append(m.IdentityProviders, other.IdentityProviders...)
+}
+
+// manages returns true when the manifests list objects of the kind
+func (m *Manifest) manages(kind string) bool {
+	if m.kinds[kind] {
+		return true
+	}
+	switch kind {
+	case KindSocket:
+		return len(m.Sockets) > 0
+	case KindPolicy:
+		return len(m.Policies) > 0
+	case KindNotification:
+		return len(m.Notifications) > 0
+	case KindDomain:
+		return len(m.Domains) > 0
+	case KindIdentityProvider:
+		return len(m.IdentityProviders) > 0
+	}
+	return false
+}
+
+func (m *Manifest) socketNames() (names []string) {
+	for _, s := range m.Sockets {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func (m *Manifest) policyNames() (names []string) {
+	for _, p := range m.Policies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func (m *Manifest) notificationNames() (names []string) {
+	for _, n := range m.Notifications {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+func (m *Manifest) domainNames() (names []string) {
+	for _, d := range m.Domains {
+		names = append(names, d.Domain)
+	}
+	return names
+}
+
+func (m *Manifest) identityProviderNames() (names []string) {
+	for _, idp := range m.IdentityProviders {
+		names = append(names, idp.Name)
+	}
+	return names
+}
+
+// resolveSecret returns the value of an env: reference, other values are returned as is
+func resolveSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, envPrefix) {
+		return value, nil
+	}
+	name := strings.TrimPrefix(value, envPrefix)
+	secret, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return secret, nil
+}
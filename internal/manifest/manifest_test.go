@@ -0,0 +1,283 @@
+package manifest
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers GET requests from responses and records the other requests
+type fakeClient struct {
+	responses map[string]interface{}
+	requests  []string
+}
+
+func (f *fakeClient) Request(method string, url string, target interface{}, data interface{}) error {
+	if method != http.MethodGet {
+		f.requests = append(f.requests, method+" "+url)
+	}
+	response, ok := f.responses[method+" "+url]
+	if !ok || target == nil {
+		return nil
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, target)
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{responses: map[string]interface{}{
+		"GET socket": []models.Socket{
+			{SocketID: "s1", Name: "prod-db", SocketType: "database", UpstreamType: "postgres", Description: "old"},
+			{SocketID: "s2", Name: "unmanaged", SocketType: "ssh"},
+			{SocketID: "s3", Name: "discovered", SocketType: "ssh", Tags: map[string]string{"name": "discovered", "connector_name": "c1"}},
+			{SocketID: "s4", Name: "web", SocketType: "http"},
+		},
+		"GET policies": []models.Policy{
+			{ID: "p1", Name: "engineering", PolicyData: models.PolicyData{Version: "v1", Action: []string{"ssh", "database"}}, SocketIDs: []string{"s1", "s2"}},
+			{ID: "p2", Name: "everyone", OrgWide: true, PolicyData: models.PolicyData{Version: "v1"}},
+		},
+		"GET organizations/notifications": []models.Notification{
+			{Name: "slack", Type: "webhook", Enabled: true, WebhookURL: "https://hooks.example.com"},
+		},
+		"GET organizations/customdomains":     []models.Domain{{Domain: "example.com", Default: true}},
+		"GET organization/identity_providers": identityProviderList{List: []identityProviderResponse{{Name: "google", Type: "global"}}},
+		"GET policies/find?name=admins":       models.Policy{ID: "p3", Name: "admins"},
+		"POST socket":                         models.Socket{SocketID: "s5", Name: "web"},
+	}}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sockets.yaml"), []byte(`
+sockets:
+  - name: prod-db
+    socket_type: database
+---
+sockets:
+  - name: web
+    socket_type: http
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policies.yml"), []byte(`
+policies:
+  - name: engineering
+    sockets: [prod-db]
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a manifest"), 0600))
+
+	m, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod-db", "web"}, m.socketNames())
+	assert.Equal(t, []string{"engineering"}, m.policyNames())
+
+	assert.True(t, m.manages(KindSocket))
+	assert.False(t, m.manages(KindNotification))
+
+	// an empty list manages the kind, so prune deletes all of them
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notifications.yaml"), []byte("notifications: []\n"), 0600))
+	m, err = Load(dir)
+	require.NoError(t, err)
+	assert.True(t, m.manages(KindNotification))
+	assert.False(t, m.manages(KindDomain))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "typo.yaml"), []byte("sockets:\n  - name: x\n    sockettype: ssh\n"), 0600))
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "typo.yaml")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "typo.yaml"), []byte("sockets:\n  - name: web\n"), 0600))
+	_, err = Load(dir)
+	assert.ErrorContains(t, err, "socket web is defined more than once")
+}
+
+func TestNewPlan(t *testing.T) {
+	t.Setenv("PROD_DB_PASSWORD", "secret")
+	enabled := true
+
+	desired := &Manifest{
+		Sockets: []Socket{
+			{Name: "prod-db", Description: "new", UpstreamPassword: "env:PROD_DB_PASSWORD", Policies: []string{"engineering", "admins"}},
+			{Name: "web", SocketType: "tls"},
+		},
+		Policies: []Policy{
+			{Name: "engineering", PolicyData: &models.PolicyData{Action: []string{"database", "ssh"}}},
+			{Name: "admins"},
+		},
+		IdentityProviders: []IdentityProvider{{Name: "google", Enabled: &enabled}},
+	}
+
+	client := newFakeClient()
+	current, err := Fetch(client)
+	require.NoError(t, err)
+	assert.True(t, current.IsConnectorSocket("discovered"))
+
+	tests := []struct {
+		name  string
+		prune bool
+		want  []string
+	}{
+		{
+			name: "without prune",
+			want: []string{
+				"update identity provider google",
+				"update socket prod-db",
+				"replace socket web",
+				"create policy admins",
+				"attach policy admins prod-db",
+			},
+		},
+		{
+			name:  "with prune",
+			prune: true,
+			want: []string{
+				"update identity provider google",
+				"update socket prod-db",
+				"replace socket web",
+				"create policy admins",
+				"attach policy admins prod-db",
+				"detach policy engineering unmanaged",
+				"delete policy everyone",
+				"delete socket unmanaged",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := NewPlan(desired, current, tt.prune, false)
+			require.NoError(t, err)
+
+			var got []string
+			for _, c := range plan.Changes {
+				got = append(got, strings.TrimSpace(strings.Join([]string{c.Action, c.Kind, c.Name, c.Socket}, " ")))
+			}
+			assert.Equal(t, tt.want, got)
+
+			// the password isn't returned by the api, so only the description changes
+			assert.Equal(t, []FieldChange{{Field: "description", From: "old", To: "new"}}, plan.Changes[1].Fields)
+		})
+	}
+}
+
+func TestNewPlan_RotateSecrets(t *testing.T) {
+	t.Setenv("PROD_DB_PASSWORD", "secret")
+
+	current, err := Fetch(newFakeClient())
+	require.NoError(t, err)
+
+	desired := &Manifest{Sockets: []Socket{{Name: "prod-db", UpstreamPassword: "env:PROD_DB_PASSWORD"}}}
+
+	// the api doesn't return the password, so applying again converges
+	plan, err := NewPlan(desired, current, false, false)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+
+	plan, err = NewPlan(desired, current, false, true)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, []FieldChange{{Field: "upstream_password", Sensitive: true}}, plan.Changes[0].Fields)
+}
+
+func TestNewPlan_Errors(t *testing.T) {
+	current, err := Fetch(newFakeClient())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		desired Manifest
+		wantErr string
+	}{
+		{name: "missing secret", desired: Manifest{Sockets: []Socket{{Name: "prod-db", UpstreamPassword: "env:BORDER0_TEST_MISSING"}}}, wantErr: "BORDER0_TEST_MISSING is not set"},
+		{name: "new socket without type", desired: Manifest{Sockets: []Socket{{Name: "new"}}}, wantErr: "socket new has no socket_type"},
+		{name: "unknown policy", desired: Manifest{Sockets: []Socket{{Name: "web", Policies: []string{"nope"}}}}, wantErr: "policy nope of socket web does not exist"},
+		{name: "org wide attachment", desired: Manifest{Policies: []Policy{{Name: "everyone", Sockets: []string{"web"}}}}, wantErr: "organization wide"},
+		{name: "unknown global provider", desired: Manifest{IdentityProviders: []IdentityProvider{{Name: "gitlab", Type: "global"}}}, wantErr: "does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPlan(&tt.desired, current, false, false)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPlan_Apply(t *testing.T) {
+	client := newFakeClient()
+	current, err := Fetch(client)
+	require.NoError(t, err)
+
+	desired := &Manifest{
+		Sockets:  []Socket{{Name: "web", SocketType: "tls", Policies: []string{"admins"}}},
+		Policies: []Policy{{Name: "admins"}},
+	}
+	plan, err := NewPlan(desired, current, false, false)
+	require.NoError(t, err)
+
+	require.NoError(t, plan.Apply(client, nil))
+	assert.Equal(t, []string{
+		"DELETE socket/s4",
+		"POST socket",
+		"POST policies",
+		"PUT policy/p3/socket",
+	}, client.requests)
+}
+
+func TestState_Export(t *testing.T) {
+	client := newFakeClient()
+	client.responses["GET socket"] = []models.Socket{
+		{SocketID: "s1", Name: "prod-db", SocketType: "database", UpstreamPassword: "secret"},
+		{SocketID: "s3", Name: "discovered", SocketType: "ssh", Tags: map[string]string{"name": "discovered", "connector_name": "c1"}},
+	}
+	client.responses["GET policies"] = []models.Policy{
+		{ID: "p1", Name: "engineering", PolicyData: models.PolicyData{Version: "v1", Action: []string{"ssh", "database"}}, SocketIDs: []string{"s1", "s3"}},
+	}
+	client.responses["GET organization/identity_providers"] = identityProviderList{List: []identityProviderResponse{{Name: "okta", Type: "okta-workforce"}}}
+	client.responses["GET organization/identity_provider/okta"] = identityProviderResponse{
+		Name:                       "okta",
+		Type:                       "okta-workforce",
+		OktaWorkforceConfiguration: map[string]interface{}{"client_id": "id", "client_secret": "shh"},
+	}
+	state, err := Fetch(client)
+	require.NoError(t, err)
+
+	m, envVars, err := state.Export(ExportOptions{Secrets: SecretsEnv})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BORDER0_PROD_DB_UPSTREAM_PASSWORD", "BORDER0_OKTA_CLIENT_SECRET"}, envVars)
+	require.Len(t, m.Sockets, 1)
+	assert.Equal(t, "env:BORDER0_PROD_DB_UPSTREAM_PASSWORD", m.Sockets[0].UpstreamPassword)
+	assert.Equal(t, []string{"engineering"}, m.Sockets[0].Policies)
+	// connector sockets aren't exported, their attachments stay with the policy
+	assert.Equal(t, []string{"discovered"}, m.Policies[0].Sockets)
+	assert.Equal(t, "env:BORDER0_OKTA_CLIENT_SECRET", m.IdentityProviders[0].Configuration["client_secret"])
+
+	m, envVars, err = state.Export(ExportOptions{Secrets: SecretsRedact, ConnectorSockets: true})
+	require.NoError(t, err)
+	assert.Empty(t, envVars)
+	assert.Len(t, m.Sockets, 2)
+	assert.Nil(t, m.Policies[0].Sockets)
+	data, err := m.Marshal()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "shh")
+	assert.NotContains(t, string(data), "upstream_password")
+
+	// an export applied to the same organization changes nothing
+	t.Setenv("BORDER0_PROD_DB_UPSTREAM_PASSWORD", "secret")
+	t.Setenv("BORDER0_OKTA_CLIENT_SECRET", "shh")
+	m, _, err = state.Export(ExportOptions{Secrets: SecretsEnv})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, m.WriteDir(dir))
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	plan, err := NewPlan(loaded, state, true, false)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+}
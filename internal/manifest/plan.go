@@ -0,0 +1,584 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	KindSocket           = "socket"
+	KindPolicy           = "policy"
+	KindNotification     = "notification"
+	KindDomain           = "domain"
+	KindIdentityProvider = "identity provider"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionReplace = "replace"
+	ActionDelete  = "delete"
+	ActionAttach  = "attach"
+	ActionDetach  = "detach"
+)
+
+const defaultPolicyVersion = "v1"
+
+// mergedFields are maps whose keys are managed one by one, other fields are
+// replaced as a whole
+var mergedFields = map[string]bool{
+	"configuration": true,
+}
+
+// relationFields are planned as attach and detach changes
+var relationFields = map[string]bool{
+	"policies": true,
+	"sockets":  true,
+}
+
+// Change is a change to one object of the organization
+type Change struct {
+	Kind   string
+	Name   string
+	Action string
+	// Socket is the socket a policy is attached to or detached from
+	Socket string
+	// Reason is why an object is replaced
+	Reason string
+	Fields []FieldChange
+
+	// desired and current are the manifest objects, desired has the secrets resolved
+	desired interface{}
+	current interface{}
+}
+
+// FieldChange is a field that's updated
+type FieldChange struct {
+	Field     string
+	From      interface{}
+	To        interface{}
+	Sensitive bool
+}
+
+// Plan is the list of changes that make an organization match the manifests
+type Plan struct {
+	Changes []Change
+
+	current       *State
+	rotateSecrets bool
+}
+
+// NewPlan compares the manifest with the current state of the organization.
+// Objects that aren't in the manifest are deleted when prune is set, only for
+// the kinds of objects the manifests list, and except for sockets created by
+// connectors and global identity providers. Secrets the api doesn't return
+// are sent with the other changes of an object, with rotateSecrets they're
+// updated even when nothing else changed
+func NewPlan(desired *Manifest, current *State, prune, rotateSecrets bool) (*Plan, error) {
+	plan := &Plan{current: current, rotateSecrets: rotateSecrets}
+
+	resolved, err := resolve(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	// domains and identity providers first, sockets may use them
+	currentDomains := map[string]Domain{}
+	for _, d := range current.Domains {
+		currentDomains[d.Domain] = d
+	}
+	for _, d := range resolved.Domains {
+		if err := plan.compare(KindDomain, d.Domain, d, currentDomains[d.Domain], currentDomains[d.Domain].Domain != "", ""); err != nil {
+			return nil, err
+		}
+	}
+
+	currentIdps := map[string]IdentityProvider{}
+	for _, idp := range current.IdentityProviders {
+		currentIdps[idp.Name] = idp
+	}
+	for _, idp := range resolved.IdentityProviders {
+		c, exists := currentIdps[idp.Name]
+		if !exists && idp.Type == identityProviderTypeGlobal {
+			return nil, fmt.Errorf("global identity provider %s does not exist", idp.Name)
+		}
+		if exists && c.Type == identityProviderTypeGlobal && (idp.Type != "" && idp.Type != c.Type || idp.DisplayName != "" || idp.LogoURL != "" || len(idp.Configuration) > 0) {
+			return nil, fmt.Errorf("only enabled can be set for global identity provider %s", idp.Name)
+		}
+		if exists && idp.Type == "" {
+			idp.Type = c.Type
+		}
+		if _, ok := identityProviderTypeConfiguration[idp.Type]; !ok && idp.Type != identityProviderTypeGlobal {
+			return nil, fmt.Errorf("identity provider %s has an invalid type %q", idp.Name, idp.Type)
+		}
+		var replace string
+		if exists && idp.Type != c.Type {
+			replace = "type can't be changed"
+		}
+		if err := plan.compare(KindIdentityProvider, idp.Name, idp, c, exists, replace); err != nil {
+			return nil, err
+		}
+	}
+
+	currentNotifications := map[string]Notification{}
+	for _, n := range current.Notifications {
+		currentNotifications[n.Name] = n
+	}
+	for _, n := range resolved.Notifications {
+		c, exists := currentNotifications[n.Name]
+		if !exists && n.Type != "email" && n.Type != "webhook" {
+			return nil, fmt.Errorf("notification %s must have type email or webhook", n.Name)
+		}
+		var replace string
+		if exists && n.Type != "" && n.Type != c.Type {
+			replace = "type can't be changed"
+		}
+		if err := plan.compare(KindNotification, n.Name, n, c, exists, replace); err != nil {
+			return nil, err
+		}
+	}
+
+	currentSockets := map[string]Socket{}
+	for _, s := range current.Sockets {
+		currentSockets[s.Name] = s
+	}
+	for _, s := range resolved.Sockets {
+		c, exists := currentSockets[s.Name]
+		if !exists && s.SocketType == "" {
+			return nil, fmt.Errorf("socket %s has no socket_type", s.Name)
+		}
+		var replace string
+		if exists && s.SocketType != "" && s.SocketType != c.SocketType {
+			replace = "socket_type can't be changed"
+		}
+		if err := plan.compare(KindSocket, s.Name, s, c, exists, replace); err != nil {
+			return nil, err
+		}
+	}
+
+	currentPolicies := map[string]Policy{}
+	for _, p := range current.Policies {
+		currentPolicies[p.Name] = p
+	}
+	for _, p := range resolved.Policies {
+		c, exists := currentPolicies[p.Name]
+		var replace string
+		if exists && p.OrgWide != nil && !reflect.DeepEqual(p.OrgWide, c.OrgWide) {
+			replace = "org_wide can't be changed"
+		}
+		if err := plan.compare(KindPolicy, p.Name, p, c, exists, replace); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := plan.compareAttachments(resolved, current, prune); err != nil {
+		return nil, err
+	}
+
+	if prune {
+		plan.prune(resolved, current)
+	}
+
+	return plan, nil
+}
+
+// Empty returns true when there's nothing to change
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan for humans
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes, the organization matches the manifests\n"
+	}
+
+	green := color.New(color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	var b strings.Builder
+	counts := map[string]int{}
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(&b, "%s %s %s\n", green("+"), c.Kind, c.Name)
+		case ActionUpdate:
+			fmt.Fprintf(&b, "%s %s %s\n", yellow("~"), c.Kind, c.Name)
+		case ActionReplace:
+			fmt.Fprintf(&b, "%s %s %s (%s)\n", red("-/+"), c.Kind, c.Name, c.Reason)
+		case ActionDelete:
+			fmt.Fprintf(&b, "%s %s %s\n", red("-"), c.Kind, c.Name)
+		case ActionAttach:
+			fmt.Fprintf(&b, "%s attach policy %s to socket %s\n", green("+"), c.Name, c.Socket)
+		case ActionDetach:
+			fmt.Fprintf(&b, "%s detach policy %s from socket %s\n", red("-"), c.Name, c.Socket)
+		}
+		for _, f := range c.Fields {
+			if f.Sensitive {
+				fmt.Fprintf(&b, "    %s: (sensitive value)\n", f.Field)
+			} else {
+				fmt.Fprintf(&b, "    %s: %s => %s\n", f.Field, formatValue(f.From), formatValue(f.To))
+			}
+		}
+	}
+
+	var summary []string
+	for _, action := range []string{ActionCreate, ActionUpdate, ActionReplace, ActionDelete, ActionAttach, ActionDetach} {
+		if counts[action] > 0 {
+			summary = append(summary, fmt.Sprintf("%d to %s", counts[action], action))
+		}
+	}
+	fmt.Fprintf(&b, "\nPlan: %s\n", strings.Join(summary, ", "))
+
+	return b.String()
+}
+
+func (p *Plan) compare(kind, name string, desired, current interface{}, exists bool, replace string) error {
+	switch {
+	case !exists:
+		p.Changes = append(p.Changes, Change{Kind: kind, Name: name, Action: ActionCreate, desired: desired})
+	case replace != "":
+		p.Changes = append(p.Changes, Change{Kind: kind, Name: name, Action: ActionReplace, Reason: replace, desired: desired, current: current})
+	default:
+		fields, err := diffFields(current, desired, p.rotateSecrets)
+		if err != nil {
+			return err
+		}
+		if len(fields) > 0 {
+			p.Changes = append(p.Changes, Change{Kind: kind, Name: name, Action: ActionUpdate, Fields: fields, desired: desired, current: current})
+		}
+	}
+	return nil
+}
+
+type attachment struct {
+	policy string
+	socket string
+}
+
+func (p *Plan) compareAttachments(desired *Manifest, current *State, prune bool) error {
+	policies := map[string]bool{}
+	orgWide := map[string]bool{}
+	for _, policy := range current.Policies {
+		policies[policy.Name] = true
+		orgWide[policy.Name] = policy.OrgWide != nil && *policy.OrgWide
+	}
+	for _, policy := range desired.Policies {
+		policies[policy.Name] = true
+		if policy.OrgWide != nil {
+			orgWide[policy.Name] = *policy.OrgWide
+		}
+	}
+	sockets := map[string]bool{}
+	for _, s := range current.Sockets {
+		sockets[s.Name] = true
+	}
+	for _, s := range desired.Sockets {
+		sockets[s.Name] = true
+	}
+
+	// objects that are created or replaced have no attachments yet
+	fresh := map[string]bool{}
+	for _, c := range p.Changes {
+		if c.Action == ActionCreate || c.Action == ActionReplace {
+			fresh[c.Kind+"/"+c.Name] = true
+		}
+	}
+
+	wanted := map[attachment]bool{}
+	add := func(policy, socket string) error {
+		if !policies[policy] {
+			return fmt.Errorf("policy %s of socket %s does not exist", policy, socket)
+		}
+		if !sockets[socket] {
+			return fmt.Errorf("socket %s of policy %s does not exist", socket, policy)
+		}
+		if orgWide[policy] {
+			return fmt.Errorf("policy %s is organization wide and can't be attached to socket %s", policy, socket)
+		}
+		wanted[attachment{policy: policy, socket: socket}] = true
+		return nil
+	}
+	for _, policy := range desired.Policies {
+		for _, socket := range policy.Sockets {
+			if err := add(policy.Name, socket); err != nil {
+				return err
+			}
+		}
+	}
+	for _, socket := range desired.Sockets {
+		for _, policy := range socket.Policies {
+			if err := add(policy, socket.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	existing := map[attachment]bool{}
+	for _, policy := range current.Policies {
+		if fresh[KindPolicy+"/"+policy.Name] {
+			continue
+		}
+		for _, socket := range policy.Sockets {
+			if !fresh[KindSocket+"/"+socket] {
+				existing[attachment{policy: policy.Name, socket: socket}] = true
+			}
+		}
+	}
+
+	for _, a := range sortedAttachments(wanted) {
+		if !existing[a] {
+			p.Changes = append(p.Changes, Change{Kind: KindPolicy, Name: a.policy, Socket: a.socket, Action: ActionAttach})
+		}
+	}
+
+	if !prune {
+		return nil
+	}
+
+	managed := map[string]bool{}
+	for _, policy := range desired.Policies {
+		managed[KindPolicy+"/"+policy.Name] = true
+	}
+	for _, socket := range desired.Sockets {
+		managed[KindSocket+"/"+socket.Name] = true
+	}
+	for _, a := range sortedAttachments(existing) {
+		if wanted[a] {
+			continue
+		}
+		// attachments of deleted objects go away with them
+		if !managed[KindPolicy+"/"+a.policy] && !managed[KindSocket+"/"+a.socket] {
+			continue
+		}
+		p.Changes = append(p.Changes, Change{Kind: KindPolicy, Name: a.policy, Socket: a.socket, Action: ActionDetach})
+	}
+
+	return nil
+}
+
+func (p *Plan) prune(desired *Manifest, current *State) {
+	wanted := map[string]bool{}
+	for _, name := range desired.policyNames() {
+		wanted[KindPolicy+"/"+name] = true
+	}
+	for _, name := range desired.socketNames() {
+		wanted[KindSocket+"/"+name] = true
+	}
+	for _, name := range desired.notificationNames() {
+		wanted[KindNotification+"/"+name] = true
+	}
+	for _, name := range desired.identityProviderNames() {
+		wanted[KindIdentityProvider+"/"+name] = true
+	}
+	for _, name := range desired.domainNames() {
+		wanted[KindDomain+"/"+name] = true
+	}
+
+	del := func(kind, name string, current interface{}) {
+		if desired.manages(kind) && !wanted[kind+"/"+name] {
+			p.Changes = append(p.Changes, Change{Kind: kind, Name: name, Action: ActionDelete, current: current})
+		}
+	}
+
+	// policies and sockets before what they may depend on
+	for _, policy := range current.Policies {
+		del(KindPolicy, policy.Name, policy)
+	}
+	for _, socket := range current.Sockets {
+		if !current.IsConnectorSocket(socket.Name) {
+			del(KindSocket, socket.Name, socket)
+		}
+	}
+	for _, n := range current.Notifications {
+		del(KindNotification, n.Name, n)
+	}
+	for _, idp := range current.IdentityProviders {
+		if idp.Type != identityProviderTypeGlobal {
+			del(KindIdentityProvider, idp.Name, idp)
+		}
+	}
+	for _, d := range current.Domains {
+		del(KindDomain, d.Domain, d)
+	}
+}
+
+// resolve returns a copy of the manifest with the env: references replaced
+// and the defaults of the api set
+func resolve(m *Manifest) (*Manifest, error) {
+	resolved := *m
+
+	resolved.Policies = make([]Policy, len(m.Policies))
+	for i, p := range m.Policies {
+		if p.PolicyData != nil && p.PolicyData.Version == "" {
+			data := *p.PolicyData
+			data.Version = defaultPolicyVersion
+			p.PolicyData = &data
+		}
+		resolved.Policies[i] = p
+	}
+
+	resolved.Sockets = make([]Socket, len(m.Sockets))
+	for i, s := range m.Sockets {
+		password, err := resolveSecret(s.UpstreamPassword)
+		if err != nil {
+			return nil, fmt.Errorf("socket %s: %w", s.Name, err)
+		}
+		s.UpstreamPassword = password
+		resolved.Sockets[i] = s
+	}
+
+	resolved.IdentityProviders = make([]IdentityProvider, len(m.IdentityProviders))
+	for i, idp := range m.IdentityProviders {
+		if len(idp.Configuration) > 0 {
+			configuration := map[string]interface{}{}
+			for k, v := range idp.Configuration {
+				if value, ok := v.(string); ok && secretFields[k] {
+					secret, err := resolveSecret(value)
+					if err != nil {
+						return nil, fmt.Errorf("identity provider %s: %w", idp.Name, err)
+					}
+					v = secret
+				}
+				configuration[k] = v
+			}
+			idp.Configuration = configuration
+		}
+		resolved.IdentityProviders[i] = idp
+	}
+
+	return &resolved, nil
+}
+
+// diffFields compares the fields that are set in desired with current, secrets
+// the api doesn't return are only changes with rotateSecrets
+func diffFields(current, desired interface{}, rotateSecrets bool) ([]FieldChange, error) {
+	c, err := toMap(current)
+	if err != nil {
+		return nil, err
+	}
+	d, err := toMap(desired)
+	if err != nil {
+		return nil, err
+	}
+	return diffMaps("", c, d, rotateSecrets), nil
+}
+
+func diffMaps(prefix string, current, desired map[string]interface{}, rotateSecrets bool) []FieldChange {
+	var changes []FieldChange
+	for _, key := range sortedKeys(desired) {
+		if prefix == "" && (key == "name" || relationFields[key]) {
+			continue
+		}
+		field := prefix + key
+		want, have := desired[key], current[key]
+
+		if wantMap, ok := want.(map[string]interface{}); ok && mergedFields[field] {
+			haveMap, _ := have.(map[string]interface{})
+			changes = append(changes, diffMaps(field+".", haveMap, wantMap, rotateSecrets)...)
+			continue
+		}
+
+		if secretFields[key] {
+			// the api doesn't always return secrets, when it doesn't they can't
+			// be compared and are only rotated on request, so plans converge
+			if want == nil || want == "" || reflect.DeepEqual(want, have) {
+				continue
+			}
+			if (have == nil || have == "") && !rotateSecrets {
+				continue
+			}
+			changes = append(changes, FieldChange{Field: field, Sensitive: true})
+			continue
+		}
+
+		if !reflect.DeepEqual(normalize(want), normalize(have)) {
+			changes = append(changes, FieldChange{Field: field, From: have, To: want})
+		}
+	}
+	return changes
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// normalize sorts lists of strings, their order doesn't matter to the api
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		strs := make([]string, 0, len(value))
+		for i, e := range value {
+			list[i] = normalize(e)
+			if s, ok := e.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		if len(strs) != len(value) {
+			return list
+		}
+		sort.Strings(strs)
+		for i, s := range strs {
+			list[i] = s
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, e := range value {
+			m[k] = normalize(e)
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedAttachments(set map[attachment]bool) []attachment {
+	list := make([]attachment, 0, len(set))
+	for a := range set {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].policy != list[j].policy {
+			return list[i].policy < list[j].policy
+		}
+		return list[i].socket < list[j].socket
+	})
+	return list
+}
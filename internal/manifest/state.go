@@ -0,0 +1,196 @@
+package manifest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+)
+
+// Client makes requests to the border0 api, it's implemented by http.Client
+type Client interface {
+	Request(method string, url string, target interface{}, data interface{}) error
+}
+
+// identityProviderTypeConfiguration maps the identity provider types to the
+// api field with their configuration, global providers have no configuration
+var identityProviderTypeConfiguration = map[string]string{
+	"oidc":             "oidc_configuration",
+	"saml":             "saml_configuration",
+	"okta-workforce":   "okta_workforce_configuration",
+	"google-workspace": "google_workspace_configuration",
+}
+
+const identityProviderTypeGlobal = "global"
+
+// State is the current configuration of an organization, as a manifest and
+// with the api objects it was built from
+type State struct {
+	Manifest
+
+	sockets  map[string]models.Socket
+	policies map[string]models.Policy
+	// connectorSockets are created by connectors, they're left to the connectors
+	connectorSockets map[string]bool
+}
+
+type identityProviderList struct {
+	List []identityProviderResponse `json:"list"`
+}
+
+type identityProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+	LogoURL     string `json:"logo_url,omitempty"`
+
+	OIDCConfiguration            map[string]interface{} `json:"oidc_configuration,omitempty"`
+	SAMLConfiguration            map[string]interface{} `json:"saml_configuration,omitempty"`
+	OktaWorkforceConfiguration   map[string]interface{} `json:"okta_workforce_configuration,omitempty"`
+	GoogleWorkspaceConfiguration map[string]interface{} `json:"google_workspace_configuration,omitempty"`
+}
+
+func (r identityProviderResponse) configuration() map[string]interface{} {
+	switch r.Type {
+	case "oidc":
+		return r.OIDCConfiguration
+	case "saml":
+		return r.SAMLConfiguration
+	case "okta-workforce":
+		return r.OktaWorkforceConfiguration
+	case "google-workspace":
+		return r.GoogleWorkspaceConfiguration
+	}
+	return nil
+}
+
+// Fetch gets the current configuration of the organization
+func Fetch(client Client) (*State, error) {
+	state := &State{
+		sockets:          map[string]models.Socket{},
+		policies:         map[string]models.Policy{},
+		connectorSockets: map[string]bool{},
+	}
+
+	var sockets []models.Socket
+	if err := client.Request(http.MethodGet, "socket", &sockets, nil); err != nil {
+		return nil, fmt.Errorf("failed to get sockets: %w", err)
+	}
+	var policies []models.Policy
+	if err := client.Request(http.MethodGet, "policies", &policies, nil); err != nil {
+		return nil, fmt.Errorf("failed to get policies: %w", err)
+	}
+	var notifications []models.Notification
+	if err := client.Request(http.MethodGet, "organizations/notifications", &notifications, nil); err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	var domains []models.Domain
+	if err := client.Request(http.MethodGet, "organizations/customdomains", &domains, nil); err != nil {
+		return nil, fmt.Errorf("failed to get domains: %w", err)
+	}
+	var idps identityProviderList
+	if err := client.Request(http.MethodGet, "organization/identity_providers", &idps, nil); err != nil {
+		return nil, fmt.Errorf("failed to get identity providers: %w", err)
+	}
+
+	socketNames := map[string]string{}
+	for _, s := range sockets {
+		socketNames[s.SocketID] = s.Name
+		state.sockets[s.Name] = s
+
+		s.BuildConnectorDataByTags()
+		if s.ConnectorData.Key() != "" {
+			state.connectorSockets[s.Name] = true
+		}
+	}
+
+	attached := map[string][]string{}
+	for _, p := range policies {
+		state.policies[p.Name] = p
+
+		policy := Policy{
+			Name:        p.Name,
+			Description: p.Description,
+			OrgWide:     boolPtr(p.OrgWide),
+			PolicyData:  policyDataPtr(p.PolicyData),
+		}
+		if !p.OrgWide {
+			for _, id := range p.SocketIDs {
+				if name, ok := socketNames[id]; ok {
+					policy.Sockets = append(policy.Sockets, name)
+					attached[name] = append(attached[name], p.Name)
+				}
+			}
+		}
+		sort.Strings(policy.Sockets)
+		state.Policies = append(state.Policies, policy)
+	}
+
+	for _, s := range sockets {
+		socket := Socket{
+			Name:                           s.Name,
+			SocketType:                     s.SocketType,
+			UpstreamType:                   s.UpstreamType,
+			Description:                    s.Description,
+			UpstreamUsername:               s.UpstreamUsername,
+			UpstreamPassword:               s.UpstreamPassword,
+			UpstreamHttpHostname:           s.UpstreamHttpHostname,
+			ConnectorAuthenticationEnabled: boolPtr(s.ConnectorAuthenticationEnabled),
+			AllowedEmailAddresses:          s.AllowedEmailAddresses,
+			AllowedEmailDomains:            s.AllowedEmailDomains,
+			Tags:                           s.Tags,
+			Policies:                       attached[s.Name],
+		}
+		sort.Strings(socket.Policies)
+		state.Sockets = append(state.Sockets, socket)
+	}
+
+	for _, n := range notifications {
+		state.Notifications = append(state.Notifications, Notification{
+			Name:            n.Name,
+			Type:            n.Type,
+			Enabled:         boolPtr(n.Enabled),
+			Events:          n.Events,
+			WebhookURL:      n.WebhookURL,
+			EmailRecipients: n.EmailRecipients,
+		})
+	}
+
+	for _, d := range domains {
+		state.Domains = append(state.Domains, Domain{Domain: d.Domain, Default: boolPtr(d.Default)})
+	}
+
+	for _, summary := range idps.List {
+		idp := summary
+		if summary.Type != identityProviderTypeGlobal {
+			if err := client.Request(http.MethodGet, "organization/identity_provider/"+summary.Name, &idp, nil); err != nil {
+				return nil, fmt.Errorf("failed to get identity provider %s: %w", summary.Name, err)
+			}
+		}
+		state.IdentityProviders = append(state.IdentityProviders, IdentityProvider{
+			Name:          idp.Name,
+			DisplayName:   idp.DisplayName,
+			Type:          idp.Type,
+			Enabled:       idp.Enabled,
+			LogoURL:       idp.LogoURL,
+			Configuration: idp.configuration(),
+		})
+	}
+
+	return state, nil
+}
+
+// IsConnectorSocket returns true if the socket was created by a connector
+func (s *State) IsConnectorSocket(name string) bool {
+	return s.connectorSockets[name]
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func policyDataPtr(data models.PolicyData) *models.PolicyData {
+	return &data
+}
@@ -0,0 +1,356 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FormatTable = "table"
+	FormatWide  = "wide"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatCSV   = "csv"
+)
+
+var formats = []string{FormatTable, FormatWide, FormatJSON, FormatYAML, FormatCSV}
+
+// secretFields are left out of json, yaml and csv output, like secrets are
+// left out of manifest exports
+var secretFields = map[string]bool{
+	"upstream_password":  true,
+	"protected_password": true,
+	"upstream_key":       true,
+	"client_secret":      true,
+	"password":           true,
+	"token":              true,
+	"access_token":       true,
+}
+
+var (
+	// Format, Filters and SortBy are set by the global --output, --filter and --sort flags
+	Format  = FormatTable
+	Filters []string
+	SortBy  string
+)
+
+// AddFlags adds the --output, --filter and --sort flags to a flag set
+func AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&Format, "output", FormatTable, "Output format of listings: "+strings.Join(formats, ", "))
+	flags.StringArrayVar(&Filters, "filter", nil, "Only list items where field=value, the value can be a glob, example: --filter socket_type=ssh --filter name='prod-*'")
+	flags.StringVar(&SortBy, "sort", "", "Sort listings by field, prefix with - for descending order, example: --sort -name")
+}
+
+// Validate checks the values of the output flags
+func Validate() error {
+	if !isFormat(Format) {
+		return fmt.Errorf("invalid output format %q, must be one of: %s", Format, strings.Join(formats, ", "))
+	}
+	for _, filter := range Filters {
+		if _, _, err := parseFilter(filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTable returns true when the output is meant for humans
+func IsTable() bool {
+	return Format == FormatTable || Format == FormatWide
+}
+
+// Column is a column of a listing. Field is the stable name used in csv
+// headers and matches the json name of the field in the models
+type Column[T any] struct {
+	Field  string
+	Header string
+	// Wide columns are only shown with --output wide and csv
+	Wide  bool
+	Value func(T) interface{}
+}
+
+// Print applies the filter and sort flags to items and prints them in the
+// selected format. The table writer can be customized with the style functions
+func Print[T any](items []T, columns []Column[T], style ...func(table.Writer)) error {
+	return Fprint(os.Stdout, items, columns, style...)
+}
+
+// Fprint is Print with a custom writer
+func Fprint[T any](w io.Writer, items []T, columns []Column[T], style ...func(table.Writer)) error {
+	items, err := Apply(items)
+	if err != nil {
+		return err
+	}
+
+	switch Format {
+	case FormatJSON:
+		redacted, err := redactItems(items)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(redacted, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal output as json: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	case FormatYAML:
+		redacted, err := redactItems(items)
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(redacted)
+		if err != nil {
+			return fmt.Errorf("failed to marshal output as yaml: %w", err)
+		}
+		fmt.Fprint(w, string(data))
+	case FormatCSV:
+		t := table.NewWriter()
+		header := table.Row{}
+		for _, c := range columns {
+			header = append(header, c.Field)
+		}
+		t.AppendHeader(header)
+		for _, item := range items {
+			// csv has the raw values of the fields, like json and yaml
+			fields, err := toFields(item)
+			if err != nil {
+				return err
+			}
+			row := table.Row{}
+			for _, c := range columns {
+				if secretFields[c.Field] {
+					row = append(row, "")
+				} else if value, ok := fields[c.Field]; ok {
+					row = append(row, csvValue(value))
+				} else {
+					row = append(row, c.Value(item))
+				}
+			}
+			t.AppendRow(row)
+		}
+		fmt.Fprintln(w, t.RenderCSV())
+	case FormatTable, FormatWide:
+		t := table.NewWriter()
+		t.SetStyle(table.StyleLight)
+		for _, s := range style {
+			s(t)
+		}
+		header := table.Row{}
+		for _, c := range columns {
+			if c.Wide && Format != FormatWide {
+				continue
+			}
+			header = append(header, c.Header)
+		}
+		t.AppendHeader(header)
+		for _, item := range items {
+			row := table.Row{}
+			for _, c := range columns {
+				if c.Wide && Format != FormatWide {
+					continue
+				}
+				row = append(row, c.Value(item))
+			}
+			t.AppendRow(row)
+		}
+		fmt.Fprintf(w, "%s\n", t.Render())
+	default:
+		return fmt.Errorf("invalid output format %q, must be one of: %s", Format, strings.Join(formats, ", "))
+	}
+
+	return nil
+}
+
+// Apply returns the items that match the filter flags, sorted by the sort flag.
+// Fields are the json names of the items, nested fields are separated by dots
+func Apply[T any](items []T) ([]T, error) {
+	if len(Filters) == 0 && SortBy == "" {
+		return items, nil
+	}
+
+	type entry struct {
+		item   T
+		fields map[string]interface{}
+	}
+
+	var entries []entry
+	for _, item := range items {
+		fields, err := toFields(item)
+		if err != nil {
+			return nil, err
+		}
+
+		match := true
+		for _, filter := range Filters {
+			field, pattern, err := parseFilter(filter)
+			if err != nil {
+				return nil, err
+			}
+			if !matches(lookup(fields, field), pattern) {
+				match = false
+				break
+			}
+		}
+		if match {
+			entries = append(entries, entry{item: item, fields: fields})
+		}
+	}
+
+	if SortBy != "" {
+		field := strings.TrimPrefix(SortBy, "-")
+		descending := strings.HasPrefix(SortBy, "-")
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := lookup(entries[i].fields, field), lookup(entries[j].fields, field)
+			if descending {
+				return less(b, a)
+			}
+			return less(a, b)
+		})
+	}
+
+	filtered := make([]T, 0, len(entries))
+	for _, e := range entries {
+		filtered = append(filtered, e.item)
+	}
+
+	return filtered, nil
+}
+
+func isFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func parseFilter(filter string) (string, string, error) {
+	field, pattern, ok := strings.Cut(filter, "=")
+	if !ok || field == "" {
+		return "", "", fmt.Errorf("invalid filter %q, must be field=value", filter)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", "", fmt.Errorf("invalid filter %q: %w", filter, err)
+	}
+	return field, pattern, nil
+}
+
+func toFields(item interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("items can't be filtered or sorted: %w", err)
+	}
+	return fields, nil
+}
+
+// redactItems returns the items as json values without their secret fields
+func redactItems(items interface{}) (interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+	return redact(value), nil
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redact(e)
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			if secretFields[k] {
+				delete(v, k)
+				continue
+			}
+			v[k] = redact(e)
+		}
+	}
+	return value
+}
+
+func lookup(fields map[string]interface{}, field string) interface{} {
+	var value interface{} = fields
+	for _, key := range strings.Split(field, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// matches returns true if the value matches the glob pattern, lists match
+// when one of their elements does
+func matches(value interface{}, pattern string) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, v := range list {
+			if matches(v, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+	ok, _ := path.Match(pattern, toString(value))
+	return ok
+}
+
+func less(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			return x < y
+		}
+	}
+	return toString(a) < toString(b)
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		var strs []string
+		for _, e := range v {
+			strs = append(strs, csvValue(e))
+		}
+		return strings.Join(strs, ",")
+	case map[string]interface{}:
+		var strs []string
+		for k, e := range v {
+			strs = append(strs, k+"="+csvValue(e))
+		}
+		sort.Strings(strs)
+		return strings.Join(strs, ",")
+	default:
+		return toString(v)
+	}
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
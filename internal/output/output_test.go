@@ -0,0 +1,112 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name  string            `json:"name"`
+	Port  int               `json:"port"`
+	Ports []int             `json:"ports,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+	// Password is a secret, it's left out of the output
+	Password string `json:"upstream_password,omitempty"`
+}
+
+var items = []item{
+	{Name: "prod-db", Port: 5432, Tags: map[string]string{"env": "prod"}, Password: "secret"},
+	{Name: "dev-db", Port: 3306, Tags: map[string]string{"env": "dev"}},
+	{Name: "prod-ssh", Port: 22, Ports: []int{22, 2222}, Tags: map[string]string{"env": "prod"}},
+}
+
+var columns = []Column[item]{
+	{Field: "name", Header: "Name", Value: func(i item) interface{} { return i.Name }},
+	{Field: "port", Header: "Port", Wide: true, Value: func(i item) interface{} { return i.Port }},
+}
+
+func names(items []item) []string {
+	var names []string
+	for _, i := range items {
+		names = append(names, i.Name)
+	}
+	return names
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		sortBy  string
+		want    []string
+	}{
+		{name: "no flags", want: []string{"prod-db", "dev-db", "prod-ssh"}},
+		{name: "glob", filters: []string{"name=prod-*"}, want: []string{"prod-db", "prod-ssh"}},
+		{name: "nested field", filters: []string{"tags.env=dev"}, want: []string{"dev-db"}},
+		{name: "list field", filters: []string{"ports=2222"}, want: []string{"prod-ssh"}},
+		{name: "all filters match", filters: []string{"tags.env=prod", "port=5432"}, want: []string{"prod-db"}},
+		{name: "no match", filters: []string{"name=staging-*"}, want: []string{}},
+		{name: "sort by name", sortBy: "name", want: []string{"dev-db", "prod-db", "prod-ssh"}},
+		{name: "sort numbers descending", sortBy: "-port", want: []string{"prod-db", "dev-db", "prod-ssh"}},
+		{name: "sort numbers", sortBy: "port", want: []string{"prod-ssh", "dev-db", "prod-db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Filters, SortBy = tt.filters, tt.sortBy
+			defer func() { Filters, SortBy = nil, "" }()
+
+			got, err := Apply(items)
+			require.NoError(t, err)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, names(got))
+		})
+	}
+}
+
+func TestFprint(t *testing.T) {
+	tests := []struct {
+		format  string
+		columns []Column[item]
+		want    string
+	}{
+		{format: FormatTable, want: "┌─────────┐\n│ NAME    │\n├─────────┤\n│ prod-db │\n└─────────┘\n"},
+		{format: FormatWide, want: "┌─────────┬──────┐\n│ NAME    │ PORT │\n├─────────┼──────┤\n│ prod-db │ 5432 │\n└─────────┴──────┘\n"},
+		{format: FormatCSV, want: "name,port\nprod-db,5432\n"},
+		{format: FormatCSV, columns: append(columns, Column[item]{Field: "upstream_password", Value: func(i item) interface{} { return i.Password }}), want: "name,port,upstream_password\nprod-db,5432,\n"},
+		{format: FormatJSON, want: "[\n  {\n    \"name\": \"prod-db\",\n    \"port\": 5432,\n    \"tags\": {\n      \"env\": \"prod\"\n    }\n  }\n]\n"},
+		{format: FormatYAML, want: "- name: prod-db\n  port: 5432\n  tags:\n    env: prod\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			Format = tt.format
+			defer func() { Format = FormatTable }()
+
+			var buf bytes.Buffer
+			cols := columns
+			if tt.columns != nil {
+				cols = tt.columns
+			}
+			require.NoError(t, Fprint(&buf, items[:1], cols))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	defer func() { Format, Filters = FormatTable, nil }()
+
+	Format = "xml"
+	assert.Error(t, Validate())
+
+	Format, Filters = FormatJSON, []string{"name"}
+	assert.Error(t, Validate())
+
+	Filters = []string{"name=prod-*"}
+	assert.NoError(t, Validate())
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	// time zones of time of day conditions, windows has no zoneinfo database
+	_ "time/tzdata"
+)
+
+// dateLayouts are the formats of the after and before conditions
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// timeOfDayLayouts are the formats of the time of day conditions, they're
+// followed by an optional time zone, like "09:00:00 UTC" or "09:00 Europe/Amsterdam"
+var timeOfDayLayouts = []string{
+	"15:04:05",
+	"15:04",
+}
+
+// ParseTime parses a date, with an optional time, in UTC unless a zone is given
+func ParseTime(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use a date like 2006-01-02 or a time like 2006-01-02T15:04:05Z", value)
+}
+
+// timeOfDay is a clock time in a location
+type timeOfDay struct {
+	seconds  int
+	location *time.Location
+}
+
+func parseTimeOfDay(value string) (timeOfDay, error) {
+	clock, zone, _ := strings.Cut(strings.TrimSpace(value), " ")
+
+	location := time.UTC
+	if zone = strings.TrimSpace(zone); zone != "" {
+		var err error
+		if location, err = time.LoadLocation(zone); err != nil {
+			return timeOfDay{}, fmt.Errorf("invalid time zone in %q", value)
+		}
+	}
+
+	for _, layout := range timeOfDayLayouts {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return timeOfDay{seconds: t.Hour()*3600 + t.Minute()*60 + t.Second(), location: location}, nil
+		}
+	}
+
+	return timeOfDay{}, fmt.Errorf("invalid time of day %q, use a time like 09:00:00 UTC", value)
+}
+
+// at returns the clock time of t in the location of the time of day
+func (d timeOfDay) at(t time.Time) int {
+	t = t.In(d.location)
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
+
+func parseCIDR(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address or cidr %q", value)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ip address or cidr %q", value)
+	}
+	return network, nil
+}
+
+// isCountryCode checks for an ISO 3166-1 alpha-2 code, like NL or US
+func isCountryCode(value string) bool {
+	if len(value) != 2 {
+		return false
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
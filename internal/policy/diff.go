@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/fatih/color"
+	"sigs.k8s.io/yaml"
+)
+
+// Marshal returns policy data as yaml
+func Marshal(data models.PolicyData) ([]byte, error) {
+	return yaml.Marshal(data)
+}
+
+// Diff returns a coloured line diff of the yaml of two versions of policy
+// data, old is nil for a new policy. It's empty when they're the same
+func Diff(old *models.PolicyData, new models.PolicyData) (string, error) {
+	var oldLines []string
+	if old != nil {
+		data, err := Marshal(*old)
+		if err != nil {
+			return "", err
+		}
+		oldLines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	data, err := Marshal(new)
+	if err != nil {
+		return "", err
+	}
+	newLines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	return diffLines(oldLines, newLines), nil
+}
+
+// diffLines diffs two lists of lines using their longest common subsequence
+func diffLines(a, b []string) string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	red := color.New(color.FgRed).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+
+	var sb strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			sb.WriteString(green("+ "+b[j]) + "\n")
+			changed = true
+			j++
+		default:
+			sb.WriteString(red("- "+a[i]) + "\n")
+			changed = true
+			i++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+	return sb.String()
+}
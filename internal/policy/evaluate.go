@@ -0,0 +1,295 @@
+package policy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+)
+
+// Result is the outcome of a condition, or of a whole policy
+type Result int
+
+const (
+	// Unknown means the request doesn't have what's needed to evaluate the condition
+	Unknown Result = iota
+	Match
+	NoMatch
+)
+
+func (r Result) String() string {
+	switch r {
+	case Match:
+		return "match"
+	case NoMatch:
+		return "no match"
+	default:
+		return "unknown"
+	}
+}
+
+// Request is who asks for access, from where and when
+type Request struct {
+	Email string
+	IP    net.IP
+	// Country is an ISO 3166-1 alpha-2 code, the country of an ip isn't looked up
+	Country string
+	Time    time.Time
+	// Action is the type of socket, like ssh or database, empty for any action
+	Action string
+}
+
+// Check is the result of one condition of a policy
+type Check struct {
+	Condition string
+	Result    Result
+	Reason    string
+}
+
+// Evaluation is the result of a policy for a request
+type Evaluation struct {
+	Policy string
+	Result Result
+	Checks []Check
+	// Actions are the actions the policy allows when it matches
+	Actions []string
+}
+
+// Evaluate checks a request against a policy, a policy matches when all its
+// conditions match. Conditions without values match every request, except
+// for who, a policy without emails or domains matches no one
+func Evaluate(name string, data models.PolicyData, req Request) Evaluation {
+	checks := []Check{
+		checkAction(data.Action, req.Action),
+		checkWho(data.Condition.Who, req.Email),
+	}
+	checks = append(checks, checkWhere(data.Condition.Where, req)...)
+	checks = append(checks, checkWhen(data.Condition.When, req.Time)...)
+
+	result := Match
+	for _, c := range checks {
+		if c.Result == NoMatch {
+			result = NoMatch
+			break
+		}
+		if c.Result == Unknown {
+			result = Unknown
+		}
+	}
+
+	return Evaluation{Policy: name, Result: result, Checks: checks, Actions: data.Action}
+}
+
+func checkAction(actions []string, action string) Check {
+	check := Check{Condition: "action"}
+	switch {
+	case action == "":
+		check.Result = Match
+		check.Reason = fmt.Sprintf("allows %s", list(actions))
+	case contains(actions, action):
+		check.Result = Match
+		check.Reason = fmt.Sprintf("%s is in %s", action, list(actions))
+	default:
+		check.Result = NoMatch
+		check.Reason = fmt.Sprintf("%s is not in %s", action, list(actions))
+	}
+	return check
+}
+
+func checkWho(who models.ConditionWho, email string) Check {
+	check := Check{Condition: "who"}
+	if len(who.Email) == 0 && len(who.Domain) == 0 {
+		check.Result = NoMatch
+		check.Reason = "no emails or domains, the policy matches no one"
+		return check
+	}
+	if email == "" {
+		check.Result = Unknown
+		check.Reason = "no email given, use --email"
+		return check
+	}
+
+	for _, e := range who.Email {
+		if strings.EqualFold(e, email) {
+			check.Result = Match
+			check.Reason = fmt.Sprintf("%s is in email %s", email, list(who.Email))
+			return check
+		}
+	}
+	if _, domain, ok := strings.Cut(email, "@"); ok {
+		for _, d := range who.Domain {
+			if strings.EqualFold(d, domain) {
+				check.Result = Match
+				check.Reason = fmt.Sprintf("%s is in domain %s", domain, list(who.Domain))
+				return check
+			}
+		}
+	}
+
+	check.Result = NoMatch
+	check.Reason = fmt.Sprintf("%s is not in email %s or domain %s", email, list(who.Email), list(who.Domain))
+	return check
+}
+
+func checkWhere(where models.ConditionWhere, req Request) []Check {
+	var checks []Check
+
+	if len(where.AllowedIP) > 0 {
+		check := Check{Condition: "where allowed_ip"}
+		switch {
+		case req.IP == nil:
+			check.Result = Unknown
+			check.Reason = "no ip address given, use --ip"
+		default:
+			check.Result = NoMatch
+			check.Reason = fmt.Sprintf("%s is not in %s", req.IP, list(where.AllowedIP))
+			for _, value := range where.AllowedIP {
+				network, err := parseCIDR(value)
+				if err != nil {
+					check.Result = Unknown
+					check.Reason = err.Error()
+					break
+				}
+				if network.Contains(req.IP) {
+					check.Result = Match
+					check.Reason = fmt.Sprintf("%s is in %s", req.IP, value)
+					break
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	if len(where.Country) > 0 {
+		check := Check{Condition: "where country"}
+		switch {
+		case req.Country == "":
+			check.Result = Unknown
+			check.Reason = fmt.Sprintf("no country given, use --country to check %s", list(where.Country))
+		case containsFold(where.Country, req.Country):
+			check.Result = Match
+			check.Reason = fmt.Sprintf("%s is in %s", req.Country, list(where.Country))
+		default:
+			check.Result = NoMatch
+			check.Reason = fmt.Sprintf("%s is not in %s", req.Country, list(where.Country))
+		}
+		checks = append(checks, check)
+	}
+
+	if len(where.CountryNot) > 0 {
+		check := Check{Condition: "where country_not"}
+		switch {
+		case req.Country == "":
+			check.Result = Unknown
+			check.Reason = fmt.Sprintf("no country given, use --country to check %s", list(where.CountryNot))
+		case containsFold(where.CountryNot, req.Country):
+			check.Result = NoMatch
+			check.Reason = fmt.Sprintf("%s is in %s", req.Country, list(where.CountryNot))
+		default:
+			check.Result = Match
+			check.Reason = fmt.Sprintf("%s is not in %s", req.Country, list(where.CountryNot))
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+func checkWhen(when models.ConditionWhen, t time.Time) []Check {
+	var checks []Check
+	timestamp := t.UTC().Format(time.RFC3339)
+
+	if when.After != "" {
+		check := Check{Condition: "when after"}
+		if after, err := ParseTime(when.After); err != nil {
+			check.Result, check.Reason = Unknown, err.Error()
+		} else if t.Before(after) {
+			check.Result, check.Reason = NoMatch, fmt.Sprintf("%s is before %s", timestamp, when.After)
+		} else {
+			check.Result, check.Reason = Match, fmt.Sprintf("%s is after %s", timestamp, when.After)
+		}
+		checks = append(checks, check)
+	}
+
+	if when.Before != "" {
+		check := Check{Condition: "when before"}
+		if before, err := ParseTime(when.Before); err != nil {
+			check.Result, check.Reason = Unknown, err.Error()
+		} else if !t.Before(before) {
+			check.Result, check.Reason = NoMatch, fmt.Sprintf("%s is not before %s", timestamp, when.Before)
+		} else {
+			check.Result, check.Reason = Match, fmt.Sprintf("%s is before %s", timestamp, when.Before)
+		}
+		checks = append(checks, check)
+	}
+
+	if when.TimeOfDayAfter != "" || when.TimeOfDayBefore != "" {
+		checks = append(checks, checkTimeOfDay(when, t))
+	}
+
+	return checks
+}
+
+func checkTimeOfDay(when models.ConditionWhen, t time.Time) Check {
+	check := Check{Condition: "when time_of_day"}
+
+	from, to := when.TimeOfDayAfter, when.TimeOfDayBefore
+	if from == "" {
+		from = "00:00:00 UTC"
+	}
+	if to == "" {
+		to = "23:59:59 UTC"
+	}
+
+	after, err := parseTimeOfDay(from)
+	if err != nil {
+		check.Result, check.Reason = Unknown, err.Error()
+		return check
+	}
+	before, err := parseTimeOfDay(to)
+	if err != nil {
+		check.Result, check.Reason = Unknown, err.Error()
+		return check
+	}
+
+	var inside bool
+	if after.location.String() == before.location.String() && after.seconds > before.seconds {
+		// the window wraps around midnight, like 22:00 to 06:00
+		now := after.at(t)
+		inside = now >= after.seconds || now <= before.seconds
+	} else {
+		inside = after.at(t) >= after.seconds && before.at(t) <= before.seconds
+	}
+
+	window := fmt.Sprintf("%s to %s", from, to)
+	if inside {
+		check.Result, check.Reason = Match, fmt.Sprintf("%s is between %s", t.UTC().Format("15:04:05 MST"), window)
+	} else {
+		check.Result, check.Reason = NoMatch, fmt.Sprintf("%s is not between %s", t.UTC().Format("15:04:05 MST"), window)
+	}
+	return check
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func list(values []string) string {
+	return "[" + strings.Join(values, ", ") + "]"
+}
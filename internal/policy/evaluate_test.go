@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	data := models.PolicyData{
+		Version: "v1",
+		Action:  []string{"ssh", "database"},
+		Condition: models.Condition{
+			Who:   models.ConditionWho{Email: []string{"alice@example.com"}, Domain: []string{"border0.com"}},
+			Where: models.ConditionWhere{AllowedIP: []string{"10.0.0.0/8", "192.0.2.1"}, CountryNot: []string{"KP"}},
+			When: models.ConditionWhen{
+				After:           "2026-01-01",
+				Before:          "2027-01-01",
+				TimeOfDayAfter:  "08:00:00 UTC",
+				TimeOfDayBefore: "18:00:00 UTC",
+			},
+		},
+	}
+	workday := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	valid := Request{Email: "alice@example.com", IP: net.ParseIP("10.1.2.3"), Country: "NL", Time: workday, Action: "ssh"}
+
+	tests := []struct {
+		name       string
+		change     func(r *Request)
+		want       Result
+		wantFailed string
+	}{
+		{name: "all conditions match", change: func(r *Request) {}, want: Match},
+		{name: "domain match", change: func(r *Request) { r.Email = "bob@BORDER0.com" }, want: Match},
+		{name: "any action", change: func(r *Request) { r.Action = "" }, want: Match},
+		{name: "single ip", change: func(r *Request) { r.IP = net.ParseIP("192.0.2.1") }, want: Match},
+		{name: "unknown user", change: func(r *Request) { r.Email = "eve@example.com" }, want: NoMatch, wantFailed: "who"},
+		{name: "wrong action", change: func(r *Request) { r.Action = "http" }, want: NoMatch, wantFailed: "action"},
+		{name: "ip outside range", change: func(r *Request) { r.IP = net.ParseIP("8.8.8.8") }, want: NoMatch, wantFailed: "where allowed_ip"},
+		{name: "excluded country", change: func(r *Request) { r.Country = "kp" }, want: NoMatch, wantFailed: "where country_not"},
+		{name: "before start date", change: func(r *Request) { r.Time = workday.AddDate(-1, 0, 0) }, want: NoMatch, wantFailed: "when after"},
+		{name: "after end date", change: func(r *Request) { r.Time = workday.AddDate(1, 0, 0) }, want: NoMatch, wantFailed: "when before"},
+		{name: "outside office hours", change: func(r *Request) { r.Time = workday.Add(12 * time.Hour) }, want: NoMatch, wantFailed: "when time_of_day"},
+		{name: "no country", change: func(r *Request) { r.Country = "" }, want: Unknown},
+		{name: "no ip", change: func(r *Request) { r.IP = nil }, want: Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.change(&req)
+
+			e := Evaluate("engineering", data, req)
+			assert.Equal(t, tt.want, e.Result)
+			for _, c := range e.Checks {
+				if c.Result == NoMatch {
+					assert.Equal(t, tt.wantFailed, c.Condition, c.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluate_NoOne(t *testing.T) {
+	e := Evaluate("empty", models.PolicyData{Action: []string{"ssh"}}, Request{Email: "alice@example.com", Time: time.Now()})
+	assert.Equal(t, NoMatch, e.Result)
+}
+
+func TestCheckTimeOfDay(t *testing.T) {
+	tests := []struct {
+		name   string
+		after  string
+		before string
+		time   time.Time
+		want   Result
+	}{
+		{name: "night shift before midnight", after: "22:00 UTC", before: "06:00 UTC", time: time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC), want: Match},
+		{name: "night shift after midnight", after: "22:00 UTC", before: "06:00 UTC", time: time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC), want: Match},
+		{name: "night shift during the day", after: "22:00 UTC", before: "06:00 UTC", time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), want: NoMatch},
+		{name: "other time zone", after: "09:00 America/New_York", before: "17:00 America/New_York", time: time.Date(2026, 1, 1, 15, 0, 0, 0, time.UTC), want: Match},
+		{name: "other time zone outside hours", after: "09:00 America/New_York", before: "17:00 America/New_York", time: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC), want: NoMatch},
+		{name: "invalid format", after: "9am", time: time.Now(), want: Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := checkTimeOfDay(models.ConditionWhen{TimeOfDayAfter: tt.after, TimeOfDayBefore: tt.before}, tt.time)
+			assert.Equal(t, tt.want, c.Result, c.Reason)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"sigs.k8s.io/yaml"
+)
+
+const Version = "v1"
+
+// Actions are the socket types a policy can allow
+var Actions = []string{"database", "ssh", "http", "tls"}
+
+// ValidationError lists everything that's wrong with a policy
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid policy:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Parse reads policy data from yaml or json, unknown keys are an error
+func Parse(data []byte) (models.PolicyData, error) {
+	var policyData models.PolicyData
+	if err := yaml.UnmarshalStrict(data, &policyData); err != nil {
+		return policyData, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := Validate(policyData); err != nil {
+		return policyData, err
+	}
+	return policyData, nil
+}
+
+// Validate checks the values of the conditions of a policy
+func Validate(data models.PolicyData) error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if data.Version != Version {
+		problem("version must be %s", Version)
+	}
+
+	if len(data.Action) == 0 {
+		problem("action must have at least one of %s", list(Actions))
+	}
+	for _, action := range data.Action {
+		if !contains(Actions, action) {
+			problem("action %q is not one of %s", action, list(Actions))
+		}
+	}
+
+	who := data.Condition.Who
+	for _, email := range who.Email {
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			problem("condition.who.email %q is not an email address", email)
+		}
+	}
+	for _, domain := range who.Domain {
+		if domain == "" || strings.ContainsAny(domain, "@ /") || !strings.Contains(domain, ".") {
+			problem("condition.who.domain %q is not a domain", domain)
+		}
+	}
+
+	where := data.Condition.Where
+	for _, ip := range where.AllowedIP {
+		if _, err := parseCIDR(ip); err != nil {
+			problem("condition.where.allowed_ip: %v", err)
+		}
+	}
+	for _, country := range where.Country {
+		if !isCountryCode(country) {
+			problem("condition.where.country %q is not a two letter country code, like NL or US", country)
+		}
+	}
+	for _, country := range where.CountryNot {
+		if !isCountryCode(country) {
+			problem("condition.where.country_not %q is not a two letter country code, like NL or US", country)
+		}
+	}
+
+	when := data.Condition.When
+	after, afterErr := ParseTime(when.After)
+	if when.After != "" && afterErr != nil {
+		problem("condition.when.after: %v", afterErr)
+	}
+	before, beforeErr := ParseTime(when.Before)
+	if when.Before != "" && beforeErr != nil {
+		problem("condition.when.before: %v", beforeErr)
+	}
+	if when.After != "" && when.Before != "" && afterErr == nil && beforeErr == nil && !after.Before(before) {
+		problem("condition.when.before must be later than condition.when.after")
+	}
+	if when.TimeOfDayAfter != "" {
+		if _, err := parseTimeOfDay(when.TimeOfDayAfter); err != nil {
+			problem("condition.when.time_of_day_after: %v", err)
+		}
+	}
+	if when.TimeOfDayBefore != "" {
+		if _, err := parseTimeOfDay(when.TimeOfDayBefore); err != nil {
+			problem("condition.when.time_of_day_before: %v", err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validYAML = `version: v1
+action: [ssh, database]
+condition:
+  who:
+    email: [alice@example.com]
+    domain: [example.com]
+  where:
+    allowed_ip: [10.0.0.0/8, "::/0", 192.0.2.1]
+    country: [NL]
+    country_not: []
+  when:
+    after: "2026-01-01"
+    before: null
+    time_of_day_after: "08:00:00 UTC"
+    time_of_day_before: "18:00 Europe/Amsterdam"
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "yaml", data: validYAML},
+		{name: "json", data: `{"version":"v1","action":["http"],"condition":{"who":{"email":["alice@example.com"]},"where":{},"when":{}}}`},
+		{name: "unknown key", data: strings.Replace(validYAML, "allowed_ip:", "allowed_ips:", 1), wantErr: `unknown field "allowed_ips"`},
+		{name: "wrong version", data: strings.Replace(validYAML, "version: v1", "version: v2", 1), wantErr: "version must be v1"},
+		{name: "unknown action", data: strings.Replace(validYAML, "[ssh, database]", "[ssh, rdp]", 1), wantErr: `action "rdp"`},
+		{name: "bad cidr", data: strings.Replace(validYAML, "10.0.0.0/8", "10.0.0.0/33", 1), wantErr: "condition.where.allowed_ip"},
+		{name: "bad country", data: strings.Replace(validYAML, "[NL]", "[Netherlands]", 1), wantErr: "condition.where.country"},
+		{name: "bad email", data: strings.Replace(validYAML, "alice@example.com", "alice", 1), wantErr: "condition.who.email"},
+		{name: "bad date", data: strings.Replace(validYAML, `"2026-01-01"`, `"01/01/2026"`, 1), wantErr: "condition.when.after"},
+		{name: "before earlier than after", data: strings.Replace(validYAML, "before: null", `before: "2025-01-01"`, 1), wantErr: "must be later than"},
+		{name: "bad time of day", data: strings.Replace(validYAML, `"08:00:00 UTC"`, `"8am"`, 1), wantErr: "condition.when.time_of_day_after"},
+		{name: "bad time zone", data: strings.Replace(validYAML, "Europe/Amsterdam", "Mars/Olympus", 1), wantErr: "condition.when.time_of_day_before"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	color.NoColor = true
+
+	old, err := Parse([]byte(validYAML))
+	require.NoError(t, err)
+
+	diff, err := Diff(&old, old)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	changed := old
+	changed.Condition.Where.Country = []string{"US"}
+	diff, err = Diff(&old, changed)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "-     - NL\n")
+	assert.Contains(t, diff, "+     - US\n")
+	assert.Contains(t, diff, "  version: v1\n")
+
+	diff, err = Diff(nil, models.PolicyData{Version: "v1"})
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+ version: v1\n")
+}
@@ -0,0 +1,155 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile keeps using the files in ~/.border0, as before profiles existed
+const DefaultProfile = "default"
+
+var (
+	osUserHomeDir = os.UserHomeDir
+	validName     = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// Profile is a set of endpoints with its own tokens and preferences, so
+// several orgs or environments can be used side by side. Empty endpoints fall
+// back to the defaults
+type Profile struct {
+	Name         string `json:"-"`
+	APIURL       string `json:"api_url,omitempty"`
+	TunnelServer string `json:"tunnel_server,omitempty"`
+	WebURL       string `json:"web_url,omitempty"`
+	// CredentialStore is the backend tokens and keys are stored with
+	CredentialStore string `json:"credential_store,omitempty"`
+}
+
+// Config is the profiles file, ~/.border0/profiles.json
+type Config struct {
+	Current  string             `json:"current,omitempty"`
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+func configFile() (string, error) {
+	home, err := osUserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir: %w", err)
+	}
+
+	return filepath.Join(home, ".border0", "profiles.json"), nil
+}
+
+// Read returns the profiles, an empty config when there is no profiles file
+func Read() (*Config, error) {
+	cfg := &Config{Profiles: map[string]Profile{}}
+
+	path, err := configFile()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+
+	return cfg, nil
+}
+
+func Write(cfg *Config) error {
+	path, err := configFile()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Names returns the names of all profiles, including the default profile
+func (c *Config) Names() []string {
+	names := []string{DefaultProfile}
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+
+	return names
+}
+
+// Get returns the profile, profiles that aren't in the file use the defaults
+func (c *Config) Get(name string) Profile {
+	p := c.Profiles[name]
+	p.Name = name
+	return p
+}
+
+// CurrentName is the profile used by this invocation, the --profile flag and
+// BORDER0_PROFILE take precedence over the profile picked with profile use
+func CurrentName() string {
+	if name := os.Getenv("BORDER0_PROFILE"); name != "" {
+		return name
+	}
+
+	cfg, err := Read()
+	if err == nil && cfg.Current != "" {
+		return cfg.Current
+	}
+
+	return DefaultProfile
+}
+
+// Current returns the profile used by this invocation
+func Current() Profile {
+	cfg, _ := Read()
+	return cfg.Get(CurrentName())
+}
+
+// ValidateName makes sure a profile name can be used in file paths
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q, use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// Path returns the path of a file in a border0 directory for the current
+// profile, the default profile uses the directory itself and other profiles a
+// directory of their own below it
+func Path(dir, file string) string {
+	name := CurrentName()
+	if name == DefaultProfile || ValidateName(name) != nil {
+		return filepath.Join(dir, file)
+	}
+
+	return filepath.Join(dir, "profiles", name, file)
+}
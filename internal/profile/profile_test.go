@@ -0,0 +1,48 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	osUserHomeDir = func() (string, error) { return home, nil }
+	defer func() { osUserHomeDir = os.UserHomeDir }()
+
+	require.NoError(t, Write(&Config{
+		Current:  "staging",
+		Profiles: map[string]Profile{"staging": {APIURL: "https://api.staging.border0.com/api/v1"}},
+	}))
+
+	tests := []struct {
+		name     string
+		env      string
+		wantName string
+		wantPath string
+		wantAPI  string
+	}{
+		{name: "current profile", wantName: "staging", wantPath: filepath.Join("dir", "profiles", "staging", "token"), wantAPI: "https://api.staging.border0.com/api/v1"},
+		{name: "environment", env: "prod", wantName: "prod", wantPath: filepath.Join("dir", "profiles", "prod", "token")},
+		{name: "default", env: DefaultProfile, wantName: DefaultProfile, wantPath: filepath.Join("dir", "token")},
+		{name: "invalid name", env: "../other", wantName: "../other", wantPath: filepath.Join("dir", "token")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BORDER0_PROFILE", tt.env)
+
+			assert.Equal(t, tt.wantName, CurrentName())
+			assert.Equal(t, tt.wantPath, Path("dir", "token"))
+			assert.Equal(t, tt.wantAPI, Current().APIURL)
+		})
+	}
+}
+
+func TestConfig_Names(t *testing.T) {
+	cfg := &Config{Profiles: map[string]Profile{"staging": {}, "dev": {}, DefaultProfile: {}}}
+	assert.Equal(t, []string{DefaultProfile, "dev", "staging"}, cfg.Names())
+}
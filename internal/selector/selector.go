@@ -0,0 +1,147 @@
+package selector
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+)
+
+// Requirement is one key=value or key!=value of a selector, the value can be a glob
+type Requirement struct {
+	Key   string
+	Value string
+	Not   bool
+}
+
+// Selector picks sockets by their fields and tags, a socket matches when it
+// matches all requirements. The keys name, type, upstream_type and dnsname are
+// socket fields, other keys are tags, like connector_name or plugin_name
+type Selector []Requirement
+
+// Parse reads selectors like "type=ssh,env=prod", multiple values are combined.
+// At least one requirement is needed
+func Parse(exprs []string) (Selector, error) {
+	var s Selector
+	for _, expr := range exprs {
+		for _, part := range strings.Split(expr, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			var r Requirement
+			if key, value, ok := strings.Cut(part, "!="); ok {
+				r = Requirement{Key: key, Value: value, Not: true}
+			} else if key, value, ok := strings.Cut(part, "="); ok {
+				r = Requirement{Key: key, Value: value}
+			} else {
+				return nil, fmt.Errorf("invalid selector %q, use key=value or key!=value", part)
+			}
+
+			r.Key = strings.TrimSpace(r.Key)
+			r.Value = strings.TrimSpace(r.Value)
+			if r.Key == "" {
+				return nil, fmt.Errorf("invalid selector %q, the key is empty", part)
+			}
+			if _, err := path.Match(r.Value, ""); err != nil {
+				return nil, fmt.Errorf("invalid selector %q: %w", part, err)
+			}
+
+			s = append(s, r)
+		}
+	}
+	// an empty selector would match every socket
+	if len(s) == 0 {
+		return nil, fmt.Errorf("empty selector, use key=value or key!=value")
+	}
+	return s, nil
+}
+
+// Matches checks if a socket matches all requirements of the selector
+func (s Selector) Matches(socket models.Socket) bool {
+	for _, r := range s {
+		value, ok := lookup(socket, r.Key)
+		matched := false
+		if ok {
+			matched, _ = path.Match(r.Value, value)
+		}
+		if matched == r.Not {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the sockets that match the selector
+func (s Selector) Select(sockets []models.Socket) []models.Socket {
+	var selected []models.Socket
+	for _, socket := range sockets {
+		if s.Matches(socket) {
+			selected = append(selected, socket)
+		}
+	}
+	return selected
+}
+
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		op := "="
+		if r.Not {
+			op = "!="
+		}
+		parts[i] = r.Key + op + r.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+func lookup(socket models.Socket, key string) (string, bool) {
+	switch key {
+	case "name":
+		return socket.Name, true
+	case "type":
+		return socket.SocketType, true
+	case "upstream_type":
+		return socket.UpstreamType, true
+	case "dnsname":
+		return socket.Dnsname, true
+	}
+
+	value, ok := socket.Tags[key]
+	return value, ok
+}
+
+// OlderThan returns the sockets created more than age ago, and the sockets
+// without a creation time, which can't be compared
+func OlderThan(sockets []models.Socket, age time.Duration, now time.Time) (old, unknown []models.Socket) {
+	for _, socket := range sockets {
+		switch {
+		case socket.CreatedAt == nil:
+			unknown = append(unknown, socket)
+		case now.Sub(*socket.CreatedAt) > age:
+			old = append(old, socket)
+		}
+	}
+	return old, unknown
+}
+
+// ParseAge parses a duration like 36h, and also days like 7d
+func ParseAge(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, use a duration like 7d or 36h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, use a duration like 7d or 36h", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,79 @@
+package selector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector(t *testing.T) {
+	sockets := []models.Socket{
+		{Name: "web-1", SocketType: "http", Tags: map[string]string{"connector_name": "lab", "plugin_name": "Ec2Discover"}},
+		{Name: "ssh-1", SocketType: "ssh", Tags: map[string]string{"connector_name": "lab", "plugin_name": "StaticSocketFinder", "env": "prod"}},
+		{Name: "ssh-2", SocketType: "ssh"},
+	}
+
+	tests := []struct {
+		name    string
+		exprs   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "type", exprs: []string{"type=ssh"}, want: []string{"ssh-1", "ssh-2"}},
+		{name: "tag", exprs: []string{"connector_name=lab"}, want: []string{"web-1", "ssh-1"}},
+		{name: "combined", exprs: []string{"connector_name=lab", "type=ssh"}, want: []string{"ssh-1"}},
+		{name: "comma separated", exprs: []string{"connector_name=lab,plugin_name=Ec2Discover"}, want: []string{"web-1"}},
+		{name: "glob", exprs: []string{"name=ssh-*"}, want: []string{"ssh-1", "ssh-2"}},
+		{name: "not, missing tags match", exprs: []string{"env!=prod"}, want: []string{"web-1", "ssh-2"}},
+		{name: "no match", exprs: []string{"env=dev"}},
+		{name: "no operator", exprs: []string{"type"}, wantErr: true},
+		{name: "empty key", exprs: []string{"=ssh"}, wantErr: true},
+		{name: "bad glob", exprs: []string{"name=[ssh"}, wantErr: true},
+		{name: "empty", exprs: []string{""}, wantErr: true},
+		{name: "only separators", exprs: []string{",", " , "}, wantErr: true},
+		{name: "none", exprs: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := Parse(tt.exprs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, s := range sel.Select(sockets) {
+				names = append(names, s.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestOlderThan(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	old, recent := now.AddDate(0, 0, -10), now.Add(-time.Hour)
+	sockets := []models.Socket{{Name: "old", CreatedAt: &old}, {Name: "recent", CreatedAt: &recent}, {Name: "unknown"}}
+
+	age, err := ParseAge("7d")
+	require.NoError(t, err)
+	selected, unknown := OlderThan(sockets, age, now)
+	require.Len(t, selected, 1)
+	assert.Equal(t, "old", selected[0].Name)
+	require.Len(t, unknown, 1)
+	assert.Equal(t, "unknown", unknown[0].Name)
+
+	age, err = ParseAge("30m")
+	require.NoError(t, err)
+	selected, _ = OlderThan(sockets, age, now)
+	assert.Len(t, selected, 2)
+
+	for _, value := range []string{"", "7days", "-1d", "-1h"} {
+		_, err := ParseAge(value)
+		assert.Error(t, err, value)
+	}
+}
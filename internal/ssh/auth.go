@@ -0,0 +1,180 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os/user"
+	"path"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	certPrincipal = "mysocket_ssh_signed"
+
+	sourceAddressOption = "source-address"
+	forceCommandOption  = "force-command"
+)
+
+// lookupUser finds local accounts, tests replace it
+var lookupUser = user.Lookup
+
+// UserConfig controls which local accounts certificate identities may log in as
+type UserConfig struct {
+	// Mapping maps certificate identities (key id or principals, glob patterns
+	// allowed) to the local accounts they may log in as, %u expands to the
+	// local part of an email identity and * allows any account, when the
+	// mapping is empty every identity may log in as any account
+	Mapping map[string][]string
+	// AllowRoot allows logging in as root
+	AllowRoot bool
+}
+
+// ParseUserMapping parses identity=account[,account...] entries into a user mapping
+func ParseUserMapping(entries []string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+	for _, entry := range entries {
+		identity, accounts, ok := strings.Cut(entry, "=")
+		if !ok || identity == "" || accounts == "" {
+			return nil, fmt.Errorf("invalid user mapping %q, expected identity=account[,account...]", entry)
+		}
+
+		for _, account := range strings.Split(accounts, ",") {
+			if account = strings.TrimSpace(account); account != "" {
+				mapping[identity] = append(mapping[identity], account)
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
+// publicKeyHandler verifies the certificate, local is false when the accounts
+// are not on this host, e.g. when sessions are proxied to an upstream server
+func publicKeyHandler(ca string, cfg UserConfig, local bool) ssh.PublicKeyHandler {
+	return func(ctx ssh.Context, key ssh.PublicKey) bool {
+		pubCert, _, _, _, err := ssh.ParseAuthorizedKey([]byte(ca))
+		if err != nil {
+			log.Fatalf("ERROR parsing public cert: %s", err)
+		}
+
+		cert, ok := key.(*gossh.Certificate)
+		if !ok {
+			log.Printf("ERROR: key is not a cert")
+			return false
+		}
+
+		if !bytes.Equal(cert.SignatureKey.Marshal(), pubCert.Marshal()) {
+			// not logging error here because multiple public certs could be given to
+			// ssh server, and some pub certs may not be valid
+			return false
+		}
+
+		certChecker := gossh.CertChecker{
+			SupportedCriticalOptions: []string{forceCommandOption},
+		}
+
+		// CheckCert verifies the validity window and rejects unknown critical options
+		if err = certChecker.CheckCert(certPrincipal, cert); err != nil {
+			log.Printf("failed validating the certificate of %s: %s", cert.KeyId, err)
+			return false
+		}
+
+		if err := checkSourceAddress(ctx.RemoteAddr(), cert.CriticalOptions[sourceAddressOption]); err != nil {
+			log.Printf("denied login for %s: %s", cert.KeyId, err)
+			return false
+		}
+
+		if err := cfg.checkLogin(cert, ctx.User(), local); err != nil {
+			log.Printf("denied login for %s as user %s: %s", cert.KeyId, ctx.User(), err)
+			return false
+		}
+
+		return true
+	}
+}
+
+// checkLogin verifies the certificate identity may log in as the account
+func (c UserConfig) checkLogin(cert *gossh.Certificate, account string, local bool) error {
+	root := account == "root"
+	if local {
+		u, err := lookupUser(account)
+		if err != nil {
+			return fmt.Errorf("could not find user: %w", err)
+		}
+		root = u.Uid == "0"
+	}
+
+	if root && !c.AllowRoot {
+		return fmt.Errorf("root login is not allowed")
+	}
+
+	if len(c.Mapping) == 0 {
+		return nil
+	}
+
+	identities := append([]string{cert.KeyId}, cert.ValidPrincipals...)
+	for pattern, accounts := range c.Mapping {
+		for _, identity := range identities {
+			if matched, _ := path.Match(pattern, identity); !matched {
+				continue
+			}
+
+			for _, allowed := range accounts {
+				localPart, _, _ := strings.Cut(identity, "@")
+				if allowed == "*" || strings.ReplaceAll(allowed, "%u", localPart) == account {
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("identity is not mapped to this user")
+}
+
+// checkSourceAddress verifies the remote address against the comma separated
+// list of addresses or cidrs of the source-address critical option
+func checkSourceAddress(addr net.Addr, sourceAddress string) error {
+	if sourceAddress == "" {
+		return nil
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("could not verify source address of %s", addr)
+	}
+
+	for _, source := range strings.Split(sourceAddress, ",") {
+		if allowedIP := net.ParseIP(source); allowedIP != nil {
+			if allowedIP.Equal(tcpAddr.IP) {
+				return nil
+			}
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return fmt.Errorf("invalid source-address %q: %w", source, err)
+		}
+
+		if network.Contains(tcpAddr.IP) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("source address %s is not allowed", tcpAddr.IP)
+}
+
+// forceCommand returns the command the certificate forces, if any
+func forceCommand(s ssh.Session) string {
+	cert, ok := s.PublicKey().(*gossh.Certificate)
+	if !ok {
+		return ""
+	}
+
+	return cert.CriticalOptions[forceCommandOption]
+}
@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"net"
+	"os/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestParseUserMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{name: "empty", entries: nil, want: map[string][]string{}},
+		{
+			name:    "multiple_accounts",
+			entries: []string{"*@example.com=%u", "admin@example.com=deploy, ubuntu"},
+			want: map[string][]string{
+				"*@example.com":     {"%u"},
+				"admin@example.com": {"deploy", "ubuntu"},
+			},
+		},
+		{name: "missing_account", entries: []string{"admin@example.com="}, wantErr: true},
+		{name: "missing_separator", entries: []string{"admin@example.com"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUserMapping(tt.entries)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckSourceAddress(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 50000}
+
+	tests := []struct {
+		name          string
+		sourceAddress string
+		wantErr       bool
+	}{
+		{name: "no_option", sourceAddress: ""},
+		{name: "matching_ip", sourceAddress: "10.1.2.3"},
+		{name: "matching_cidr", sourceAddress: "192.168.0.0/16,10.0.0.0/8"},
+		{name: "not_matching", sourceAddress: "192.168.0.0/16", wantErr: true},
+		{name: "invalid", sourceAddress: "not-an-address", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSourceAddress(addr, tt.sourceAddress)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestUserConfig_checkLogin(t *testing.T) {
+	// toor is an alias of root, with uid 0
+	accounts := map[string]string{"root": "0", "toor": "0", "alice": "1000", "deploy": "1001"}
+	lookupUser = func(name string) (*user.User, error) {
+		uid, ok := accounts[name]
+		if !ok {
+			return nil, user.UnknownUserError(name)
+		}
+		return &user.User{Username: name, Uid: uid}, nil
+	}
+	defer func() { lookupUser = user.Lookup }()
+
+	cert := &gossh.Certificate{KeyId: "alice@example.com", ValidPrincipals: []string{certPrincipal}}
+	mapping := map[string][]string{"*@example.com": {"%u"}, "admin@example.com": {"*"}}
+
+	tests := []struct {
+		name    string
+		cfg     UserConfig
+		account string
+		local   bool
+		wantErr string
+	}{
+		{name: "root_denied", cfg: UserConfig{}, account: "root", local: true, wantErr: "root login is not allowed"},
+		{name: "root_allowed", cfg: UserConfig{AllowRoot: true}, account: "root", local: true},
+		{name: "uid_0_alias_denied", cfg: UserConfig{}, account: "toor", local: true, wantErr: "root login is not allowed"},
+		{name: "uid_0_alias_allowed", cfg: UserConfig{AllowRoot: true}, account: "toor", local: true},
+		{name: "root_denied_upstream", cfg: UserConfig{}, account: "root", wantErr: "root login is not allowed"},
+		{name: "unknown_local_user", cfg: UserConfig{}, account: "bob", local: true, wantErr: "could not find user"},
+		{name: "no_mapping", cfg: UserConfig{}, account: "deploy", local: true},
+		{name: "mapped_identity", cfg: UserConfig{Mapping: mapping}, account: "alice", local: true},
+		{name: "unmapped_account", cfg: UserConfig{Mapping: mapping}, account: "deploy", local: true, wantErr: "identity is not mapped to this user"},
+		{name: "unmapped_identity", cfg: UserConfig{Mapping: map[string][]string{"*@border0.com": {"*"}}}, account: "alice", local: true, wantErr: "identity is not mapped to this user"},
+		{name: "mapped_principal", cfg: UserConfig{Mapping: map[string][]string{certPrincipal: {"deploy"}}}, account: "deploy", local: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.checkLogin(cert, tt.account, tt.local)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
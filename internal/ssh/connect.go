@@ -18,10 +18,12 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/borderzero/border0-cli/internal/api"
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/dbproxy"
 	border0_http "github.com/borderzero/border0-cli/internal/http"
 	"github.com/cenkalti/backoff/v4"
 	gssh "github.com/gliderlabs/ssh"
@@ -49,6 +51,13 @@ type Connection struct {
 	closed     bool
 	numOfRetry int
 	api        api.API
+
+	// target is where plain tcp connections are forwarded to, it can be
+	// changed while the tunnel is connected
+	targetMu   sync.Mutex
+	targetHost string
+	targetPort int
+	forwarding bool
 }
 
 func NewConnection(logger *zap.Logger, api api.API, opts ...ConnectionOption) *Connection {
@@ -61,11 +70,16 @@ func NewConnection(logger *zap.Logger, api api.API, opts ...ConnectionOption) *C
 	return connection
 }
 
-func (c *Connection) Connect(ctx context.Context, userID string, socketID string, tunnelID string, port int, targethost string, identityFile string, proxyHost string, version string, localssh, httpserver bool, sshCa string, accessToken, httpdir string, connectorAuthRequired bool, caCertPool *x509.CertPool) error {
+func (c *Connection) Connect(ctx context.Context, userID string, socketID string, tunnelID string, port int, targethost string, identityFile string, proxyHost string, version string, localssh, httpserver bool, sshCa string, accessToken, httpdir string, connectorAuthRequired bool, caCertPool *x509.CertPool, serverConfig *ServerConfig, dbProxy *dbproxy.Proxy) error {
 	c.socketID = socketID
 	c.tunnelID = tunnelID
 	var tunnel *models.Tunnel
 
+	c.targetMu.Lock()
+	c.targetHost, c.targetPort = targethost, port
+	c.forwarding = !localssh && !httpserver && dbProxy == nil
+	c.targetMu.Unlock()
+
 	c.api.StartRefreshAccessTokenJob(ctx)
 
 	if tunnelID != "" {
@@ -146,7 +160,7 @@ func (c *Connection) Connect(ctx context.Context, userID string, socketID string
 		c.logger.Info("Connecting to Server", zap.String("server", sshServer()))
 		time.Sleep(1 * time.Second)
 
-		err = c.connect(ctx, proxyDialer, sshConfig, tunnel, port, targethost, localssh, httpserver, sshCa, httpdir, connectorAuthRequired, c.socketID, caCertPool)
+		err = c.connect(ctx, proxyDialer, sshConfig, tunnel, localssh, httpserver, sshCa, httpdir, connectorAuthRequired, c.socketID, caCertPool, serverConfig, dbProxy)
 		if err != nil {
 			// abort retry when session is disconnected or it's already connected in the tcp port
 			if errors.Is(err, ErrListenOnPort) || errors.Is(err, ErrSessionDisconnected) {
@@ -167,7 +181,7 @@ func (c *Connection) Connect(ctx context.Context, userID string, socketID string
 	return errors.New("ssh session disconnected")
 }
 
-func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshConfig *ssh.ClientConfig, tunnel *models.Tunnel, port int, targethost string, localssh, httpserver bool, sshCa, httpdir string, connectorAuthRequired bool, socketID string, caCertPool *x509.CertPool) error {
+func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshConfig *ssh.ClientConfig, tunnel *models.Tunnel, localssh, httpserver bool, sshCa, httpdir string, connectorAuthRequired bool, socketID string, caCertPool *x509.CertPool, serverConfig *ServerConfig, dbProxy *dbproxy.Proxy) error {
 	remoteHost := net.JoinHostPort(sshServer(), "22")
 
 	conn, err := proxyDialer.Dial("tcp", remoteHost)
@@ -280,7 +294,7 @@ func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshC
 
 	var sshServer *gssh.Server
 	if localssh {
-		sshServer = newServer(sshCa)
+		sshServer = newServer(sshCa, serverConfig)
 	}
 
 	if httpserver {
@@ -295,6 +309,7 @@ func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshC
 				}
 
 				go func() {
+					var identity string
 					if connectorAuthRequired {
 						tlsConn := tls.Server(client, tlsConfig)
 						if err = tlsConn.Handshake(); err != nil {
@@ -307,13 +322,17 @@ func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshC
 							log.Printf("Failed to complete handshake: %s", err)
 							return
 						}
-						log.Printf("client %s authenticated", tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+						identity = tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName
+						log.Printf("client %s authenticated", identity)
 						time.Sleep(200 * time.Millisecond)
 					}
 
 					if localssh {
 						go sshServer.HandleConn(client)
+					} else if dbProxy != nil {
+						go dbProxy.HandleConn(client, identity)
 					} else {
+						targethost, port := c.target()
 						local, err := net.Dial("tcp", fmt.Sprintf("%s:%d", targethost, port))
 						if err != nil {
 							c.logger.Error("Dial INTO local service error", zap.Error(err))
@@ -342,6 +361,29 @@ func (c *Connection) connect(ctx context.Context, proxyDialer proxy.Dialer, sshC
 	return nil
 }
 
+// Retarget changes where new connections of the tunnel are forwarded to,
+// connections that are already open are kept. It returns false when the
+// tunnel doesn't forward plain tcp, like with the local ssh server or a
+// database proxy, those have to reconnect to change their upstream
+func (c *Connection) Retarget(targethost string, port int) bool {
+	c.targetMu.Lock()
+	defer c.targetMu.Unlock()
+
+	if !c.forwarding {
+		return false
+	}
+
+	c.targetHost, c.targetPort = targethost, port
+	return true
+}
+
+func (c *Connection) target() (string, int) {
+	c.targetMu.Lock()
+	defer c.targetMu.Unlock()
+
+	return c.targetHost, c.targetPort
+}
+
 func (c *Connection) Close() {
 	if c.session != nil {
 		if err := c.session.Close(); err != nil {
@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const defaultExecShell = "/bin/sh"
+
+// errNoExecUser is returned without a container user, running sessions as the
+// requested login would let it pick any user of the container, e.g. uid 0
+var errNoExecUser = errors.New("a container user is required")
+
+// dockerExecClient is the part of the docker client the exec backend uses
+type dockerExecClient interface {
+	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error)
+	ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+	Close() error
+}
+
+// DockerExec runs ssh sessions with docker exec in a container
+type DockerExec struct {
+	ContainerID string
+	// User runs the sessions as this container user, required
+	User string
+	// Shell is used for interactive sessions and to run commands, defaults to /bin/sh
+	Shell string
+
+	newClient func() (dockerExecClient, error)
+	// killProcess ends the exec process when the session ends before it
+	killProcess func(pid int) error
+}
+
+var _ ExecBackend = (*DockerExec)(nil)
+
+func (d *DockerExec) Name() string {
+	return fmt.Sprintf("docker container %s", d.ContainerID)
+}
+
+func (d *DockerExec) Exec(ctx context.Context, opts ExecOptions) (int, error) {
+	if d.User == "" {
+		return 0, errNoExecUser
+	}
+
+	newClient := d.newClient
+	if newClient == nil {
+		newClient = func() (dockerExecClient, error) {
+			return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		}
+	}
+
+	cli, err := newClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	exec, err := cli.ContainerExecCreate(ctx, d.ContainerID, types.ExecConfig{
+		User:         d.User,
+		Tty:          opts.Tty,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          opts.Env,
+		Cmd:          execCommand(d.Shell, opts.Command),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec in container %s: %w", d.ContainerID, err)
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: opts.Tty})
+	if err != nil {
+		return 0, fmt.Errorf("failed to attach to exec in container %s: %w", d.ContainerID, err)
+	}
+	defer resp.Close()
+
+	if opts.Resize != nil {
+		go func() {
+			for win := range opts.Resize {
+				cli.ContainerExecResize(ctx, exec.ID, types.ResizeOptions{Width: uint(win.Width), Height: uint(win.Height)})
+			}
+		}()
+	}
+
+	go func() {
+		io.Copy(resp.Conn, opts.Stdin)
+		resp.CloseWrite()
+	}()
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		if opts.Tty {
+			io.Copy(opts.Stdout, resp.Reader)
+		} else {
+			stdcopy.StdCopy(opts.Stdout, opts.Stderr, resp.Reader)
+		}
+	}()
+
+	select {
+	case <-output:
+	case <-ctx.Done():
+		// docker has no way to stop an exec, so the process is killed when it
+		// outlives the session
+		resp.Close()
+		d.stop(cli, exec.ID)
+		return 0, ctx.Err()
+	}
+
+	inspect, err := cli.ContainerExecInspect(context.Background(), exec.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec in container %s: %w", d.ContainerID, err)
+	}
+
+	return inspect.ExitCode, nil
+}
+
+// stop kills the exec process if it's still running
+func (d *DockerExec) stop(cli dockerExecClient, execID string) {
+	inspect, err := cli.ContainerExecInspect(context.Background(), execID)
+	if err != nil || !inspect.Running || inspect.Pid <= 0 {
+		return
+	}
+
+	killProcess := d.killProcess
+	if killProcess == nil {
+		killProcess = func(pid int) error {
+			process, err := os.FindProcess(pid)
+			if err != nil {
+				return err
+			}
+			return process.Kill()
+		}
+	}
+
+	killProcess(inspect.Pid)
+}
+
+// execCommand returns the command line for a session, the shell itself for
+// interactive sessions or the shell running the command otherwise
+func execCommand(shell, command string) []string {
+	if shell == "" {
+		shell = defaultExecShell
+	}
+
+	if command == "" {
+		return []string{shell}
+	}
+
+	return []string{shell, "-c", command}
+}
@@ -0,0 +1,171 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDockerClient struct {
+	config  types.ExecConfig
+	stdout  string
+	stderr  string
+	inspect types.ContainerExecInspect
+	// running makes the exec run until the attach connection is closed
+	running bool
+}
+
+func (c *fakeDockerClient) ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error) {
+	c.config = config
+	return types.IDResponse{ID: "exec-1"}, nil
+}
+
+func (c *fakeDockerClient) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		if c.running {
+			io.Copy(io.Discard, server)
+			return
+		}
+		if config.Tty {
+			server.Write([]byte(c.stdout))
+			return
+		}
+		stdcopy.NewStdWriter(server, stdcopy.Stdout).Write([]byte(c.stdout))
+		stdcopy.NewStdWriter(server, stdcopy.Stderr).Write([]byte(c.stderr))
+	}()
+
+	return types.HijackedResponse{Conn: client, Reader: bufio.NewReader(client)}, nil
+}
+
+func (c *fakeDockerClient) ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error {
+	return nil
+}
+
+func (c *fakeDockerClient) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	return c.inspect, nil
+}
+
+func (c *fakeDockerClient) Close() error {
+	return nil
+}
+
+func TestExecCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		shell   string
+		command string
+		want    []string
+	}{
+		{name: "default_shell", want: []string{"/bin/sh"}},
+		{name: "shell", shell: "/bin/bash", want: []string{"/bin/bash"}},
+		{name: "command", command: "uptime", want: []string{"/bin/sh", "-c", "uptime"}},
+		{name: "command_in_shell", shell: "/bin/bash", command: "ls -la", want: []string{"/bin/bash", "-c", "ls -la"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, execCommand(tt.shell, tt.command))
+		})
+	}
+}
+
+func TestDockerExec_Exec(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         ExecOptions
+		client       fakeDockerClient
+		wantStdout   string
+		wantStderr   string
+		wantExitCode int
+	}{
+		{
+			name:         "command",
+			opts:         ExecOptions{User: "root", Command: "uptime"},
+			client:       fakeDockerClient{stdout: "up 1 day", stderr: "warning", inspect: types.ContainerExecInspect{ExitCode: 0}},
+			wantStdout:   "up 1 day",
+			wantStderr:   "warning",
+			wantExitCode: 0,
+		},
+		{
+			name:         "exit_code",
+			opts:         ExecOptions{User: "root", Command: "false"},
+			client:       fakeDockerClient{inspect: types.ContainerExecInspect{ExitCode: 1}},
+			wantExitCode: 1,
+		},
+		{
+			name:         "tty",
+			opts:         ExecOptions{User: "root", Tty: true},
+			client:       fakeDockerClient{stdout: "$ ", inspect: types.ContainerExecInspect{ExitCode: 130}},
+			wantStdout:   "$ ",
+			wantExitCode: 130,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := tt.client
+			backend := &DockerExec{
+				ContainerID: "web",
+				User:        "app",
+				newClient:   func() (dockerExecClient, error) { return &client, nil },
+			}
+
+			var stdout, stderr bytes.Buffer
+			tt.opts.Stdin = strings.NewReader("")
+			tt.opts.Stdout, tt.opts.Stderr = &stdout, &stderr
+
+			exitCode, err := backend.Exec(context.Background(), tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantExitCode, exitCode)
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantStderr, stderr.String())
+			// the session runs as the container user, not the requested login
+			assert.Equal(t, "app", client.config.User)
+			assert.Equal(t, execCommand("", tt.opts.Command), client.config.Cmd)
+		})
+	}
+}
+
+func TestDockerExec_ExecCancelled(t *testing.T) {
+	client := &fakeDockerClient{running: true, inspect: types.ContainerExecInspect{Running: true, Pid: 4242}}
+
+	var killed int
+	backend := &DockerExec{
+		ContainerID: "web",
+		User:        "app",
+		newClient:   func() (dockerExecClient, error) { return client, nil },
+		killProcess: func(pid int) error {
+			killed = pid
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := backend.Exec(ctx, ExecOptions{Stdin: strings.NewReader(""), Stdout: io.Discard, Stderr: io.Discard})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 4242, killed)
+}
+
+func TestDockerExec_RequiresUser(t *testing.T) {
+	backend := &DockerExec{
+		ContainerID: "web",
+		newClient: func() (dockerExecClient, error) {
+			t.Fatal("no exec without a container user")
+			return nil, nil
+		},
+	}
+
+	_, err := backend.Exec(context.Background(), ExecOptions{User: "root"})
+	assert.ErrorIs(t, err, errNoExecUser)
+}
@@ -0,0 +1,100 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// ExecBackend runs ssh sessions somewhere other than the local host, e.g. in a container
+type ExecBackend interface {
+	// Exec runs the command, or an interactive shell when the command is empty,
+	// and returns its exit code
+	Exec(ctx context.Context, opts ExecOptions) (int, error)
+	// Name describes where sessions run, used for logging
+	Name() string
+}
+
+// ExecOptions describes a session to run on an ExecBackend
+type ExecOptions struct {
+	// User is the login the ssh user requested
+	User    string
+	Command string
+	Env     []string
+	Tty     bool
+	Term    string
+	// Resize receives the window size changes of the tty, the first one is the initial size
+	Resize <-chan Window
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Window is the size of the terminal of a session
+type Window struct {
+	Width  int
+	Height int
+}
+
+func execBackendHandler(cfg *ServerConfig, sessions *sessionCounter) ssh.Handler {
+	return func(s ssh.Session) {
+		cert, ok := s.PublicKey().(*gossh.Certificate)
+		if !ok {
+			log.Printf("could not get user certificate")
+			return
+		}
+
+		session, done, ok := startSession(s, cert, cfg, sessions)
+		if !ok {
+			return
+		}
+		defer done()
+
+		log.Printf("new ssh session for %s (as user %s) in %s\n", cert.KeyId, s.User(), cfg.Exec.Name())
+
+		command := s.RawCommand()
+		if forced := forceCommand(s); forced != "" {
+			command = forced
+		}
+
+		opts := ExecOptions{
+			User:    s.User(),
+			Command: command,
+			Env:     s.Environ(),
+			Stdin:   session,
+			Stdout:  session,
+			Stderr:  session.Stderr(),
+		}
+
+		ptyReq, winCh, isPty := s.Pty()
+		if isPty {
+			resize := make(chan Window, 1)
+			resize <- Window{Width: ptyReq.Window.Width, Height: ptyReq.Window.Height}
+			go func() {
+				defer close(resize)
+				for win := range winCh {
+					resize <- Window{Width: win.Width, Height: win.Height}
+				}
+			}()
+
+			opts.Tty = true
+			opts.Term = ptyReq.Term
+			opts.Resize = resize
+			opts.Env = append(opts.Env, "TERM="+ptyReq.Term)
+		}
+
+		exitCode, err := cfg.Exec.Exec(session.Context(), opts)
+		if err != nil {
+			log.Printf("ssh session for %s in %s failed: %s", cert.KeyId, cfg.Exec.Name(), err)
+			fmt.Fprintf(s.Stderr(), "Border0: %s\r\n", err)
+			s.Exit(1)
+			return
+		}
+
+		s.Exit(exitCode)
+	}
+}
@@ -0,0 +1,126 @@
+package ssh
+
+import (
+	"log"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// ForwardingConfig holds the port and agent forwarding settings of the
+// built-in ssh server, entries of the allowlists are host:port pairs where
+// host can be a hostname, a glob pattern, an ip or a cidr, and port can be a
+// single port, a range (e.g. 8000-8080) or *
+type ForwardingConfig struct {
+	// LocalAllowed lists the destinations local port forwarding (ssh -L) may connect to
+	LocalAllowed []string
+	// RemoteAllowed lists the addresses remote port forwarding (ssh -R) may listen on
+	RemoteAllowed []string
+	// Agent enables agent forwarding (ssh -A)
+	Agent bool
+}
+
+func localPortForwardingCallback(cfg ForwardingConfig) ssh.LocalPortForwardingCallback {
+	return func(ctx ssh.Context, host string, port uint32) bool {
+		if addrAllowed(cfg.LocalAllowed, host, port) {
+			log.Printf("port forwarding to %s for %s", net.JoinHostPort(host, strconv.Itoa(int(port))), ctx.User())
+			return true
+		}
+
+		log.Printf("denied port forwarding to %s for %s", net.JoinHostPort(host, strconv.Itoa(int(port))), ctx.User())
+		return false
+	}
+}
+
+func reversePortForwardingCallback(cfg ForwardingConfig) ssh.ReversePortForwardingCallback {
+	return func(ctx ssh.Context, host string, port uint32) bool {
+		if addrAllowed(cfg.RemoteAllowed, host, port) {
+			log.Printf("reverse port forwarding on %s for %s", net.JoinHostPort(host, strconv.Itoa(int(port))), ctx.User())
+			return true
+		}
+
+		log.Printf("denied reverse port forwarding on %s for %s", net.JoinHostPort(host, strconv.Itoa(int(port))), ctx.User())
+		return false
+	}
+}
+
+// forwardAgent starts forwarding agent connections for the session when the
+// client requested it, it returns the SSH_AUTH_SOCK path and a cleanup function
+func forwardAgent(s ssh.Session, uid, gid uint64) (string, func()) {
+	l, err := ssh.NewAgentListener()
+	if err != nil {
+		log.Printf("could not start agent listener: %s", err)
+		return "", func() {}
+	}
+
+	sock := l.Addr().String()
+	dir := filepath.Dir(sock)
+
+	// the session runs as the mapped user, so it needs access to the socket
+	os.Chown(dir, int(uid), int(gid))
+	os.Chown(sock, int(uid), int(gid))
+
+	go ssh.ForwardAgentConnections(l, s)
+
+	return sock, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func addrAllowed(allowlist []string, host string, port uint32) bool {
+	for _, entry := range allowlist {
+		allowedHost, allowedPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			log.Printf("invalid forwarding allowlist entry %q: %s", entry, err)
+			continue
+		}
+
+		if hostMatches(allowedHost, host) && portMatches(allowedPort, port) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hostMatches(pattern, host string) bool {
+	if pattern == "*" || strings.EqualFold(pattern, host) {
+		return true
+	}
+
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host))
+	return matched
+}
+
+func portMatches(pattern string, port uint32) bool {
+	if pattern == "*" {
+		return true
+	}
+
+	if from, to, ok := strings.Cut(pattern, "-"); ok {
+		low, err := strconv.ParseUint(from, 10, 32)
+		if err != nil {
+			return false
+		}
+		high, err := strconv.ParseUint(to, 10, 32)
+		if err != nil {
+			return false
+		}
+
+		return uint64(port) >= low && uint64(port) <= high
+	}
+
+	p, err := strconv.ParseUint(pattern, 10, 32)
+	return err == nil && uint32(p) == port
+}
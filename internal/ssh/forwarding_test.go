@@ -0,0 +1,34 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddrAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		host      string
+		port      uint32
+		want      bool
+	}{
+		{name: "empty_allowlist", allowlist: nil, host: "localhost", port: 22, want: false},
+		{name: "exact_match", allowlist: []string{"localhost:5432"}, host: "localhost", port: 5432, want: true},
+		{name: "wrong_port", allowlist: []string{"localhost:5432"}, host: "localhost", port: 5433, want: false},
+		{name: "any_host_any_port", allowlist: []string{"*:*"}, host: "db.internal", port: 1, want: true},
+		{name: "cidr", allowlist: []string{"10.0.0.0/8:22"}, host: "10.1.2.3", port: 22, want: true},
+		{name: "cidr_no_match", allowlist: []string{"10.0.0.0/8:22"}, host: "192.168.1.1", port: 22, want: false},
+		{name: "glob", allowlist: []string{"*.internal:443"}, host: "api.internal", port: 443, want: true},
+		{name: "port_range", allowlist: []string{"127.0.0.1:8000-8080"}, host: "127.0.0.1", port: 8080, want: true},
+		{name: "port_range_outside", allowlist: []string{"127.0.0.1:8000-8080"}, host: "127.0.0.1", port: 8081, want: false},
+		{name: "ipv6", allowlist: []string{"[::1]:22"}, host: "::1", port: 22, want: true},
+		{name: "invalid_entry", allowlist: []string{"localhost"}, host: "localhost", port: 22, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, addrAllowed(tt.allowlist, tt.host, tt.port))
+		})
+	}
+}
@@ -27,7 +27,9 @@ import (
 
 	"github.com/borderzero/border0-cli/internal/api"
 	"github.com/borderzero/border0-cli/internal/api/models"
+	"github.com/borderzero/border0-cli/internal/credstore"
 	border0_http "github.com/borderzero/border0-cli/internal/http"
+	"github.com/borderzero/border0-cli/internal/profile"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 	"golang.org/x/net/proxy"
@@ -52,9 +54,16 @@ type httpProxy struct {
 	forward  proxy.Dialer
 }
 
+// TunnelServer is the tunnel server sockets connect to
+func TunnelServer() string {
+	return sshServer()
+}
+
 func sshServer() string {
 	if os.Getenv("BORDER0_TUNNEL") != "" {
 		return os.Getenv("BORDER0_TUNNEL")
+	} else if server := profile.Current().TunnelServer; server != "" {
+		return server
 	} else {
 		return "tunnel.border0.com"
 	}
@@ -67,17 +76,11 @@ func getSshCert(userId string, socketID string, accessToken string, numOfRetry i
 		return s, fmt.Errorf("error: failed to get home dir: %w", err)
 	}
 
-	privateKeyFile := home + "/.border0"
-	if _, err := os.Stat(privateKeyFile); os.IsNotExist(err) {
-		err := os.Mkdir(privateKeyFile, 0700)
-		if err != nil {
-			return s, fmt.Errorf("error: could not create directory: %w", err)
-		}
-	}
-
-	privateKeyFile = home + "/.border0/user_" + userId
+	store := credstore.New()
+	privateKeyFile := home + "/.border0/user_" + userId
 
-	if _, err := os.Stat(privateKeyFile); os.IsNotExist(err) {
+	keyContent, err := store.Read(privateKeyFile)
+	if errors.Is(err, credstore.ErrNotFound) {
 		// Let's create a key pair
 
 		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -90,20 +93,11 @@ func getSshCert(userId string, socketID string, accessToken string, numOfRetry i
 			return s, fmt.Errorf("error: failed to create ssh key: %v", err)
 		}
 
-		keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: parsed})
-		err = ioutil.WriteFile(privateKeyFile, keyPem, 0600)
-		if err != nil {
+		keyContent = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: parsed})
+		if err := store.Write(privateKeyFile, keyContent); err != nil {
 			return s, fmt.Errorf("error: failed to write ssh key: %v", err)
 		}
-	}
-	// Ok now let's load the key
-
-	if _, err := os.Stat(privateKeyFile); os.IsNotExist(err) {
-		return s, fmt.Errorf("error: failed to load private ssh key: %v", err)
-	}
-	// read private key from file
-	keyContent, _ := ioutil.ReadFile(privateKeyFile)
-	if err != nil {
+	} else if err != nil {
 		return s, fmt.Errorf("error: failed to load private ssh key: %v", err)
 	}
 
@@ -168,7 +162,7 @@ func getSshCert(userId string, socketID string, accessToken string, numOfRetry i
 	return certSigner, nil
 }
 
-func SshConnect(userID string, socketID string, tunnelID string, port int, targethost string, identityFile string, proxyHost string, version string, localhttp, localssh bool, sshCa string, accessToken, httpdir string, connectorAuthRequired bool, caCertPool *x509.CertPool) error {
+func SshConnect(userID string, socketID string, tunnelID string, port int, targethost string, identityFile string, proxyHost string, version string, localhttp, localssh bool, sshCa string, accessToken, httpdir string, connectorAuthRequired bool, caCertPool *x509.CertPool, serverConfig *ServerConfig) error {
 	var tunnel *models.Tunnel
 	var err error
 
@@ -260,11 +254,11 @@ func SshConnect(userID string, socketID string, tunnelID string, port int, targe
 		fmt.Println("\nConnecting to Server: " + sshServer() + "\n")
 		time.Sleep(1 * time.Second)
 
-		sshConnect(proxyDialer, sshConfig, tunnel, port, targethost, localhttp, localssh, sshCa, httpdir, connectorAuthRequired, caCertPool, socketID)
+		sshConnect(proxyDialer, sshConfig, tunnel, port, targethost, localhttp, localssh, sshCa, httpdir, connectorAuthRequired, caCertPool, socketID, serverConfig)
 	}
 }
 
-func sshConnect(proxyDialer proxy.Dialer, sshConfig *ssh.ClientConfig, tunnel *models.Tunnel, port int, targethost string, localhttp, localssh bool, sshCa, httpDir string, connectorAuthRequired bool, caCertPool *x509.CertPool, socketID string) {
+func sshConnect(proxyDialer proxy.Dialer, sshConfig *ssh.ClientConfig, tunnel *models.Tunnel, port int, targethost string, localhttp, localssh bool, sshCa, httpDir string, connectorAuthRequired bool, caCertPool *x509.CertPool, socketID string, serverConfig *ServerConfig) {
 	remoteHost := net.JoinHostPort(sshServer(), "22")
 
 	conn, err := proxyDialer.Dial("tcp", remoteHost)
@@ -377,7 +371,7 @@ func sshConnect(proxyDialer proxy.Dialer, sshConfig *ssh.ClientConfig, tunnel *m
 
 	var sshServer *gssh.Server
 	if localssh {
-		sshServer = newServer(sshCa)
+		sshServer = newServer(sshCa, serverConfig)
 	}
 
 	if localhttp {
@@ -0,0 +1,124 @@
+package ssh
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// hostKeyTypes are the host keys the built-in ssh server uses, in order of preference
+var hostKeyTypes = []string{"ed25519", "ecdsa"}
+
+// DefaultHostKeyDir returns the directory the host keys of the built-in ssh
+// server are stored in when no directory is configured
+func DefaultHostKeyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home dir: %w", err)
+	}
+
+	return filepath.Join(home, ".border0"), nil
+}
+
+// LoadHostKeys reads the host keys of the built-in ssh server from dir,
+// keys that don't exist yet are generated and stored, so they stay the same
+// across restarts
+func LoadHostKeys(dir string) ([]gossh.Signer, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultHostKeyDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create host key directory: %w", err)
+	}
+
+	var signers []gossh.Signer
+	for _, keyType := range hostKeyTypes {
+		signer, err := loadOrCreateHostKey(filepath.Join(dir, fmt.Sprintf("ssh_host_%s_key", keyType)), keyType)
+		if err != nil {
+			return nil, err
+		}
+
+		signers = append(signers, signer)
+	}
+
+	return signers, nil
+}
+
+func loadOrCreateHostKey(path, keyType string) (gossh.Signer, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err == nil {
+		signer, err := gossh.ParsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host key %s: %w", path, err)
+		}
+
+		return signer, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read host key %s: %w", path, err)
+	}
+
+	var key crypto.Signer
+	var block *pem.Block
+
+	switch keyType {
+	case "ed25519":
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 host key: %w", err)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		key = privateKey
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	case "ecdsa":
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ecdsa host key: %w", err)
+		}
+
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+
+		key = privateKey
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		return nil, fmt.Errorf("unsupported host key type %s", keyType)
+	}
+
+	signer, err := gossh.NewSignerFromSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host key signer: %w", err)
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write host key %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path+".pub", gossh.MarshalAuthorizedKey(signer.PublicKey()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write host public key %s.pub: %w", path, err)
+	}
+
+	return signer, nil
+}
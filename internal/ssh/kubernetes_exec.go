@@ -0,0 +1,206 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// KubernetesExec runs ssh sessions with pods/exec in a pod, either a named pod
+// or the first running pod matching the label selector, one of them is required
+type KubernetesExec struct {
+	Clientset kubernetes.Interface
+	// RESTClient builds the pods/exec requests, the core v1 client of the clientset
+	RESTClient rest.Interface
+	Config     *rest.Config
+
+	Namespace     string
+	Pod           string
+	LabelSelector string
+	// Container is the container of the pod to exec in, defaults to the first container
+	Container string
+	// Shell is used for interactive sessions and to run commands, defaults to /bin/sh
+	Shell string
+
+	newExecutor func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error)
+}
+
+var _ ExecBackend = (*KubernetesExec)(nil)
+
+// errNoPodSelector is returned without a pod or label selector, an empty
+// selector would pick any pod of the namespace
+var errNoPodSelector = errors.New("a pod name or label selector is required")
+
+// NewKubernetesExec returns a kubernetes exec backend for the cluster of the config
+func NewKubernetesExec(config *rest.Config, namespace, pod, labelSelector, container, shell string) (*KubernetesExec, error) {
+	if pod == "" && labelSelector == "" {
+		return nil, errNoPodSelector
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return &KubernetesExec{
+		Clientset:     clientset,
+		RESTClient:    clientset.CoreV1().RESTClient(),
+		Config:        config,
+		Namespace:     namespace,
+		Pod:           pod,
+		LabelSelector: labelSelector,
+		Container:     container,
+		Shell:         shell,
+	}, nil
+}
+
+func (k *KubernetesExec) Name() string {
+	if k.Pod != "" {
+		return fmt.Sprintf("kubernetes pod %s/%s", k.Namespace, k.Pod)
+	}
+
+	return fmt.Sprintf("kubernetes pods %s/%s", k.Namespace, k.LabelSelector)
+}
+
+func (k *KubernetesExec) Exec(ctx context.Context, opts ExecOptions) (int, error) {
+	pod, err := k.findPod(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	command := execCommand(k.Shell, opts.Command)
+	if opts.Tty && opts.Term != "" {
+		// pods/exec has no environment, so the terminal type is passed with env
+		command = append([]string{"env", "TERM=" + opts.Term}, command...)
+	}
+
+	req := k.RESTClient.Post().
+		Resource("pods").
+		Namespace(k.Namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: k.Container,
+			Command:   command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    !opts.Tty,
+			TTY:       opts.Tty,
+		}, scheme.ParameterCodec)
+
+	newExecutor := k.newExecutor
+	if newExecutor == nil {
+		newExecutor = remotecommand.NewSPDYExecutor
+	}
+
+	executor, err := newExecutor(k.Config, "POST", req.URL())
+	if err != nil {
+		return 0, fmt.Errorf("failed to create executor for pod %s: %w", pod, err)
+	}
+
+	// the stream can't be cancelled, so its stdin is closed when the session
+	// ends, which ends the command in the pod, and its output is dropped
+	stdin, stdinWriter := io.Pipe()
+	go func() {
+		io.Copy(stdinWriter, opts.Stdin)
+		stdinWriter.Close()
+	}()
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: contextWriter{ctx: ctx, w: opts.Stdout},
+		Tty:    opts.Tty,
+	}
+	if !opts.Tty {
+		streamOpts.Stderr = contextWriter{ctx: ctx, w: opts.Stderr}
+	}
+	if opts.Resize != nil {
+		streamOpts.TerminalSizeQueue = terminalSizeQueue(opts.Resize)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Stream(streamOpts)
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		stdinWriter.Close()
+		return 0, ctx.Err()
+	}
+
+	var exitErr utilexec.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus(), nil
+	default:
+		return 0, fmt.Errorf("exec in pod %s failed: %w", pod, err)
+	}
+}
+
+func (k *KubernetesExec) findPod(ctx context.Context) (string, error) {
+	if k.Pod != "" {
+		return k.Pod, nil
+	}
+	if k.LabelSelector == "" {
+		return "", errNoPodSelector
+	}
+
+	pods, err := k.Clientset.CoreV1().Pods(k.Namespace).List(ctx, metav1.ListOptions{LabelSelector: k.LabelSelector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var running []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodRunning && pod.DeletionTimestamp == nil {
+			running = append(running, pod.Name)
+		}
+	}
+
+	if len(running) == 0 {
+		return "", fmt.Errorf("no running pod found for selector %q in namespace %s", k.LabelSelector, k.Namespace)
+	}
+
+	sort.Strings(running)
+	return running[0], nil
+}
+
+// contextWriter stops writing once the context is done
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c contextWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
+
+// terminalSizeQueue adapts the session window changes to remotecommand
+type terminalSizeQueue <-chan Window
+
+func (q terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	win, ok := <-q
+	if !ok {
+		return nil
+	}
+
+	return &remotecommand.TerminalSize{Width: uint16(win.Width), Height: uint16(win.Height)}
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	fakerest "k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+type fakeExecutor struct {
+	url    *url.URL
+	output string
+	err    error
+	opts   remotecommand.StreamOptions
+	// stdinClosed makes the stream run until its stdin is closed
+	stdinClosed chan struct{}
+}
+
+func (e *fakeExecutor) Stream(opts remotecommand.StreamOptions) error {
+	e.opts = opts
+	if e.stdinClosed != nil {
+		// runs like a shell until its stdin is closed
+		io.Copy(io.Discard, opts.Stdin)
+		close(e.stdinClosed)
+		return nil
+	}
+	opts.Stdout.Write([]byte(e.output))
+	return e.err
+}
+
+func pod(name, app string, phase v1.PodPhase) runtime.Object {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+}
+
+func TestKubernetesExec_Exec(t *testing.T) {
+	tests := []struct {
+		name         string
+		backend      KubernetesExec
+		opts         ExecOptions
+		executorErr  error
+		wantPod      string
+		wantCommand  []string
+		wantTty      bool
+		wantExitCode int
+		wantErr      bool
+	}{
+		{
+			name:        "command_in_first_running_pod",
+			backend:     KubernetesExec{Namespace: "default", LabelSelector: "app=web"},
+			opts:        ExecOptions{Command: "uptime"},
+			wantPod:     "web-1",
+			wantCommand: []string{"/bin/sh", "-c", "uptime"},
+		},
+		{
+			name:        "interactive_shell_in_named_pod",
+			backend:     KubernetesExec{Namespace: "default", Pod: "web-2", Container: "app", Shell: "/bin/bash"},
+			opts:        ExecOptions{Tty: true, Term: "xterm"},
+			wantPod:     "web-2",
+			wantCommand: []string{"env", "TERM=xterm", "/bin/bash"},
+			wantTty:     true,
+		},
+		{
+			name:         "exit_code",
+			backend:      KubernetesExec{Namespace: "default", LabelSelector: "app=web"},
+			opts:         ExecOptions{Command: "false"},
+			executorErr:  utilexec.CodeExitError{Err: assert.AnError, Code: 3},
+			wantPod:      "web-1",
+			wantCommand:  []string{"/bin/sh", "-c", "false"},
+			wantExitCode: 3,
+		},
+		{
+			name:    "no_pod_or_selector",
+			backend: KubernetesExec{Namespace: "default"},
+			opts:    ExecOptions{Command: "uptime"},
+			wantErr: true,
+		},
+		{
+			name:    "no_running_pod",
+			backend: KubernetesExec{Namespace: "default", LabelSelector: "app=db"},
+			opts:    ExecOptions{Command: "uptime"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &fakeExecutor{output: "ok", err: tt.executorErr}
+
+			backend := tt.backend
+			backend.Clientset = fake.NewSimpleClientset(
+				pod("web-2", "web", v1.PodRunning),
+				pod("web-1", "web", v1.PodRunning),
+				pod("web-0", "web", v1.PodPending),
+				pod("db-0", "db", v1.PodFailed),
+			)
+			backend.RESTClient = &fakerest.RESTClient{
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				GroupVersion:         v1.SchemeGroupVersion,
+				VersionedAPIPath:     "/api/v1",
+			}
+			backend.newExecutor = func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+				executor.url = url
+				return executor, nil
+			}
+
+			var stdout bytes.Buffer
+			opts := tt.opts
+			opts.Stdin = strings.NewReader("")
+			opts.Stdout = &stdout
+			opts.Stderr = &bytes.Buffer{}
+
+			exitCode, err := backend.Exec(context.Background(), opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantExitCode, exitCode)
+			assert.Equal(t, "ok", stdout.String())
+			assert.Equal(t, "/api/v1/namespaces/default/pods/"+tt.wantPod+"/exec", executor.url.Path)
+			assert.Equal(t, tt.wantCommand, executor.url.Query()["command"])
+			assert.Equal(t, tt.backend.Container, executor.url.Query().Get("container"))
+			assert.Equal(t, tt.wantTty, executor.opts.Tty)
+			assert.Equal(t, tt.wantTty, executor.opts.Stderr == nil)
+		})
+	}
+}
+
+func TestKubernetesExec_ExecCancelled(t *testing.T) {
+	executor := &fakeExecutor{stdinClosed: make(chan struct{})}
+	backend := KubernetesExec{
+		Namespace: "default",
+		Pod:       "web-1",
+		RESTClient: &fakerest.RESTClient{
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			GroupVersion:         v1.SchemeGroupVersion,
+			VersionedAPIPath:     "/api/v1",
+		},
+		newExecutor: func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+			return executor, nil
+		},
+	}
+
+	// the session stdin stays open, the stream ends with the session
+	sessionStdin, _ := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := backend.Exec(ctx, ExecOptions{Stdin: sessionStdin, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-executor.stdinClosed:
+	case <-time.After(time.Second):
+		t.Fatal("the stdin of the exec wasn't closed")
+	}
+}
+
+func TestNewKubernetesExec_RequiresPodOrSelector(t *testing.T) {
+	_, err := NewKubernetesExec(&rest.Config{Host: "https://127.0.0.1"}, "default", "", "", "", "")
+	assert.ErrorIs(t, err, errNoPodSelector)
+}
@@ -0,0 +1,171 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+)
+
+const defaultWarningBefore = time.Minute
+
+// limitCheckInterval is how often the session limits are checked, tests shorten it
+var limitCheckInterval = time.Second
+
+// SessionLimits holds the session limits of the built-in ssh server, zero
+// values disable the limit
+type SessionLimits struct {
+	// IdleTimeout closes sessions without any input or output for this long
+	IdleTimeout time.Duration
+	// MaxDuration closes sessions after this long regardless of activity
+	MaxDuration time.Duration
+	// MaxSessionsPerIdentity limits the concurrent sessions of a certificate identity
+	MaxSessionsPerIdentity int
+	// WarningBefore is how long before closing a session a warning is shown, defaults to one minute
+	WarningBefore time.Duration
+}
+
+func (l SessionLimits) enabled() bool {
+	return l.IdleTimeout > 0 || l.MaxDuration > 0
+}
+
+// sessionCounter counts the open sessions per identity
+type sessionCounter struct {
+	mu       sync.Mutex
+	sessions map[string]int
+}
+
+func newSessionCounter() *sessionCounter {
+	return &sessionCounter{sessions: make(map[string]int)}
+}
+
+func (c *sessionCounter) acquire(identity string, max int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sessions[identity] >= max {
+		return false
+	}
+
+	c.sessions[identity]++
+	return true
+}
+
+func (c *sessionCounter) release(identity string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessions[identity]--
+	if c.sessions[identity] <= 0 {
+		delete(c.sessions, identity)
+	}
+}
+
+// limitedSession tracks the activity of a session and ends it when the idle
+// timeout or the maximum duration is reached
+type limitedSession struct {
+	ssh.Session
+	ctx          context.Context
+	lastActivity int64
+}
+
+func (s *limitedSession) Read(p []byte) (int, error) {
+	atomic.StoreInt64(&s.lastActivity, time.Now().UnixNano())
+	return s.Session.Read(p)
+}
+
+func (s *limitedSession) Write(p []byte) (int, error) {
+	atomic.StoreInt64(&s.lastActivity, time.Now().UnixNano())
+	return s.Session.Write(p)
+}
+
+func (s *limitedSession) Stderr() io.ReadWriter {
+	return &activityReadWriter{ReadWriter: s.Session.Stderr(), lastActivity: &s.lastActivity}
+}
+
+// Context is canceled when a session limit is reached
+func (s *limitedSession) Context() context.Context {
+	return s.ctx
+}
+
+type activityReadWriter struct {
+	io.ReadWriter
+	lastActivity *int64
+}
+
+func (rw *activityReadWriter) Write(p []byte) (int, error) {
+	atomic.StoreInt64(rw.lastActivity, time.Now().UnixNano())
+	return rw.ReadWriter.Write(p)
+}
+
+// limitSession enforces the idle timeout and maximum duration on the session,
+// the returned function stops the enforcement
+func limitSession(s ssh.Session, limits SessionLimits) (ssh.Session, func()) {
+	if !limits.enabled() {
+		return s, func() {}
+	}
+
+	warningBefore := limits.WarningBefore
+	if warningBefore <= 0 {
+		warningBefore = defaultWarningBefore
+	}
+	if limits.IdleTimeout > 0 && warningBefore >= limits.IdleTimeout {
+		warningBefore = limits.IdleTimeout / 2
+	}
+
+	ctx, cancel := context.WithCancel(s.Context())
+	session := &limitedSession{
+		Session:      s,
+		ctx:          ctx,
+		lastActivity: time.Now().UnixNano(),
+	}
+
+	// the banner goes to stdout for interactive sessions so it shows on the terminal
+	banner := s.Stderr()
+	if _, _, isPty := s.Pty(); isPty {
+		banner = s
+	}
+
+	interval := limitCheckInterval
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var warned time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				deadline, reason := time.Time{}, ""
+				if limits.MaxDuration > 0 {
+					deadline, reason = start.Add(limits.MaxDuration), "maximum session duration"
+				}
+				if limits.IdleTimeout > 0 {
+					idleDeadline := time.Unix(0, atomic.LoadInt64(&session.lastActivity)).Add(limits.IdleTimeout)
+					if deadline.IsZero() || idleDeadline.Before(deadline) {
+						deadline, reason = idleDeadline, "idle timeout"
+					}
+				}
+
+				if !now.Before(deadline) {
+					fmt.Fprintf(banner, "\r\nBorder0: closing session (%s)\r\n", reason)
+					cancel()
+					return
+				}
+
+				if !now.Before(deadline.Add(-warningBefore)) && !warned.Equal(deadline) {
+					fmt.Fprintf(banner, "\r\nBorder0: session will be closed in %s (%s)\r\n", deadline.Sub(now).Round(time.Second), reason)
+					warned = deadline
+				}
+			}
+		}
+	}()
+
+	return session, cancel
+}
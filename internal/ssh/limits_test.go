@@ -0,0 +1,178 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCounter(t *testing.T) {
+	counter := newSessionCounter()
+
+	assert.True(t, counter.acquire("alice@example.com", 2))
+	assert.True(t, counter.acquire("alice@example.com", 2))
+	assert.False(t, counter.acquire("alice@example.com", 2))
+	assert.True(t, counter.acquire("bob@example.com", 2))
+
+	counter.release("alice@example.com")
+	assert.True(t, counter.acquire("alice@example.com", 2))
+
+	counter.release("bob@example.com")
+	assert.NotContains(t, counter.sessions, "bob@example.com")
+}
+
+// syncBuffer is written by the limit goroutine while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type fakeSession struct {
+	ssh.Session
+	ctx    context.Context
+	pty    bool
+	stdout syncBuffer
+	stderr syncBuffer
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeSession) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (s *fakeSession) Write(p []byte) (int, error) {
+	return s.stdout.Write(p)
+}
+
+func (s *fakeSession) Stderr() io.ReadWriter {
+	return &s.stderr
+}
+
+func (s *fakeSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	return ssh.Pty{}, nil, s.pty
+}
+
+func newFakeSession(t *testing.T, pty bool) *fakeSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &fakeSession{ctx: ctx, pty: pty}
+}
+
+func TestLimitSession(t *testing.T) {
+	limitCheckInterval = 5 * time.Millisecond
+	defer func() { limitCheckInterval = time.Second }()
+
+	tests := []struct {
+		name   string
+		limits SessionLimits
+		pty    bool
+		// activity writes to the session for this long
+		activity    time.Duration
+		wantClosed  time.Duration
+		wantMessage string
+	}{
+		{
+			name:        "idle_timeout",
+			limits:      SessionLimits{IdleTimeout: 100 * time.Millisecond, WarningBefore: 75 * time.Millisecond},
+			wantClosed:  100 * time.Millisecond,
+			wantMessage: "closing session (idle timeout)",
+		},
+		{
+			name:        "activity_postpones_idle_timeout",
+			limits:      SessionLimits{IdleTimeout: 100 * time.Millisecond, WarningBefore: 75 * time.Millisecond},
+			activity:    150 * time.Millisecond,
+			wantClosed:  250 * time.Millisecond,
+			wantMessage: "closing session (idle timeout)",
+		},
+		{
+			name:        "max_duration",
+			limits:      SessionLimits{IdleTimeout: time.Minute, MaxDuration: 100 * time.Millisecond, WarningBefore: 75 * time.Millisecond},
+			activity:    300 * time.Millisecond,
+			wantClosed:  100 * time.Millisecond,
+			wantMessage: "closing session (maximum session duration)",
+		},
+		{
+			name:        "banner_on_tty",
+			limits:      SessionLimits{MaxDuration: 100 * time.Millisecond, WarningBefore: 75 * time.Millisecond},
+			pty:         true,
+			wantClosed:  100 * time.Millisecond,
+			wantMessage: "closing session (maximum session duration)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeSession(t, tt.pty)
+			start := time.Now()
+
+			session, stop := limitSession(s, tt.limits)
+			defer stop()
+
+			for time.Since(start) < tt.activity && session.Context().Err() == nil {
+				session.Write([]byte("."))
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			select {
+			case <-session.Context().Done():
+			case <-time.After(time.Second):
+				t.Fatal("session was not closed")
+			}
+			assert.GreaterOrEqual(t, time.Since(start), tt.wantClosed)
+
+			banner := &s.stderr
+			if tt.pty {
+				banner = &s.stdout
+			}
+			assert.Contains(t, banner.String(), "session will be closed in")
+			assert.Contains(t, banner.String(), tt.wantMessage)
+		})
+	}
+}
+
+func TestLimitSession_Disabled(t *testing.T) {
+	s := newFakeSession(t, false)
+
+	session, stop := limitSession(s, SessionLimits{MaxSessionsPerIdentity: 2})
+	defer stop()
+
+	assert.Equal(t, ssh.Session(s), session)
+}
+
+func TestLimitSession_Stop(t *testing.T) {
+	limitCheckInterval = 5 * time.Millisecond
+	defer func() { limitCheckInterval = time.Second }()
+
+	s := newFakeSession(t, false)
+
+	_, stop := limitSession(s, SessionLimits{IdleTimeout: 50 * time.Millisecond})
+	stop()
+
+	// the session ended before its limits, it isn't warned or closed later
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, s.stderr.String())
+}
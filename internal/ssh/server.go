@@ -1,9 +1,6 @@
 package ssh
 
 import (
-	"bytes"
-	"crypto/rand"
-	"crypto/rsa"
 	"errors"
 	"fmt"
 	"log"
@@ -19,8 +16,28 @@ import (
 	gossh "golang.org/x/crypto/ssh"
 )
 
-func newServer(ca string) *ssh.Server {
-	handler := ssh.Handler(func(s ssh.Session) {
+// ServerConfig holds the settings of the built-in ssh server
+type ServerConfig struct {
+	// HostKeyDir is where the host keys are stored, defaults to ~/.border0
+	HostKeyDir string
+	Users      UserConfig
+	Sftp       SftpConfig
+	Forwarding ForwardingConfig
+	Limits     SessionLimits
+	// Upstream proxies sessions to an upstream ssh server instead of running them locally
+	Upstream *UpstreamConfig
+	// Exec runs sessions on an exec backend, e.g. in a container, instead of locally
+	Exec ExecBackend
+}
+
+func newServer(ca string, cfg *ServerConfig) *ssh.Server {
+	if cfg == nil {
+		cfg = &ServerConfig{}
+	}
+
+	sessions := newSessionCounter()
+
+	var handler ssh.Handler = func(s ssh.Session) {
 		user, err := user.Lookup(s.User())
 		if err != nil {
 			log.Printf("could not find user: %s", err)
@@ -45,6 +62,12 @@ func newServer(ca string) *ssh.Server {
 			return
 		}
 
+		session, done, ok := startSession(s, cert, cfg, sessions)
+		if !ok {
+			return
+		}
+		defer done()
+
 		log.Printf("new ssh session for %s (as user %s)\n", cert.KeyId, s.User())
 
 		uid, _ := strconv.ParseUint(user.Uid, 10, 32)
@@ -59,17 +82,39 @@ func newServer(ca string) *ssh.Server {
 
 		cmd.Dir = user.HomeDir
 
-		execCmd(s, cmd, uid, gid)
-	})
+		if cfg.Forwarding.Agent && ssh.AgentRequested(s) {
+			sock, cleanup := forwardAgent(s, uid, gid)
+			defer cleanup()
+			if sock != "" {
+				cmd.Env = append(cmd.Env, "SSH_AUTH_SOCK="+sock)
+			}
+		}
 
-	key, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		log.Fatalf("could not generate rsa key: %s", err)
+		command := s.RawCommand()
+		if forced := forceCommand(s); forced != "" {
+			cmd.Env = append(cmd.Env, "SSH_ORIGINAL_COMMAND="+command)
+			command = forced
+		}
+
+		execCmd(session, cmd, uid, gid, command)
 	}
 
-	signer, err := gossh.NewSignerFromKey(key)
+	if cfg.Upstream != nil {
+		handler = upstreamHandler(cfg, sessions)
+	}
+
+	if cfg.Exec != nil {
+		handler = execBackendHandler(cfg, sessions)
+	}
+
+	hostSigners, err := LoadHostKeys(cfg.HostKeyDir)
 	if err != nil {
-		log.Fatalf("could not generate signer: %s", err)
+		log.Fatalf("could not load host keys: %s", err)
+	}
+
+	var signers []ssh.Signer
+	for _, signer := range hostSigners {
+		signers = append(signers, signer)
 	}
 
 	requestHandlers := map[string]ssh.RequestHandler{}
@@ -87,44 +132,68 @@ func newServer(ca string) *ssh.Server {
 		subsystemHandlers[k] = v
 	}
 
-	return &ssh.Server{
-		Version:     "Border0-ssh-server",
-		HostSigners: []ssh.Signer{signer},
-		Handler:     handler,
-		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool {
-			pubCert, _, _, _, err := ssh.ParseAuthorizedKey([]byte(ca))
-			if err != nil {
-				log.Fatalf("ERROR parsing public cert: %s", err)
-			}
+	// forwards are dialed from this host, which isn't where upstream and exec
+	// backend sessions run
+	local := cfg.Upstream == nil && cfg.Exec == nil
 
-			cert, ok := key.(*gossh.Certificate)
-			if !ok {
-				log.Printf("ERROR: key is not a cert")
-				return false
-			}
+	if local && len(cfg.Users.Mapping) == 0 {
+		log.Printf("WARNING: no user mapping configured, any identity allowed by the socket's policies may log in as any local user except root")
+	}
 
-			if !bytes.Equal(cert.SignatureKey.Marshal(), pubCert.Marshal()) {
-				// not logging error here because multiple public certs could be given to
-				// ssh server, and some pub certs may not be valid
-				return false
-			}
+	if local && len(cfg.Forwarding.LocalAllowed) > 0 {
+		channelHandlers["direct-tcpip"] = ssh.DirectTCPIPHandler
+	}
 
-			var certChecker gossh.CertChecker
+	if local && len(cfg.Forwarding.RemoteAllowed) > 0 {
+		forwardHandler := &ssh.ForwardedTCPHandler{}
+		requestHandlers["tcpip-forward"] = forwardHandler.HandleSSHRequest
+		requestHandlers["cancel-tcpip-forward"] = forwardHandler.HandleSSHRequest
+	}
 
-			err = certChecker.CheckCert("mysocket_ssh_signed", cert)
-			if err != nil {
-				log.Println("failed validating the certificate")
-				return false
-			}
+	// sftp is not supported on exec backends
+	if !cfg.Sftp.Disabled && cfg.Exec == nil {
+		if cfg.Upstream != nil {
+			subsystemHandlers["sftp"] = upstreamSftpHandler(cfg, sessions)
+		} else {
+			subsystemHandlers["sftp"] = sftpHandler(cfg, sessions)
+		}
+	}
 
-			return true
-		},
-		RequestHandlers:   requestHandlers,
-		ChannelHandlers:   channelHandlers,
-		SubsystemHandlers: subsystemHandlers,
+	return &ssh.Server{
+		Version:                       "Border0-ssh-server",
+		HostSigners:                   signers,
+		Handler:                       handler,
+		PublicKeyHandler:              publicKeyHandler(ca, cfg.Users, local),
+		RequestHandlers:               requestHandlers,
+		ChannelHandlers:               channelHandlers,
+		SubsystemHandlers:             subsystemHandlers,
+		LocalPortForwardingCallback:   localPortForwardingCallback(cfg.Forwarding),
+		ReversePortForwardingCallback: reversePortForwardingCallback(cfg.Forwarding),
 	}
 }
 
+// startSession enforces the concurrent session limit and starts enforcing the
+// idle and duration limits, done must be called when the session ends
+func startSession(s ssh.Session, cert *gossh.Certificate, cfg *ServerConfig, sessions *sessionCounter) (ssh.Session, func(), bool) {
+	if cfg.Limits.MaxSessionsPerIdentity > 0 {
+		if !sessions.acquire(cert.KeyId, cfg.Limits.MaxSessionsPerIdentity) {
+			log.Printf("denied ssh session for %s: too many concurrent sessions", cert.KeyId)
+			fmt.Fprintf(s.Stderr(), "Border0: maximum of %d concurrent sessions reached\r\n", cfg.Limits.MaxSessionsPerIdentity)
+			s.Exit(1)
+			return nil, nil, false
+		}
+	}
+
+	session, stop := limitSession(s, cfg.Limits)
+
+	return session, func() {
+		stop()
+		if cfg.Limits.MaxSessionsPerIdentity > 0 {
+			sessions.release(cert.KeyId)
+		}
+	}, true
+}
+
 func getShell(user *user.User) (string, error) {
 	switch runtime.GOOS {
 	case "linux", "openbsd", "freebsd":
@@ -19,7 +19,7 @@ import (
 	"github.com/opencontainers/selinux/go-selinux"
 )
 
-func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
+func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64, command string) {
 
 	euid := os.Geteuid()
 	var loginCmd string
@@ -28,14 +28,14 @@ func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
 	}
 	sysProcAttr := &syscall.SysProcAttr{}
 
-	if len(s.Command()) > 0 {
+	if command != "" {
 		sysProcAttr.Credential = &syscall.Credential{
 			Uid:         uint32(uid),
 			Gid:         uint32(gid),
 			NoSetGroups: true,
 		}
 
-		cmd.Args = append(cmd.Args, "-c", s.RawCommand())
+		cmd.Args = append(cmd.Args, "-c", command)
 	} else {
 		if euid == 0 && loginCmd != "" {
 			cmd.Path = loginCmd
@@ -122,6 +122,17 @@ func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
 			log.Printf("failed to start command %v\n", err)
 			return
 		}
+
+		exited := make(chan struct{})
+		defer close(exited)
+		go func() {
+			select {
+			case <-s.Context().Done():
+				cmd.Process.Signal(syscall.SIGHUP)
+			case <-exited:
+			}
+		}()
+
 		go func() {
 			defer stdin.Close()
 			if _, err := io.Copy(stdin, s); err != nil {
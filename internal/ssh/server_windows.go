@@ -15,7 +15,7 @@ import (
 	"syscall"
 )
 
-func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
+func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64, command string) {
 	ptyReq, winCh, isPty := s.Pty()
 
 	vsn := windows.RtlGetVersion()
@@ -24,8 +24,8 @@ func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
 		return
 	}
 
-	if len(s.Command()) > 0 {
-		cmd.Args = append(cmd.Args, "/C", s.RawCommand())
+	if command != "" {
+		cmd.Args = append(cmd.Args, "/C", command)
 	}
 
 	if isPty {
@@ -124,6 +124,17 @@ func execCmd(s ssh.Session, cmd exec.Cmd, uid, gid uint64) {
 			log.Printf("failed to start command %v\n", err)
 			return
 		}
+
+		exited := make(chan struct{})
+		defer close(exited)
+		go func() {
+			select {
+			case <-s.Context().Done():
+				cmd.Process.Kill()
+			case <-exited:
+			}
+		}()
+
 		go func() {
 			defer stdin.Close()
 			if _, err := io.Copy(stdin, s); err != nil {
@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"log"
+	"os/user"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// SftpConfig holds the settings of the sftp subsystem of the built-in ssh server
+type SftpConfig struct {
+	// Disabled turns the sftp subsystem off
+	Disabled bool
+	// Chroot is the directory sftp sessions are confined to, %u is replaced by
+	// the username and %h by the home directory of the user
+	Chroot string
+	// ReadOnly rejects every request that would modify the filesystem
+	ReadOnly bool
+}
+
+func (c SftpConfig) chrootDir(u *user.User) string {
+	if c.Chroot == "" {
+		return ""
+	}
+
+	return strings.NewReplacer("%u", u.Username, "%h", u.HomeDir).Replace(c.Chroot)
+}
+
+func sftpHandler(cfg *ServerConfig, sessions *sessionCounter) ssh.SubsystemHandler {
+	return func(s ssh.Session) {
+		user, err := user.Lookup(s.User())
+		if err != nil {
+			log.Printf("could not find user: %s", err)
+			s.Exit(1)
+			return
+		}
+
+		cert, ok := s.PublicKey().(*gossh.Certificate)
+		if !ok {
+			log.Printf("could not get user certificate")
+			s.Exit(1)
+			return
+		}
+
+		if forceCommand(s) != "" {
+			log.Printf("denied sftp session for %s: certificate forces a command", cert.KeyId)
+			s.Exit(1)
+			return
+		}
+
+		session, done, ok := startSession(s, cert, cfg, sessions)
+		if !ok {
+			return
+		}
+		defer done()
+
+		log.Printf("new sftp session for %s (as user %s)\n", cert.KeyId, s.User())
+
+		if err := serveSftp(session, user, cfg.Sftp); err != nil {
+			log.Printf("sftp session for %s failed: %s", s.User(), err)
+			s.Exit(1)
+			return
+		}
+
+		s.Exit(0)
+	}
+}
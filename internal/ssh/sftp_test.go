@@ -0,0 +1,29 @@
+package ssh
+
+import (
+	"os/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSftpConfig_chrootDir(t *testing.T) {
+	u := &user.User{Username: "alice", HomeDir: "/home/alice"}
+
+	tests := []struct {
+		name   string
+		chroot string
+		want   string
+	}{
+		{name: "no_chroot", chroot: "", want: ""},
+		{name: "fixed", chroot: "/srv/sftp", want: "/srv/sftp"},
+		{name: "username", chroot: "/srv/sftp/%u", want: "/srv/sftp/alice"},
+		{name: "home", chroot: "%h/uploads", want: "/home/alice/uploads"},
+		{name: "both", chroot: "/chroot%h/%u", want: "/chroot/home/alice/alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SftpConfig{Chroot: tt.chroot}.chrootDir(u))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+//go:build !windows
+// +build !windows
+
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/pkg/sftp"
+)
+
+// SftpServerCommand is the name of the hidden command that serves a single
+// sftp session on stdin/stdout, the ssh server runs it as a child process so
+// the session can be chrooted and run with the uid/gid of the mapped user
+const SftpServerCommand = "sftp-server"
+
+func serveSftp(s ssh.Session, u *user.User, cfg SftpConfig) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find executable: %w", err)
+	}
+
+	cmd := exec.Cmd{
+		Path:   executable,
+		Args:   sftpServerArgs(executable, u, cfg),
+		Stdin:  s,
+		Stdout: s,
+		Stderr: s.Stderr(),
+		Env: []string{
+			"HOME=" + u.HomeDir,
+			"USER=" + u.Username,
+		},
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-s.Context().Done():
+		cmd.Process.Signal(syscall.SIGHUP)
+		return <-done
+	}
+}
+
+// sftpServerArgs returns the command line of the sftp-server child of a session
+func sftpServerArgs(executable string, u *user.User, cfg SftpConfig) []string {
+	args := []string{executable, SftpServerCommand, "--user", u.Username}
+	if chroot := cfg.chrootDir(u); chroot != "" {
+		args = append(args, "--chroot", chroot)
+	}
+	if cfg.ReadOnly {
+		args = append(args, "--readonly")
+	}
+
+	return args
+}
+
+// ServeSftp serves sftp on stdin/stdout as the given user, optionally chrooted
+// into dir, switching uid/gid requires the process to run as root
+func ServeSftp(username, chroot string, readOnly bool) error {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return fmt.Errorf("could not find user: %w", err)
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid uid %s: %w", u.Uid, err)
+	}
+
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid %s: %w", u.Gid, err)
+	}
+
+	var groups []int
+	groupIDs, _ := u.GroupIds()
+	for _, g := range groupIDs {
+		if id, err := strconv.Atoi(g); err == nil {
+			groups = append(groups, id)
+		}
+	}
+
+	euid := os.Geteuid()
+	if err := checkPrivileges(euid, uid, chroot); err != nil {
+		return fmt.Errorf("cannot serve sftp as user %s: %w", username, err)
+	}
+
+	if chroot != "" {
+		if err := syscall.Chroot(chroot); err != nil {
+			return fmt.Errorf("could not chroot to %s: %w", chroot, err)
+		}
+		if err := os.Chdir("/"); err != nil {
+			return err
+		}
+	} else if err := os.Chdir(u.HomeDir); err != nil {
+		return fmt.Errorf("could not change to home directory: %w", err)
+	}
+
+	if euid == 0 {
+		if err := syscall.Setgroups(groups); err != nil {
+			return fmt.Errorf("could not set groups: %w", err)
+		}
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("could not set gid: %w", err)
+		}
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("could not set uid: %w", err)
+		}
+	}
+
+	return serveSftpOn(stdio{os.Stdin, os.Stdout}, readOnly)
+}
+
+// checkPrivileges makes sure the process can run as the user, without root
+// only the user itself can serve sftp and chroot isn't possible
+func checkPrivileges(euid, uid int, chroot string) error {
+	if euid == 0 {
+		return nil
+	}
+	if chroot != "" {
+		return errors.New("chroot requires root privileges")
+	}
+	if euid != uid {
+		return errors.New("switching users requires root privileges")
+	}
+
+	return nil
+}
+
+// serveSftpOn serves sftp on the stream until the client closes it
+func serveSftpOn(rw io.ReadWriteCloser, readOnly bool) error {
+	var opts []sftp.ServerOption
+	if readOnly {
+		opts = append(opts, sftp.ReadOnly())
+	}
+
+	server, err := sftp.NewServer(rw, opts...)
+	if err != nil {
+		return err
+	}
+
+	if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+type stdio struct {
+	io.Reader
+	io.WriteCloser
+}
@@ -0,0 +1,106 @@
+//go:build !windows
+// +build !windows
+
+package ssh
+
+import (
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSftpServerArgs(t *testing.T) {
+	u := &user.User{Username: "alice", HomeDir: "/home/alice"}
+
+	tests := []struct {
+		name string
+		cfg  SftpConfig
+		want []string
+	}{
+		{name: "defaults", want: []string{"/usr/bin/border0", SftpServerCommand, "--user", "alice"}},
+		{name: "read_only", cfg: SftpConfig{ReadOnly: true}, want: []string{"/usr/bin/border0", SftpServerCommand, "--user", "alice", "--readonly"}},
+		{
+			name: "chroot",
+			cfg:  SftpConfig{Chroot: "%h", ReadOnly: true},
+			want: []string{"/usr/bin/border0", SftpServerCommand, "--user", "alice", "--chroot", "/home/alice", "--readonly"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sftpServerArgs("/usr/bin/border0", u, tt.cfg))
+		})
+	}
+}
+
+func TestCheckPrivileges(t *testing.T) {
+	tests := []struct {
+		name    string
+		euid    int
+		uid     int
+		chroot  string
+		wantErr bool
+	}{
+		{name: "root", euid: 0, uid: 1000, chroot: "/srv/sftp"},
+		{name: "same_user", euid: 1000, uid: 1000},
+		{name: "other_user", euid: 1000, uid: 1001, wantErr: true},
+		{name: "chroot_without_root", euid: 1000, uid: 1000, chroot: "/srv/sftp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPrivileges(tt.euid, tt.uid, tt.chroot)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestServeSftpOn(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("hello"), 0600))
+
+	tests := []struct {
+		name      string
+		readOnly  bool
+		wantWrite bool
+	}{
+		{name: "read_write", wantWrite: true},
+		{name: "read_only", readOnly: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientReader, serverWriter := io.Pipe()
+			serverReader, clientWriter := io.Pipe()
+
+			served := make(chan error, 1)
+			go func() {
+				err := serveSftpOn(stdio{serverReader, serverWriter}, tt.readOnly)
+				// the client waits for the end of its stream when closing
+				serverWriter.Close()
+				served <- err
+			}()
+
+			client, err := sftp.NewClientPipe(clientReader, clientWriter)
+			require.NoError(t, err)
+
+			f, err := client.Open(filepath.Join(dir, "existing.txt"))
+			require.NoError(t, err)
+			data, err := io.ReadAll(f)
+			f.Close()
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+
+			name := filepath.Join(dir, tt.name+".txt")
+			_, err = client.Create(name)
+			assert.Equal(t, tt.wantWrite, err == nil)
+			assert.Equal(t, tt.wantWrite, client.Mkdir(filepath.Join(dir, tt.name)) == nil)
+
+			client.Close()
+			assert.NoError(t, <-served)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"errors"
+	"io"
+	"os/user"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/pkg/sftp"
+)
+
+// SftpServerCommand is the name of the hidden command that serves a single
+// sftp session on stdin/stdout
+const SftpServerCommand = "sftp-server"
+
+func serveSftp(s ssh.Session, u *user.User, cfg SftpConfig) error {
+	if cfg.chrootDir(u) != "" {
+		return errors.New("sftp chroot is not supported on windows")
+	}
+
+	var opts []sftp.ServerOption
+	if cfg.ReadOnly {
+		opts = append(opts, sftp.ReadOnly())
+	}
+
+	server, err := sftp.NewServer(s, opts...)
+	if err != nil {
+		return err
+	}
+
+	// ends the session when a session limit is reached
+	served := make(chan struct{})
+	defer close(served)
+	go func() {
+		select {
+		case <-s.Context().Done():
+			server.Close()
+		case <-served:
+		}
+	}()
+
+	if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// ServeSftp is not supported on windows, sftp sessions are served in-process
+func ServeSftp(username, chroot string, readOnly bool) error {
+	return errors.New("sftp-server is not supported on windows")
+}
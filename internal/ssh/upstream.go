@@ -0,0 +1,281 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// UpstreamConfig makes the ssh server terminate the certificate session and
+// open its own session to an upstream ssh server with the credentials held here
+type UpstreamConfig struct {
+	Host string
+	Port int
+	// Username is the upstream login, defaults to the login the user requested
+	Username string
+	Password string
+	// PrivateKey is a PEM encoded private key, PrivateKeyFile a path to one
+	PrivateKey     string
+	PrivateKeyFile string
+	// KnownHostsFile verifies the upstream host key, it's required unless
+	// InsecureIgnoreHostKey is set
+	KnownHostsFile string
+	// InsecureIgnoreHostKey accepts any upstream host key, which allows the
+	// upstream server to be impersonated
+	InsecureIgnoreHostKey bool
+}
+
+func (c *UpstreamConfig) address() string {
+	port := c.Port
+	if port == 0 {
+		port = 22
+	}
+
+	return net.JoinHostPort(c.Host, strconv.Itoa(port))
+}
+
+func (c *UpstreamConfig) clientConfig(login string) (*gossh.ClientConfig, error) {
+	var auth []gossh.AuthMethod
+
+	keyPEM := []byte(c.PrivateKey)
+	if len(keyPEM) == 0 && c.PrivateKeyFile != "" {
+		var err error
+		if keyPEM, err = os.ReadFile(c.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read upstream private key: %w", err)
+		}
+	}
+
+	if len(keyPEM) > 0 {
+		signer, err := gossh.ParsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse upstream private key: %w", err)
+		}
+		auth = append(auth, gossh.PublicKeys(signer))
+	}
+
+	if c.Password != "" {
+		auth = append(auth, gossh.Password(c.Password))
+	}
+
+	if len(auth) == 0 {
+		return nil, errors.New("no upstream credentials configured")
+	}
+
+	var hostKeyCallback gossh.HostKeyCallback
+	switch {
+	case c.KnownHostsFile != "":
+		var err error
+		if hostKeyCallback, err = knownhosts.New(c.KnownHostsFile); err != nil {
+			return nil, fmt.Errorf("failed to read upstream known hosts: %w", err)
+		}
+	case c.InsecureIgnoreHostKey:
+		log.Printf("WARNING: not verifying the host key of upstream %s", c.address())
+		hostKeyCallback = gossh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("no upstream known hosts file configured")
+	}
+
+	username := c.Username
+	if username == "" {
+		username = login
+	}
+
+	return &gossh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         defaultTimeout,
+	}, nil
+}
+
+func (c *UpstreamConfig) dial(login string) (*gossh.Client, error) {
+	clientConfig, err := c.clientConfig(login)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := gossh.Dial("tcp", c.address(), clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to upstream %s: %w", c.address(), err)
+	}
+
+	return client, nil
+}
+
+func upstreamHandler(cfg *ServerConfig, sessions *sessionCounter) ssh.Handler {
+	return func(s ssh.Session) {
+		cert, ok := s.PublicKey().(*gossh.Certificate)
+		if !ok {
+			log.Printf("could not get user certificate")
+			return
+		}
+
+		session, done, ok := startSession(s, cert, cfg, sessions)
+		if !ok {
+			return
+		}
+		defer done()
+
+		log.Printf("new upstream ssh session for %s (as user %s) to %s\n", cert.KeyId, s.User(), cfg.Upstream.address())
+
+		command := s.RawCommand()
+		if forced := forceCommand(s); forced != "" {
+			command = forced
+		}
+
+		client, err := cfg.Upstream.dial(s.User())
+		if err != nil {
+			log.Printf("upstream ssh session for %s failed: %s", cert.KeyId, err)
+			fmt.Fprintf(s.Stderr(), "Border0: failed to connect to upstream server\r\n")
+			s.Exit(1)
+			return
+		}
+		defer client.Close()
+
+		s.Exit(proxySession(session, client, command, ""))
+	}
+}
+
+func upstreamSftpHandler(cfg *ServerConfig, sessions *sessionCounter) ssh.SubsystemHandler {
+	return func(s ssh.Session) {
+		cert, ok := s.PublicKey().(*gossh.Certificate)
+		if !ok {
+			log.Printf("could not get user certificate")
+			s.Exit(1)
+			return
+		}
+
+		if forceCommand(s) != "" {
+			log.Printf("denied sftp session for %s: certificate forces a command", cert.KeyId)
+			s.Exit(1)
+			return
+		}
+
+		session, done, ok := startSession(s, cert, cfg, sessions)
+		if !ok {
+			return
+		}
+		defer done()
+
+		log.Printf("new upstream sftp session for %s (as user %s) to %s\n", cert.KeyId, s.User(), cfg.Upstream.address())
+
+		client, err := cfg.Upstream.dial(s.User())
+		if err != nil {
+			log.Printf("upstream sftp session for %s failed: %s", cert.KeyId, err)
+			s.Exit(1)
+			return
+		}
+		defer client.Close()
+
+		s.Exit(proxySession(session, client, "", "sftp"))
+	}
+}
+
+// proxySession runs the session on the upstream client and returns its exit status
+func proxySession(s ssh.Session, client *gossh.Client, command, subsystem string) int {
+	upstream, err := client.NewSession()
+	if err != nil {
+		log.Printf("failed to open upstream session: %s", err)
+		return 1
+	}
+	defer upstream.Close()
+
+	for _, env := range s.Environ() {
+		if key, value, ok := strings.Cut(env, "="); ok {
+			// servers reject variables they don't accept, that's not fatal
+			upstream.Setenv(key, value)
+		}
+	}
+
+	ptyReq, winCh, isPty := s.Pty()
+	if isPty {
+		if err := upstream.RequestPty(ptyReq.Term, ptyReq.Window.Height, ptyReq.Window.Width, gossh.TerminalModes{}); err != nil {
+			log.Printf("failed to request upstream pty: %s", err)
+			return 1
+		}
+
+		go func() {
+			for win := range winCh {
+				upstream.WindowChange(win.Height, win.Width)
+			}
+		}()
+	}
+
+	stdin, err := upstream.StdinPipe()
+	if err != nil {
+		log.Printf("failed to set stdin: %v\n", err)
+		return 1
+	}
+	stdout, err := upstream.StdoutPipe()
+	if err != nil {
+		log.Printf("failed to set stdout: %v\n", err)
+		return 1
+	}
+	stderr, err := upstream.StderrPipe()
+	if err != nil {
+		log.Printf("failed to set stderr: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case subsystem != "":
+		err = upstream.RequestSubsystem(subsystem)
+	case command != "":
+		err = upstream.Start(command)
+	default:
+		err = upstream.Shell()
+	}
+	if err != nil {
+		log.Printf("failed to start upstream session: %s", err)
+		return 1
+	}
+
+	go func() {
+		defer stdin.Close()
+		io.Copy(stdin, s)
+	}()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(s, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(s.Stderr(), stderr)
+	}()
+
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-s.Context().Done():
+			upstream.Close()
+		case <-exited:
+		}
+	}()
+
+	wg.Wait()
+	err = upstream.Wait()
+
+	var exitErr *gossh.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus()
+	default:
+		return 1
+	}
+}
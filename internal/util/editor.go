@@ -0,0 +1,127 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// Edit opens a file in the editor from VISUAL or EDITOR and waits until it's
+// closed. The editor can have arguments, like "code --wait"
+func Edit(path string) error {
+	args := editorCommand()
+
+	c := exec.Command(args[0], append(args[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	if err := (term.TTY{In: os.Stdin, TryDev: true}).Safe(c.Run); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return fmt.Errorf("unable to launch the editor %s, set EDITOR to your editor: %w", args[0], err)
+		}
+		return fmt.Errorf("there was a problem with the editor: %w", err)
+	}
+
+	return nil
+}
+
+// EditUntilValid opens a temporary file with the initial content in the editor
+// and parses it when the editor exits, on errors it asks to edit the file
+// again. What names the edited thing in the prompts, like "socket"
+func EditUntilValid(pattern, what string, initial []byte, parse func([]byte) error) error {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return fmt.Errorf("could not create a %s file %w", what, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(initial)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("could not write the %s file %w", what, err)
+	}
+
+	for {
+		if err := Edit(f.Name()); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			return fmt.Errorf("could not open %s file %w", what, err)
+		}
+
+		err = parse(data)
+		if err == nil {
+			return nil
+		}
+
+		fmt.Printf("⛔ %v\n", err)
+		again := true
+		if err := survey.AskOne(&survey.Confirm{Message: "Edit the " + what + " again?", Default: true}, &again); err != nil || !again {
+			return fmt.Errorf("%s not saved", what)
+		}
+	}
+}
+
+func editorCommand() []string {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			return []string{"notepad"}
+		}
+		return []string{"vi"}
+	}
+
+	// a path with spaces, like C:\Program Files\Notepad++\notepad++.exe
+	if _, err := os.Stat(editor); err == nil {
+		return []string{editor}
+	}
+
+	return splitCommand(editor)
+}
+
+// splitCommand splits a command line on spaces, except for quoted parts
+func splitCommand(command string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
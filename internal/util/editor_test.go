@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "vim", want: []string{"vim"}},
+		{command: "code --wait", want: []string{"code", "--wait"}},
+		{command: `"C:\Program Files\Notepad++\notepad++.exe" -multiInst`, want: []string{`C:\Program Files\Notepad++\notepad++.exe`, "-multiInst"}},
+		{command: "emacs  -nw 'my file'", want: []string{"emacs", "-nw", "my file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitCommand(tt.command))
+		})
+	}
+}